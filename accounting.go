@@ -0,0 +1,612 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 취득 원가 계산 방식
+const (
+	CostMethodFIFO    = "fifo"    // 먼저 매수한 수량부터 매도
+	CostMethodAverage = "average" // 이동 평균 단가
+)
+
+// 업비트 기준 일자 (KST)
+var kst = time.FixedZone("KST", 9*60*60)
+
+// Fill 구조체 - 체결 한 건 (JSON Lines)
+type Fill struct {
+	Time       time.Time `json:"time"`
+	TradeUUID  string    `json:"trade_uuid"`
+	OrderUUID  string    `json:"order_uuid"`
+	Identifier string    `json:"identifier,omitempty"`
+	Market     string    `json:"market"`
+	Side       string    `json:"side"` // bid(매수), ask(매도)
+	Price      float64   `json:"price"`
+	Volume     float64   `json:"volume"`
+	Funds      float64   `json:"funds"` // 체결 금액 (수수료 제외)
+	Fee        float64   `json:"fee"`   // 주문의 paid_fee를 체결 금액 비율로 배분
+}
+
+// Ledger 구조체 - 거래소 체결 내역을 파일에 기록하고 손익 계산에 사용
+type Ledger struct {
+	Method   string        // 취득 원가 계산 방식 (fifo, average)
+	Interval time.Duration // 체결 내역 동기화 주기
+
+	mu      sync.Mutex
+	file    *os.File
+	fills   []Fill
+	orders  map[string]bool // 체결을 기록한 주문 UUID
+	skipped map[string]bool // 체결 내역이 없어 건너뛴 주문 UUID (다시 조회하지 않음)
+}
+
+// 체결 기록 파일을 열고 기존 기록을 복원
+func openLedger(path, method string, interval time.Duration) (*Ledger, error) {
+	if method != CostMethodFIFO && method != CostMethodAverage {
+		return nil, fmt.Errorf("invalid cost method: %s", method)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create ledger directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %v", err)
+	}
+
+	ledger := &Ledger{
+		Method:   method,
+		Interval: interval,
+		file:     file,
+		orders:   make(map[string]bool),
+		skipped:  make(map[string]bool),
+	}
+
+	fills, err := readFills(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	for _, fill := range fills {
+		ledger.fills = append(ledger.fills, fill)
+		ledger.orders[fill.OrderUUID] = true
+	}
+
+	return ledger, nil
+}
+
+// JSON Lines 체결 기록 읽기 (기록 도중 종료되어 잘린 줄은 무시)
+func readFills(r io.Reader) ([]Fill, error) {
+	var fills []Fill
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var fill Fill
+		if err := json.Unmarshal(scanner.Bytes(), &fill); err != nil {
+			continue
+		}
+		fills = append(fills, fill)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fills: %v", err)
+	}
+	return fills, nil
+}
+
+// 체결 시각순 정렬 (같은 시각은 기록 순서 유지)
+func sortFills(fills []Fill) {
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+}
+
+// 주문의 체결 내역이 이미 기록되었거나 건너뛴 주문인지 확인
+func (l *Ledger) hasOrder(orderUUID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.orders[orderUUID] || l.skipped[orderUUID]
+}
+
+// 체결 내역을 기록할 수 없는 주문 표시 - 이후 동기화에서 다시 조회하지 않음
+func (l *Ledger) skip(orderUUID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.skipped[orderUUID] = true
+}
+
+// 주문 하나의 체결 내역 기록 - 디스크 동기화까지 완료된 후 반환
+func (l *Ledger) record(fills []Fill) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, fill := range fills {
+		line, err := json.Marshal(fill)
+		if err != nil {
+			return fmt.Errorf("failed to encode fill: %v", err)
+		}
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write fill: %v", err)
+		}
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync ledger: %v", err)
+	}
+
+	for _, fill := range fills {
+		l.fills = append(l.fills, fill)
+		l.orders[fill.OrderUUID] = true
+	}
+	return nil
+}
+
+// 기록된 체결 내역 복사본 (체결 시각순)
+func (l *Ledger) snapshot() []Fill {
+	l.mu.Lock()
+	fills := append([]Fill(nil), l.fills...)
+	l.mu.Unlock()
+
+	sortFills(fills)
+	return fills
+}
+
+// 체결 기록 파일 닫기
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// 주기적 체결 내역 동기화 - ctx가 취소될 때까지 실행
+func (l *Ledger) run(ctx context.Context, bot *TradingBot) {
+	if l.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.sync(ctx, bot); err != nil && ctx.Err() == nil {
+			bot.logger.Error("Fill sync failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 완료/취소 주문 중 체결 내역이 기록되지 않은 주문의 체결을 가져와 기록
+func (l *Ledger) sync(ctx context.Context, bot *TradingBot) (int, error) {
+	recorded := 0
+	for _, state := range []string{"done", "cancel"} {
+		orders, err := l.unrecordedOrders(ctx, bot, state)
+		if err != nil {
+			return recorded, fmt.Errorf("failed to fetch %s orders: %v", state, err)
+		}
+
+		// 최신순 목록이므로 오래된 주문부터 기록 (같은 시각 체결의 순서 유지)
+		for i := len(orders) - 1; i >= 0; i-- {
+			summary := orders[i]
+
+			// 목록 조회에는 체결 내역이 없으므로 주문별로 다시 조회
+			order, err := bot.getOrder(ctx, summary.UUID)
+			if err != nil {
+				return recorded, fmt.Errorf("failed to fetch order %s: %v", summary.UUID, err)
+			}
+			// 체결 내역이 없는 주문은 기록하지 않고 넘어감 (동기화가 멈추지 않도록)
+			if len(order.Trades) == 0 {
+				bot.logger.Error("Order %s reports executed volume %s but has no trades, skipping", order.UUID, summary.ExecutedVolume)
+				l.skip(order.UUID)
+				continue
+			}
+			fills, err := fillsFromOrder(order)
+			if err != nil {
+				return recorded, err
+			}
+			if err := l.record(fills); err != nil {
+				return recorded, err
+			}
+			recorded += len(fills)
+		}
+	}
+
+	if recorded > 0 {
+		bot.logger.Info("Recorded %d new fills", recorded)
+		// 체결로 바뀐 잔고를 보유 현황에 반영
+		if err := bot.refreshPositions(ctx); err != nil {
+			bot.logger.Error("Position refresh after fills failed: %v", err)
+		}
+	}
+	return recorded, nil
+}
+
+// 체결 수량이 있지만 장부에 없는 주문 목록 (최신순)
+// 페이지 전체가 이미 기록했거나 기록할 체결이 없는 주문이면 그 이전 이력도 처리된 것으로 보고 조회를 멈춘다.
+// 처음 동기화할 때만 전체 이력을 조회하고, 이후에는 보통 첫 페이지에서 끝난다.
+func (l *Ledger) unrecordedOrders(ctx context.Context, bot *TradingBot, state string) ([]Order, error) {
+	var orders []Order
+	for page := 1; ; page++ {
+		batch, err := bot.getOrdersPage(ctx, state, page)
+		if err != nil {
+			return nil, err
+		}
+
+		fresh := 0
+		for _, order := range batch {
+			executed, _ := strconv.ParseFloat(order.ExecutedVolume, 64)
+			if executed > 0 && !l.hasOrder(order.UUID) {
+				orders = append(orders, order)
+				fresh++
+			}
+		}
+		if len(batch) < ordersPageSize || fresh == 0 {
+			return orders, nil
+		}
+	}
+}
+
+// 주문 조회 결과를 체결 목록으로 변환 (paid_fee는 체결 금액 비율로 배분)
+func fillsFromOrder(order *Order) ([]Fill, error) {
+	if len(order.Trades) == 0 {
+		return nil, fmt.Errorf("order %s has no trades", order.UUID)
+	}
+	paidFee, err := strconv.ParseFloat(order.PaidFee, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paid_fee for order %s: %v", order.UUID, err)
+	}
+
+	fills := make([]Fill, 0, len(order.Trades))
+	totalFunds := 0.0
+	for _, trade := range order.Trades {
+		price, err := strconv.ParseFloat(trade.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trade price for order %s: %v", order.UUID, err)
+		}
+		volume, err := strconv.ParseFloat(trade.Volume, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trade volume for order %s: %v", order.UUID, err)
+		}
+		funds, err := strconv.ParseFloat(trade.Funds, 64)
+		if err != nil {
+			funds = price * volume
+		}
+		at, err := time.Parse(time.RFC3339, trade.CreatedAt)
+		if err != nil {
+			at, _ = time.Parse(time.RFC3339, order.CreatedAt)
+		}
+
+		fills = append(fills, Fill{
+			Time:       at,
+			TradeUUID:  trade.UUID,
+			OrderUUID:  order.UUID,
+			Identifier: order.Identifier,
+			Market:     order.Market,
+			Side:       order.Side,
+			Price:      price,
+			Volume:     volume,
+			Funds:      funds,
+		})
+		totalFunds += funds
+	}
+
+	for i := range fills {
+		if totalFunds > 0 {
+			fills[i].Fee = paidFee * fills[i].Funds / totalFunds
+		} else {
+			fills[i].Fee = paidFee / float64(len(fills))
+		}
+	}
+	return fills, nil
+}
+
+// costLot 구조체 - 아직 매도하지 않은 매수 수량과 수수료 포함 취득 원가
+type costLot struct {
+	volume float64
+	cost   float64
+}
+
+// PositionPnL 구조체 - 마켓별 손익
+type PositionPnL struct {
+	Market          string  `json:"market"`
+	Volume          float64 `json:"volume"`     // 장부상 보유 수량
+	CostBasis       float64 `json:"cost_basis"` // 보유 수량의 수수료 포함 취득 원가
+	AvgCost         float64 `json:"avg_cost"`
+	Price           float64 `json:"price"` // 현재가 (조회 실패 시 0)
+	MarketValue     float64 `json:"market_value"`
+	Realized        float64 `json:"realized"`   // 실현 손익 (매수/매도 수수료 차감)
+	Unrealized      float64 `json:"unrealized"` // 평가 손익 (현재가 기준)
+	Fees            float64 `json:"fees"`
+	UnmatchedVolume float64 `json:"unmatched_volume,omitempty"` // 장부에 매수 기록이 없는 매도 수량 (손익 0으로 처리)
+
+	lots []costLot
+}
+
+// PnLSummary 구조체 - 기간별 손익 요약
+type PnLSummary struct {
+	Start    time.Time `json:"start"`
+	Realized float64   `json:"realized"`
+	Fees     float64   `json:"fees"`
+	Turnover float64   `json:"turnover"` // 체결 금액 합계
+	Buys     int       `json:"buys"`
+	Sells    int       `json:"sells"`
+}
+
+// PnLReport 구조체 - GET /api/pnl 응답
+type PnLReport struct {
+	Time       time.Time     `json:"time"`
+	Method     string        `json:"method"`
+	Fills      int           `json:"fills"`
+	Realized   float64       `json:"realized"`
+	Unrealized float64       `json:"unrealized"`
+	Fees       float64       `json:"fees"`
+	Cash       float64       `json:"cash"`     // KRW 잔고 (주문 중 묶인 금액 포함)
+	Equity     float64       `json:"equity"`   // 전체 계좌의 KRW 평가 금액
+	Unpriced   []string      `json:"unpriced"` // KRW 마켓 시세가 없어 평가 금액에서 제외한 통화
+	Positions  []PositionPnL `json:"positions"`
+	Daily      []PnLSummary  `json:"daily"`
+	Weekly     []PnLSummary  `json:"weekly"`
+}
+
+// 체결 내역으로 마켓별 실현 손익과 보유 원가, 일/주별 요약 계산 (KRW 마켓만)
+func computePnL(fills []Fill, method string) (map[string]*PositionPnL, []PnLSummary, []PnLSummary) {
+	positions := make(map[string]*PositionPnL)
+	daily := make(map[time.Time]*PnLSummary)
+	weekly := make(map[time.Time]*PnLSummary)
+
+	for _, fill := range fills {
+		if quote, _ := splitMarket(fill.Market); quote != "KRW" {
+			continue
+		}
+		position, ok := positions[fill.Market]
+		if !ok {
+			position = &PositionPnL{Market: fill.Market}
+			positions[fill.Market] = position
+		}
+
+		realized := position.apply(fill, method)
+
+		for _, bucket := range []struct {
+			summaries map[time.Time]*PnLSummary
+			start     time.Time
+		}{
+			{daily, startOfDay(fill.Time)},
+			{weekly, startOfWeek(fill.Time)},
+		} {
+			summary, ok := bucket.summaries[bucket.start]
+			if !ok {
+				summary = &PnLSummary{Start: bucket.start}
+				bucket.summaries[bucket.start] = summary
+			}
+			summary.Realized += realized
+			summary.Fees += fill.Fee
+			summary.Turnover += fill.Funds
+			if fill.Side == "bid" {
+				summary.Buys++
+			} else {
+				summary.Sells++
+			}
+		}
+	}
+
+	for _, position := range positions {
+		for _, lot := range position.lots {
+			position.Volume += lot.volume
+			position.CostBasis += lot.cost
+		}
+		if position.Volume > 0 {
+			position.AvgCost = position.CostBasis / position.Volume
+		}
+	}
+
+	return positions, sortSummaries(daily), sortSummaries(weekly)
+}
+
+// 체결 한 건을 포지션에 반영하고 실현 손익 반환 (매수 체결은 0)
+func (p *PositionPnL) apply(fill Fill, method string) float64 {
+	p.Fees += fill.Fee
+	if fill.Side == "bid" {
+		lot := costLot{volume: fill.Volume, cost: fill.Funds + fill.Fee}
+		if method == CostMethodAverage && len(p.lots) > 0 {
+			p.lots[0].volume += lot.volume
+			p.lots[0].cost += lot.cost
+		} else {
+			p.lots = append(p.lots, lot)
+		}
+		return 0
+	}
+
+	cost, unmatched := p.consume(fill.Volume)
+	// 매수 기록이 없는 수량은 매도 금액을 원가로 보아 손익 0
+	if unmatched > 0 {
+		cost += fill.Funds * unmatched / fill.Volume
+		p.UnmatchedVolume += unmatched
+	}
+	realized := fill.Funds - fill.Fee - cost
+	p.Realized += realized
+	return realized
+}
+
+// 보유 수량에서 먼저 매수한 순서대로 차감하고 차감한 원가와 부족한 수량 반환
+func (p *PositionPnL) consume(volume float64) (cost, unmatched float64) {
+	for volume > 0 && len(p.lots) > 0 {
+		lot := &p.lots[0]
+		if lot.volume <= volume {
+			cost += lot.cost
+			volume -= lot.volume
+			p.lots = p.lots[1:]
+			continue
+		}
+		share := lot.cost * volume / lot.volume
+		cost += share
+		lot.cost -= share
+		lot.volume -= volume
+		volume = 0
+	}
+	// 부동소수점 오차로 남은 극소량은 무시
+	if volume < 1e-12 {
+		volume = 0
+	}
+	return cost, volume
+}
+
+// KST 기준 해당 일자의 시작 시각
+func startOfDay(t time.Time) time.Time {
+	t = t.In(kst)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, kst)
+}
+
+// KST 기준 해당 주(월요일 시작)의 시작 시각
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// 기간별 요약을 최신순으로 정렬
+func sortSummaries(summaries map[time.Time]*PnLSummary) []PnLSummary {
+	result := make([]PnLSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.After(result[j].Start) })
+	return result
+}
+
+// 체결 장부와 거래소 잔고/시세로 손익 보고서 생성
+// market을 지정하면 해당 마켓의 체결만 집계하고, 평가 금액은 항상 전체 계좌 기준이다.
+func (bot *TradingBot) pnlReport(ctx context.Context, market string, limit int) (*PnLReport, error) {
+	fills := bot.ledger.snapshot()
+	if market != "" {
+		filtered := fills[:0]
+		for _, fill := range fills {
+			if fill.Market == market {
+				filtered = append(filtered, fill)
+			}
+		}
+		fills = filtered
+	}
+	positions, daily, weekly := computePnL(fills, bot.ledger.Method)
+
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+	allMarkets, err := bot.fetchAllMarkets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch markets: %v", err)
+	}
+	listed := make(map[string]bool, len(allMarkets))
+	for _, m := range allMarkets {
+		listed[m.Market] = true
+	}
+
+	// 보유 통화와 장부상 포지션의 KRW 마켓 시세 조회
+	var pricedMarkets []string
+	seen := make(map[string]bool)
+	addMarket := func(m string) {
+		if listed[m] && !seen[m] {
+			seen[m] = true
+			pricedMarkets = append(pricedMarkets, m)
+		}
+	}
+	for _, account := range accounts {
+		if account.Currency != "KRW" {
+			addMarket("KRW-" + account.Currency)
+		}
+	}
+	for m := range positions {
+		addMarket(m)
+	}
+	prices := make(map[string]float64)
+	if len(pricedMarkets) > 0 {
+		tickers, err := bot.fetchTickers(ctx, pricedMarkets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tickers: %v", err)
+		}
+		for _, ticker := range tickers {
+			prices[ticker.Market] = ticker.TradePrice
+		}
+	}
+
+	report := &PnLReport{
+		Time:      time.Now(),
+		Method:    bot.ledger.Method,
+		Fills:     len(fills),
+		Unpriced:  []string{},
+		Positions: make([]PositionPnL, 0, len(positions)),
+		Daily:     limitSummaries(daily, limit),
+		Weekly:    limitSummaries(weekly, limit),
+	}
+
+	// 전체 계좌 평가 금액 (주문 중 묶인 수량 포함)
+	for _, account := range accounts {
+		available, locked, err := accountBalance([]Account{account}, account.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s balance: %v", account.Currency, err)
+		}
+		if account.Currency == "KRW" {
+			report.Cash += available + locked
+			continue
+		}
+		price, ok := prices["KRW-"+account.Currency]
+		if !ok {
+			report.Unpriced = append(report.Unpriced, account.Currency)
+			continue
+		}
+		report.Equity += (available + locked) * price
+	}
+	report.Equity += report.Cash
+
+	for _, position := range positions {
+		position.Price = prices[position.Market]
+		if position.Price > 0 {
+			position.MarketValue = position.Volume * position.Price
+			position.Unrealized = position.MarketValue - position.CostBasis
+		}
+		report.Realized += position.Realized
+		report.Unrealized += position.Unrealized
+		report.Fees += position.Fees
+		report.Positions = append(report.Positions, *position)
+	}
+	sort.Slice(report.Positions, func(i, j int) bool { return report.Positions[i].Market < report.Positions[j].Market })
+	sort.Strings(report.Unpriced)
+
+	return report, nil
+}
+
+// 최신 limit개 기간만 반환
+func limitSummaries(summaries []PnLSummary, limit int) []PnLSummary {
+	if limit > 0 && len(summaries) > limit {
+		return summaries[:limit]
+	}
+	return summaries
+}
+
+// GET /api/pnl 쿼리 파라미터 파싱 (market, limit)
+func parsePnLQuery(c *gin.Context) (market string, limit int, err error) {
+	market = strings.ToUpper(c.Query("market"))
+	limit = 30
+
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return "", 0, fmt.Errorf("invalid limit: %s", value)
+		}
+	}
+	return market, limit, nil
+}
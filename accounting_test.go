@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"trading-bot/upbitmock"
+)
+
+// 시장가 매수 주문을 count건 체결
+func placeFilledOrders(t *testing.T, bot *TradingBot, count int) []string {
+	t.Helper()
+	uuids := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		order, err := bot.placeOrder(context.Background(), OrderRequest{
+			Market:     "KRW-BTC",
+			Side:       "bid",
+			OrdType:    OrderTypePrice,
+			Price:      10000,
+			Identifier: "tb-ledger-" + uuid.NewString(),
+		})
+		if err != nil {
+			t.Fatalf("placeOrder %d failed: %v", i, err)
+		}
+		uuids = append(uuids, order.UUID)
+	}
+	return uuids
+}
+
+func TestLedgerSyncPaginatesOrderHistory(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 10000000, 0)
+
+	// 완료/취소 주문 목록 조회 횟수
+	var pages atomic.Int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if state := r.URL.Query().Get("state"); r.URL.Path == "/v1/orders" && (state == "done" || state == "cancel") {
+			pages.Add(1)
+		}
+		mock.ServeHTTP(w, r)
+	})
+
+	bot := newMockBot(t, handler, nil)
+	count := ordersPageSize + ordersPageSize/2
+	placeFilledOrders(t, bot, count)
+
+	ctx := context.Background()
+	recorded, err := bot.ledger.sync(ctx, bot)
+	if err != nil {
+		t.Fatalf("ledger sync failed: %v", err)
+	}
+	if recorded != count {
+		t.Fatalf("recorded = %d, want %d orders across pages", recorded, count)
+	}
+
+	// 이미 기록한 이력은 다시 끝까지 조회하지 않음 (done, cancel 첫 페이지만)
+	pages.Store(0)
+	if recorded, err := bot.ledger.sync(ctx, bot); err != nil || recorded != 0 {
+		t.Fatalf("second sync = %d, %v, want nothing new", recorded, err)
+	}
+	if got := pages.Load(); got != 2 {
+		t.Fatalf("second sync fetched %d pages, want 2", got)
+	}
+
+	// 새 주문은 첫 페이지에서 찾고, 모두 기록된 다음 페이지에서 멈춤
+	placeFilledOrders(t, bot, 5)
+	pages.Store(0)
+	if recorded, err := bot.ledger.sync(ctx, bot); err != nil || recorded != 5 {
+		t.Fatalf("third sync = %d, %v, want 5 new fills", recorded, err)
+	}
+	if got := pages.Load(); got != 3 {
+		t.Fatalf("third sync fetched %d pages, want 3", got)
+	}
+}
+
+func TestLedgerSyncSkipsOrderWithoutTrades(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 10000000, 0)
+
+	// 특정 주문의 개별 조회 응답에서 체결 내역 제거
+	var noTrades string
+	lookups := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/order" || r.URL.Query().Get("uuid") != noTrades {
+			mock.ServeHTTP(w, r)
+			return
+		}
+		lookups++
+		rec := httptest.NewRecorder()
+		mock.ServeHTTP(rec, r)
+		var order map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &order); err != nil {
+			t.Errorf("failed to decode order: %v", err)
+		}
+		delete(order, "trades")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(order)
+	})
+
+	bot := newMockBot(t, handler, nil)
+	uuids := placeFilledOrders(t, bot, 3)
+	noTrades = uuids[0]
+
+	ctx := context.Background()
+	recorded, err := bot.ledger.sync(ctx, bot)
+	if err != nil {
+		t.Fatalf("ledger sync failed: %v", err)
+	}
+	if recorded != 2 {
+		t.Fatalf("recorded = %d, want the 2 orders with trades", recorded)
+	}
+
+	// 건너뛴 주문은 다시 조회하지 않음
+	if _, err := bot.ledger.sync(ctx, bot); err != nil {
+		t.Fatalf("second ledger sync failed: %v", err)
+	}
+	if lookups != 1 {
+		t.Fatalf("order without trades was fetched %d times, want 1", lookups)
+	}
+}
+
+// 테스트용 체결 (체결 금액 = 가격 x 수량)
+func testFill(market, side string, volume, price, fee float64) Fill {
+	return Fill{
+		Time:   time.Date(2024, 3, 4, 1, 0, 0, 0, time.UTC),
+		Market: market,
+		Side:   side,
+		Price:  price,
+		Volume: volume,
+		Funds:  price * volume,
+		Fee:    fee,
+	}
+}
+
+func TestComputePnL(t *testing.T) {
+	twoBuys := []Fill{
+		testFill("KRW-BTC", "bid", 1, 100, 1),
+		testFill("KRW-BTC", "bid", 1, 200, 2),
+	}
+
+	tests := []struct {
+		name          string
+		fills         []Fill
+		method        string
+		wantRealized  float64
+		wantVolume    float64
+		wantCost      float64
+		wantFees      float64
+		wantUnmatched float64
+	}{
+		{
+			// 원가 101 (매수 수수료 포함), 매도 300 - 수수료 3
+			name:         "fifo sells the oldest lot",
+			fills:        append(append([]Fill{}, twoBuys...), testFill("KRW-BTC", "ask", 1, 300, 3)),
+			method:       CostMethodFIFO,
+			wantRealized: 196,
+			wantVolume:   1,
+			wantCost:     202,
+			wantFees:     6,
+		},
+		{
+			// 평균 원가 303 / 2
+			name:         "average cost on the same fills",
+			fills:        append(append([]Fill{}, twoBuys...), testFill("KRW-BTC", "ask", 1, 300, 3)),
+			method:       CostMethodAverage,
+			wantRealized: 145.5,
+			wantVolume:   1,
+			wantCost:     151.5,
+			wantFees:     6,
+		},
+		{
+			// 첫 로트 전부(101)와 두 번째 로트 절반(101)
+			name:         "fifo sale spanning lots",
+			fills:        append(append([]Fill{}, twoBuys...), testFill("KRW-BTC", "ask", 1.5, 300, 4.5)),
+			method:       CostMethodFIFO,
+			wantRealized: 243.5,
+			wantVolume:   0.5,
+			wantCost:     101,
+			wantFees:     7.5,
+		},
+		{
+			name:         "average sale spanning lots",
+			fills:        append(append([]Fill{}, twoBuys...), testFill("KRW-BTC", "ask", 1.5, 300, 4.5)),
+			method:       CostMethodAverage,
+			wantRealized: 218.25,
+			wantVolume:   0.5,
+			wantCost:     75.75,
+			wantFees:     7.5,
+		},
+		{
+			// 로트 일부만 두 번 매도: 원가 50.5, 101
+			name: "partial lot consumption",
+			fills: []Fill{
+				testFill("KRW-BTC", "bid", 2, 100, 2),
+				testFill("KRW-BTC", "ask", 0.5, 150, 0.75),
+				testFill("KRW-BTC", "ask", 1, 120, 1.2),
+			},
+			method:       CostMethodFIFO,
+			wantRealized: 23.75 + 17.8,
+			wantVolume:   0.5,
+			wantCost:     50.5,
+			wantFees:     3.95,
+		},
+		{
+			// 장부에 없는 수량은 매도 금액을 원가로 보아 수수료만 손실
+			name: "sale without a recorded buy",
+			fills: []Fill{
+				testFill("KRW-BTC", "bid", 1, 100, 1),
+				testFill("KRW-BTC", "ask", 2, 150, 3),
+			},
+			method:        CostMethodFIFO,
+			wantRealized:  300 - 3 - 101 - 150,
+			wantFees:      4,
+			wantUnmatched: 1,
+		},
+		{
+			name: "non-KRW markets are ignored",
+			fills: []Fill{
+				testFill("KRW-BTC", "bid", 1, 100, 1),
+				testFill("BTC-ETH", "ask", 1, 0.05, 0.0001),
+			},
+			method:     CostMethodFIFO,
+			wantVolume: 1,
+			wantCost:   101,
+			wantFees:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions, _, _ := computePnL(tt.fills, tt.method)
+			if _, ok := positions["BTC-ETH"]; ok {
+				t.Fatalf("non-KRW market was included")
+			}
+			position := positions["KRW-BTC"]
+			if position == nil {
+				t.Fatalf("no KRW-BTC position")
+			}
+			if !approxEqual(position.Realized, tt.wantRealized) || !approxEqual(position.Volume, tt.wantVolume) ||
+				!approxEqual(position.CostBasis, tt.wantCost) || !approxEqual(position.Fees, tt.wantFees) ||
+				!approxEqual(position.UnmatchedVolume, tt.wantUnmatched) {
+				t.Fatalf("position = %+v, want realized %f, volume %f, cost %f, fees %f, unmatched %f",
+					position, tt.wantRealized, tt.wantVolume, tt.wantCost, tt.wantFees, tt.wantUnmatched)
+			}
+			if tt.wantVolume > 0 && !approxEqual(position.AvgCost, tt.wantCost/tt.wantVolume) {
+				t.Fatalf("avg cost = %f, want %f", position.AvgCost, tt.wantCost/tt.wantVolume)
+			}
+		})
+	}
+}
+
+func TestComputePnLSummariesUseKST(t *testing.T) {
+	buy := testFill("KRW-BTC", "bid", 1, 100, 1)
+	buy.Time = time.Date(2024, 3, 3, 14, 0, 0, 0, time.UTC) // 일요일 23:00 KST
+	sell := testFill("KRW-BTC", "ask", 1, 150, 1.5)
+	sell.Time = time.Date(2024, 3, 3, 16, 0, 0, 0, time.UTC) // 월요일 01:00 KST
+
+	_, daily, weekly := computePnL([]Fill{buy, sell}, CostMethodFIFO)
+	if len(daily) != 2 || len(weekly) != 2 {
+		t.Fatalf("daily = %+v, weekly = %+v, want the fills on separate KST days and weeks", daily, weekly)
+	}
+	// 최신순
+	for _, summaries := range [][]PnLSummary{daily, weekly} {
+		if summaries[0].Sells != 1 || !approxEqual(summaries[0].Realized, 150-1.5-101) {
+			t.Fatalf("latest summary = %+v, want the sale's realized PnL", summaries[0])
+		}
+		if summaries[1].Buys != 1 || summaries[1].Realized != 0 || !approxEqual(summaries[1].Fees, 1) {
+			t.Fatalf("earlier summary = %+v, want the buy only", summaries[1])
+		}
+	}
+	if got := daily[0].Start; !got.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, kst)) {
+		t.Fatalf("latest day starts at %v", got)
+	}
+}
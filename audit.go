@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditEntry 구조체 - 제어 API 호출 감사 기록
+// Hash는 PrevHash를 포함한 나머지 필드의 SHA256 해시로, 기록을 수정하면 이후 체인이 모두 어긋난다.
+type AuditEntry struct {
+	Seq       int64                  `json:"seq"`
+	Time      time.Time              `json:"time"`
+	Principal string                 `json:"principal"`
+	Role      string                 `json:"role"`
+	Action    string                 `json:"action"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Status    int                    `json:"status"`
+	Result    string                 `json:"result"`
+	SourceIP  string                 `json:"source_ip"`
+	PrevHash  string                 `json:"prev_hash"`
+	Hash      string                 `json:"hash,omitempty"`
+}
+
+// AuditLog 구조체 - append-only 해시 체인 감사 로그
+type AuditLog struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	seq      int64
+	lastHash string
+}
+
+// 감사 기록 필터
+type AuditQuery struct {
+	Principal string
+	Action    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// 감사 로그 파일을 열고 마지막 해시를 복원
+func openAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %v", err)
+	}
+
+	auditLog := &AuditLog{path: path}
+	entries, _, err := auditLog.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		auditLog.seq = last.Seq
+		auditLog.lastHash = last.Hash
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	auditLog.file = file
+
+	return auditLog, nil
+}
+
+// 감사 기록 해시 계산 (Hash 필드는 제외)
+func (e AuditEntry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// 감사 기록 추가
+func (l *AuditLog) append(entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.seq + 1
+	entry.PrevHash = l.lastHash
+	hash, err := entry.computeHash()
+	if err != nil {
+		return fmt.Errorf("failed to hash audit entry: %v", err)
+	}
+	entry.Hash = hash
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %v", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %v", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %v", err)
+	}
+
+	l.seq = entry.Seq
+	l.lastHash = entry.Hash
+	return nil
+}
+
+// 감사 로그 전체를 읽고 해시 체인 검증 (처음 어긋난 seq 반환, 0이면 정상)
+func (l *AuditLog) read() ([]AuditEntry, int64, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	var brokenAt int64
+	prevHash := ""
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			if brokenAt == 0 {
+				brokenAt = int64(len(entries) + 1)
+			}
+			continue
+		}
+		if brokenAt == 0 {
+			hash, err := entry.computeHash()
+			if err != nil || entry.PrevHash != prevHash || entry.Hash != hash {
+				brokenAt = entry.Seq
+			}
+		}
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read audit log: %v", err)
+	}
+
+	return entries, brokenAt, nil
+}
+
+// 조건에 맞는 최근 감사 기록 조회 (최신순)
+func (l *AuditLog) query(q AuditQuery) ([]AuditEntry, int64, error) {
+	l.mu.Lock()
+	entries, brokenAt, err := l.read()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]AuditEntry, 0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if q.Principal != "" && entry.Principal != q.Principal {
+			continue
+		}
+		if q.Action != "" && !strings.Contains(entry.Action, q.Action) {
+			continue
+		}
+		if !q.From.IsZero() && entry.Time.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && entry.Time.After(q.To) {
+			continue
+		}
+		result = append(result, entry)
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+	}
+
+	return result, brokenAt, nil
+}
+
+// 감사 로그 파일 닫기
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// 미들웨어: 인증된 제어 API 호출 기록
+func (l *AuditLog) middleware(logger *Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params := requestAuditParams(c)
+
+		c.Next()
+
+		entry := AuditEntry{
+			Time:     time.Now(),
+			Action:   c.Request.Method + " " + c.FullPath(),
+			Params:   params,
+			Status:   c.Writer.Status(),
+			Result:   "success",
+			SourceIP: c.ClientIP(), // TRUSTED_PROXIES가 아니면 X-Forwarded-For 대신 접속 IP
+		}
+		if entry.Status >= http.StatusBadRequest {
+			entry.Result = "failure"
+		}
+		if value, ok := c.Get("claims"); ok {
+			if claims, ok := value.(*OperatorClaims); ok {
+				entry.Principal = claims.Subject
+				entry.Role = claims.Role
+			}
+		}
+
+		if err := l.append(entry); err != nil {
+			logger.Error("Failed to write audit entry: %v", err)
+		}
+	}
+}
+
+// 요청의 쿼리/경로/JSON 본문 파라미터 수집 (비밀 값은 가림)
+func requestAuditParams(c *gin.Context) map[string]interface{} {
+	params := make(map[string]interface{})
+
+	for key, values := range c.Request.URL.Query() {
+		params[key] = strings.Join(values, ",")
+	}
+	for _, param := range c.Params {
+		params[param.Key] = param.Value
+	}
+
+	if c.Request.Body != nil && strings.HasPrefix(c.ContentType(), "application/json") {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64*1024))
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err == nil && len(body) > 0 {
+			var fields map[string]interface{}
+			if json.Unmarshal(body, &fields) == nil {
+				for key, value := range fields {
+					params[key] = value
+				}
+			}
+		}
+	}
+
+	for key := range params {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.Contains(lower, "token") {
+			params[key] = "[REDACTED]"
+		}
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// GET /api/audit 쿼리 파라미터 파싱
+func parseAuditQuery(c *gin.Context) (AuditQuery, error) {
+	q := AuditQuery{
+		Principal: c.Query("principal"),
+		Action:    c.Query("action"),
+		Limit:     100,
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return q, fmt.Errorf("invalid limit: %s", value)
+		}
+		q.Limit = limit
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return q, fmt.Errorf("invalid from: %s", value)
+		}
+		q.From = from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return q, fmt.Errorf("invalid to: %s", value)
+		}
+		q.To = to
+	}
+
+	return q, nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestAuditLog(t *testing.T) *AuditLog {
+	t.Helper()
+	audit, err := openAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("openAuditLog failed: %v", err)
+	}
+	t.Cleanup(func() { audit.Close() })
+	return audit
+}
+
+// 기준 시각부터 1분 간격으로 감사 기록 추가
+func appendAuditEntries(t *testing.T, audit *AuditLog, base time.Time, entries ...AuditEntry) {
+	t.Helper()
+	for i, entry := range entries {
+		entry.Time = base.Add(time.Duration(i) * time.Minute)
+		if err := audit.append(entry); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+}
+
+func TestAuditChainDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(lines []string) []string
+		wantBroken int64
+	}{
+		{
+			name:       "untouched",
+			tamper:     func(lines []string) []string { return lines },
+			wantBroken: 0,
+		},
+		{
+			name: "edited line",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"principal":"bob"`, `"principal":"mallory"`, 1)
+				return lines
+			},
+			wantBroken: 2,
+		},
+		{
+			// 수정한 기록의 해시를 다시 계산해도 다음 기록의 prev_hash와 어긋남
+			name: "edited line with recomputed hash",
+			tamper: func(lines []string) []string {
+				var entry AuditEntry
+				json.Unmarshal([]byte(lines[1]), &entry)
+				entry.Status = 403
+				entry.Hash, _ = entry.computeHash()
+				line, _ := json.Marshal(entry)
+				lines[1] = string(line)
+				return lines
+			},
+			wantBroken: 3,
+		},
+		{
+			name: "deleted line",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantBroken: 3,
+		},
+		{
+			name: "corrupted line",
+			tamper: func(lines []string) []string {
+				lines[2] = "{not json"
+				return lines
+			},
+			wantBroken: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := newTestAuditLog(t)
+			appendAuditEntries(t, audit, time.Now(),
+				AuditEntry{Principal: "alice", Action: "POST /api/start", Status: 200},
+				AuditEntry{Principal: "bob", Action: "GET /api/status", Status: 200},
+				AuditEntry{Principal: "alice", Action: "POST /api/stop", Status: 200},
+				AuditEntry{Principal: "alice", Action: "GET /api/pnl", Status: 200},
+			)
+
+			data, err := os.ReadFile(audit.path)
+			if err != nil {
+				t.Fatalf("failed to read audit log: %v", err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+			if err := os.WriteFile(audit.path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+				t.Fatalf("failed to rewrite audit log: %v", err)
+			}
+
+			_, brokenAt, err := audit.query(AuditQuery{})
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if brokenAt != tt.wantBroken {
+				t.Fatalf("broken at = %d, want %d", brokenAt, tt.wantBroken)
+			}
+		})
+	}
+}
+
+func TestAuditLogResumesChainAfterReopen(t *testing.T) {
+	audit := newTestAuditLog(t)
+	appendAuditEntries(t, audit, time.Now(), AuditEntry{Principal: "alice", Action: "POST /api/start"})
+	audit.Close()
+
+	reopened, err := openAuditLog(audit.path)
+	if err != nil {
+		t.Fatalf("openAuditLog failed: %v", err)
+	}
+	defer reopened.Close()
+	appendAuditEntries(t, reopened, time.Now(), AuditEntry{Principal: "alice", Action: "POST /api/stop"})
+
+	entries, brokenAt, err := reopened.query(AuditQuery{})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if brokenAt != 0 || len(entries) != 2 || entries[0].Seq != 2 {
+		t.Fatalf("entries = %+v, broken at %d, want an intact chain of 2", entries, brokenAt)
+	}
+}
+
+func TestAuditQueryFilters(t *testing.T) {
+	audit := newTestAuditLog(t)
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	appendAuditEntries(t, audit, base,
+		AuditEntry{Principal: "alice", Action: "POST /api/start"},   // 09:00
+		AuditEntry{Principal: "bob", Action: "GET /api/status"},     // 09:01
+		AuditEntry{Principal: "alice", Action: "POST /api/stop"},    // 09:02
+		AuditEntry{Principal: "alice", Action: "GET /api/status"},   // 09:03
+		AuditEntry{Principal: "bob", Action: "POST /api/rebalance"}, // 09:04
+	)
+
+	tests := []struct {
+		name  string
+		query AuditQuery
+		want  []int64 // 최신순 seq
+	}{
+		{"all", AuditQuery{}, []int64{5, 4, 3, 2, 1}},
+		{"principal", AuditQuery{Principal: "alice"}, []int64{4, 3, 1}},
+		{"action substring", AuditQuery{Action: "status"}, []int64{4, 2}},
+		{"principal and action", AuditQuery{Principal: "bob", Action: "POST"}, []int64{5}},
+		{"from is inclusive", AuditQuery{From: base.Add(3 * time.Minute)}, []int64{5, 4}},
+		{"to is inclusive", AuditQuery{To: base.Add(time.Minute)}, []int64{2, 1}},
+		{"range", AuditQuery{From: base.Add(time.Minute), To: base.Add(3 * time.Minute)}, []int64{4, 3, 2}},
+		{"limit keeps newest", AuditQuery{Limit: 2}, []int64{5, 4}},
+		{"no match", AuditQuery{Principal: "carol"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, _, err := audit.query(tt.query)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			var got []int64
+			for _, entry := range entries {
+				got = append(got, entry.Seq)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("seqs = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("seqs = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAuditQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    AuditQuery
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: AuditQuery{Limit: 100}},
+		{name: "filters", query: "principal=alice&action=start&limit=5", want: AuditQuery{Principal: "alice", Action: "start", Limit: 5}},
+		{name: "time range", query: "from=2024-03-01T00:00:00Z&to=2024-03-01T09:00:00%2B09:00", want: AuditQuery{Limit: 100, From: from, To: from}},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "non-numeric limit", query: "limit=ten", wantErr: true},
+		{name: "date without time", query: "from=2024-03-01", wantErr: true},
+		{name: "invalid to", query: "to=yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/audit?"+tt.query, nil)
+
+			got, err := parseAuditQuery(c)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAuditQuery(%q) succeeded, want an error", tt.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAuditQuery(%q) failed: %v", tt.query, err)
+			}
+			if got.Principal != tt.want.Principal || got.Action != tt.want.Action || got.Limit != tt.want.Limit ||
+				!got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) {
+				t.Fatalf("query = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditMiddlewareRecordsRemoteIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	audit := newTestAuditLog(t)
+	r := gin.New()
+	if err := setTrustedProxies(r, ""); err != nil {
+		t.Fatalf("setTrustedProxies failed: %v", err)
+	}
+	r.GET("/api/status", audit.middleware(&Logger{}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, _, err := audit.query(AuditQuery{})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	// httptest 요청의 접속 IP는 192.0.2.1
+	if len(entries) != 1 || entries[0].SourceIP != "192.0.2.1" {
+		t.Fatalf("entries = %+v, want source IP 192.0.2.1 instead of the forged header", entries)
+	}
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 운영자 권한
+const (
+	RoleViewer   = "viewer"   // 상태 조회만 가능
+	RoleOperator = "operator" // 거래 시작/중지 등 제어 가능
+)
+
+// 토큰 유효 기간
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 24 * time.Hour
+)
+
+// 비밀번호 해시 파라미터 (PBKDF2-HMAC-SHA256)
+const (
+	passwordHashPrefix     = "pbkdf2-sha256"
+	passwordHashIterations = 210000
+)
+
+// 로그인 실패 시 IP별 대기 시간 (허용 횟수를 넘으면 실패할 때마다 두 배로 증가)
+const (
+	loginFreeAttempts = 3
+	loginBackoffBase  = time.Second
+	loginBackoffMax   = 5 * time.Minute
+)
+
+// Principal 구조체 - 제어 API 사용자 또는 API 키
+type Principal struct {
+	Name       string
+	Role       string
+	SecretHash string
+}
+
+// OperatorClaims 구조체 - 제어 API 액세스 토큰 클레임
+type OperatorClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// 로그인 실패 기록
+type loginFailure struct {
+	count     int
+	blockedAt time.Time // 이 시각 전까지 로그인 거부
+	lastSeen  time.Time
+}
+
+type refreshSession struct {
+	subject   string
+	role      string
+	expiresAt time.Time
+}
+
+// AuthService 구조체 - 운영자 인증 및 토큰 발급
+type AuthService struct {
+	mu         sync.Mutex
+	signingKey []byte
+	principals map[string]Principal
+	sessions   map[string]refreshSession // 리프레시 토큰 해시 → 세션
+	failures   map[string]*loginFailure  // 클라이언트 IP → 로그인 실패 기록
+	dummyHash  string                    // 없는 사용자도 같은 시간이 걸리도록 검증에 쓰는 해시
+}
+
+// 환경 변수로부터 인증 서비스 생성
+// API_USERS, API_KEYS 형식: "이름:권한:해시,이름:권한:해시"
+func newAuthService(signingKey string, users, apiKeys string) (*AuthService, error) {
+	if signingKey == "" {
+		return nil, fmt.Errorf("API_JWT_SECRET is required")
+	}
+
+	auth := &AuthService{
+		signingKey: []byte(signingKey),
+		principals: make(map[string]Principal),
+		sessions:   make(map[string]refreshSession),
+		failures:   make(map[string]*loginFailure),
+	}
+
+	dummyHash, err := hashSecret("unknown-user")
+	if err != nil {
+		return nil, err
+	}
+	auth.dummyHash = dummyHash
+
+	for _, spec := range []string{users, apiKeys} {
+		for _, entry := range strings.Split(spec, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			parts := strings.SplitN(entry, ":", 3)
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("invalid principal entry: %q", entry)
+			}
+			if parts[1] != RoleViewer && parts[1] != RoleOperator {
+				return nil, fmt.Errorf("invalid role for %s: %s", parts[0], parts[1])
+			}
+			if !strings.HasPrefix(parts[2], passwordHashPrefix+".") {
+				return nil, fmt.Errorf("secret for %s must be a %s hash", parts[0], passwordHashPrefix)
+			}
+			auth.principals[parts[0]] = Principal{Name: parts[0], Role: parts[1], SecretHash: parts[2]}
+		}
+	}
+
+	if len(auth.principals) == 0 {
+		return nil, fmt.Errorf("no API users or API keys configured")
+	}
+
+	return auth, nil
+}
+
+// 이름과 비밀번호(또는 API 키 시크릿)로 인증 후 토큰 발급
+// 없는 사용자도 더미 해시로 같은 계산을 하여 응답 시간으로 사용자 존재 여부가 드러나지 않게 한다.
+func (a *AuthService) login(name, secret string) (accessToken, refreshToken string, err error) {
+	principal, ok := a.principals[name]
+	hash := principal.SecretHash
+	if !ok {
+		hash = a.dummyHash
+	}
+	if !verifySecret(secret, hash) || !ok {
+		return "", "", fmt.Errorf("invalid credentials")
+	}
+	return a.issueTokens(principal.Name, principal.Role)
+}
+
+// 로그인 대기 중인 IP면 남은 시간 반환
+func (a *AuthService) loginBlocked(ip string, now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	failure, ok := a.failures[ip]
+	if !ok || !now.Before(failure.blockedAt) {
+		return 0
+	}
+	return failure.blockedAt.Sub(now)
+}
+
+// 로그인 결과 기록 - 성공하면 실패 기록 삭제, 실패하면 대기 시간 증가
+func (a *AuthService) recordLogin(ip string, success bool, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if success {
+		delete(a.failures, ip)
+		return
+	}
+
+	// 오래된 실패 기록 정리
+	for key, failure := range a.failures {
+		if now.Sub(failure.lastSeen) > loginBackoffMax && !now.Before(failure.blockedAt) {
+			delete(a.failures, key)
+		}
+	}
+
+	failure, ok := a.failures[ip]
+	if !ok {
+		failure = &loginFailure{}
+		a.failures[ip] = failure
+	}
+	failure.count++
+	failure.lastSeen = now
+	if failure.count > loginFreeAttempts {
+		backoff := loginBackoffMax
+		if shift := failure.count - loginFreeAttempts - 1; shift < 20 {
+			backoff = loginBackoffBase << shift
+		}
+		if backoff > loginBackoffMax {
+			backoff = loginBackoffMax
+		}
+		failure.blockedAt = now.Add(backoff)
+	}
+}
+
+// 리프레시 토큰으로 새 토큰 발급 (사용한 리프레시 토큰은 폐기)
+func (a *AuthService) refresh(refreshToken string) (accessToken, newRefreshToken string, err error) {
+	key := hashToken(refreshToken)
+
+	a.mu.Lock()
+	session, ok := a.sessions[key]
+	delete(a.sessions, key)
+	a.mu.Unlock()
+
+	if !ok || time.Now().After(session.expiresAt) {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+
+	// 설정에서 제거되었거나 권한이 바뀐 사용자는 현재 설정을 따름
+	principal, ok := a.principals[session.subject]
+	if !ok {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+	return a.issueTokens(principal.Name, principal.Role)
+}
+
+// 리프레시 토큰 폐기
+func (a *AuthService) revoke(refreshToken string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.sessions, hashToken(refreshToken))
+}
+
+func (a *AuthService) issueTokens(subject, role string) (string, string, error) {
+	now := time.Now()
+	claims := OperatorClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.signingKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign access token: %v", err)
+	}
+
+	refreshBytes := make([]byte, 32)
+	if _, err := rand.Read(refreshBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(refreshBytes)
+
+	a.mu.Lock()
+	// 만료된 세션 정리
+	for key, session := range a.sessions {
+		if now.After(session.expiresAt) {
+			delete(a.sessions, key)
+		}
+	}
+	a.sessions[hashToken(refreshToken)] = refreshSession{
+		subject:   subject,
+		role:      role,
+		expiresAt: now.Add(refreshTokenTTL),
+	}
+	a.mu.Unlock()
+
+	return accessToken, refreshToken, nil
+}
+
+// 액세스 토큰 검증
+func (a *AuthService) parseAccessToken(tokenString string) (*OperatorClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &OperatorClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return a.signingKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*OperatorClaims)
+	if !ok || !token.Valid || claims.Subject == "" {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return claims, nil
+}
+
+// 미들웨어: 액세스 토큰 검증
+func (a *AuthService) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			return
+		}
+
+		claims, err := a.parseAccessToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		// claims를 컨텍스트에 저장
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// 미들웨어: 권한 확인 (operator는 viewer 권한을 포함)
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("claims")
+		claims, ok := value.(*OperatorClaims)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		if role == RoleOperator && claims.Role != RoleOperator {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "operator role required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// 신뢰할 프록시 설정 (쉼표로 구분한 IP 또는 CIDR)
+// 지정하지 않으면 어떤 프록시도 신뢰하지 않으므로 X-Forwarded-For를 무시하고 접속한 IP를 사용한다.
+func setTrustedProxies(r *gin.Engine, value string) error {
+	var proxies []string
+	for _, proxy := range strings.Split(value, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		r.SetTrustedProxies(nil)
+		return err
+	}
+	return nil
+}
+
+// 인증 엔드포인트 등록
+func (a *AuthService) registerRoutes(r *gin.Engine) {
+	// 로그인 - 사용자 이름/비밀번호 또는 API 키 ID/시크릿
+	r.POST("/auth/login", func(c *gin.Context) {
+		var req struct {
+			Username string `json:"username" binding:"required"`
+			Password string `json:"password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		// 로그인 실패가 반복된 IP는 대기 시간 동안 거부 (신뢰하는 프록시가 아니면 X-Forwarded-For 무시)
+		ip := c.ClientIP()
+		if wait := a.loginBlocked(ip, time.Now()); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts"})
+			return
+		}
+
+		accessToken, refreshToken, err := a.login(req.Username, req.Password)
+		a.recordLogin(ip, err == nil, time.Now())
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			return
+		}
+		c.JSON(http.StatusOK, tokenResponse(accessToken, refreshToken))
+	})
+
+	// 토큰 갱신
+	r.POST("/auth/refresh", func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		accessToken, refreshToken, err := a.refresh(req.RefreshToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusOK, tokenResponse(accessToken, refreshToken))
+	})
+
+	// 로그아웃 - 리프레시 토큰 폐기
+	r.POST("/auth/logout", func(c *gin.Context) {
+		var req struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+
+		a.revoke(req.RefreshToken)
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	})
+}
+
+func tokenResponse(accessToken, refreshToken string) gin.H {
+	return gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(accessTokenTTL.Seconds()),
+	}
+}
+
+// 비밀번호/시크릿 해시 생성 - "pbkdf2-sha256.반복횟수.솔트.해시"
+// .env 파일의 변수 치환과 충돌하지 않도록 구분자로 "$" 대신 "."을 사용한다.
+func hashSecret(secret string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	key := pbkdf2.Key([]byte(secret), salt, passwordHashIterations, sha256.Size, sha256.New)
+	return fmt.Sprintf("%s.%d.%s.%s", passwordHashPrefix, passwordHashIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// 비밀번호/시크릿 해시 검증
+func verifySecret(secret, encoded string) bool {
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 4 || parts[0] != passwordHashPrefix {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	key := pbkdf2.Key([]byte(secret), salt, iterations, len(expected), sha256.New)
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// 리프레시 토큰은 해시로만 보관
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
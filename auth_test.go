@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVerifySecretKnownVector(t *testing.T) {
+	// RFC 7914의 PBKDF2-HMAC-SHA256 테스트 벡터 (P="passwd", S="salt", c=1) 앞 32바이트
+	key, _ := hex.DecodeString("55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc")
+	encoded := strings.Join([]string{
+		passwordHashPrefix,
+		"1",
+		base64.RawStdEncoding.EncodeToString([]byte("salt")),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, ".")
+
+	if !verifySecret("passwd", encoded) {
+		t.Fatalf("known vector did not verify")
+	}
+	if verifySecret("password", encoded) {
+		t.Fatalf("wrong secret verified")
+	}
+}
+
+func TestHashSecretRoundTrip(t *testing.T) {
+	encoded, err := hashSecret("s3cret")
+	if err != nil {
+		t.Fatalf("hashSecret failed: %v", err)
+	}
+	if !verifySecret("s3cret", encoded) || verifySecret("other", encoded) {
+		t.Fatalf("hash %s did not round trip", encoded)
+	}
+}
+
+func newTestAuthService(t *testing.T) *AuthService {
+	t.Helper()
+	hash, err := hashSecret("s3cret")
+	if err != nil {
+		t.Fatalf("hashSecret failed: %v", err)
+	}
+	auth, err := newAuthService("signing-key", "alice:operator:"+hash, "")
+	if err != nil {
+		t.Fatalf("newAuthService failed: %v", err)
+	}
+	return auth
+}
+
+func TestLoginUnknownUser(t *testing.T) {
+	auth := newTestAuthService(t)
+
+	if _, _, err := auth.login("bob", "s3cret"); err == nil {
+		t.Fatalf("unknown user logged in")
+	}
+	// 더미 해시의 비밀번호로도 로그인되지 않아야 함
+	if _, _, err := auth.login("bob", "unknown-user"); err == nil {
+		t.Fatalf("unknown user logged in with the dummy secret")
+	}
+	if _, _, err := auth.login("alice", "s3cret"); err != nil {
+		t.Fatalf("valid login failed: %v", err)
+	}
+}
+
+func TestLoginBackoff(t *testing.T) {
+	auth := newTestAuthService(t)
+	now := time.Now()
+
+	for i := 0; i < loginFreeAttempts; i++ {
+		auth.recordLogin("10.0.0.1", false, now)
+		if wait := auth.loginBlocked("10.0.0.1", now); wait != 0 {
+			t.Fatalf("blocked after %d failures", i+1)
+		}
+	}
+
+	auth.recordLogin("10.0.0.1", false, now)
+	if wait := auth.loginBlocked("10.0.0.1", now); wait != loginBackoffBase {
+		t.Fatalf("wait = %v, want %v", wait, loginBackoffBase)
+	}
+	auth.recordLogin("10.0.0.1", false, now)
+	if wait := auth.loginBlocked("10.0.0.1", now); wait != 2*loginBackoffBase {
+		t.Fatalf("wait = %v, want %v", wait, 2*loginBackoffBase)
+	}
+	if wait := auth.loginBlocked("10.0.0.2", now); wait != 0 {
+		t.Fatalf("other IP blocked for %v", wait)
+	}
+	if wait := auth.loginBlocked("10.0.0.1", now.Add(2*loginBackoffBase)); wait != 0 {
+		t.Fatalf("still blocked after the backoff, wait = %v", wait)
+	}
+
+	for i := 0; i < 40; i++ {
+		auth.recordLogin("10.0.0.1", false, now)
+	}
+	if wait := auth.loginBlocked("10.0.0.1", now); wait != loginBackoffMax {
+		t.Fatalf("wait = %v, want max %v", wait, loginBackoffMax)
+	}
+
+	auth.recordLogin("10.0.0.1", true, now)
+	if wait := auth.loginBlocked("10.0.0.1", now); wait != 0 {
+		t.Fatalf("still blocked after a successful login, wait = %v", wait)
+	}
+}
+
+// 로그인 엔드포인트만 등록한 라우터와 로그인 요청 함수 (forwardedFor가 있으면 X-Forwarded-For로 전송)
+func newLoginRouter(t *testing.T, trustedProxies string) func(password, forwardedFor string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	auth := newTestAuthService(t)
+	r := gin.New()
+	if err := setTrustedProxies(r, trustedProxies); err != nil {
+		t.Fatalf("setTrustedProxies failed: %v", err)
+	}
+	auth.registerRoutes(r)
+
+	return func(password, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice","password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestLoginEndpointRateLimited(t *testing.T) {
+	send := newLoginRouter(t, "")
+	login := func(password string) *httptest.ResponseRecorder { return send(password, "") }
+
+	for i := 0; i <= loginFreeAttempts; i++ {
+		if w := login("wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	// 대기 중에는 올바른 비밀번호도 거부
+	w := login("s3cret")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("status = %d, Retry-After = %q, want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestLoginBackoffIgnoresSpoofedForwardedFor(t *testing.T) {
+	login := newLoginRouter(t, "")
+
+	// 요청마다 X-Forwarded-For를 바꿔도 접속 IP 기준으로 제한
+	for i := 0; i <= loginFreeAttempts; i++ {
+		if w := login("wrong", fmt.Sprintf("198.51.100.%d", i)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, w.Code)
+		}
+	}
+	if w := login("wrong", "198.51.100.200"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429 despite a new X-Forwarded-For", w.Code)
+	}
+}
+
+func TestLoginBackoffUsesTrustedProxyHeader(t *testing.T) {
+	// httptest 요청의 접속 IP(192.0.2.1)를 프록시로 신뢰하면 X-Forwarded-For의 클라이언트별로 제한
+	login := newLoginRouter(t, "192.0.2.1")
+
+	for i := 0; i <= loginFreeAttempts; i++ {
+		login("wrong", "198.51.100.1")
+	}
+	if w := login("wrong", "198.51.100.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429 for the throttled client", w.Code)
+	}
+	if w := login("s3cret", "198.51.100.2"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for another client behind the proxy", w.Code)
+	}
+}
+
+func TestSetTrustedProxiesRejectsInvalid(t *testing.T) {
+	if err := setTrustedProxies(gin.New(), "not-an-ip"); err == nil {
+		t.Fatalf("invalid proxy accepted")
+	}
+}
@@ -0,0 +1,54 @@
+// upbitmock은 가짜 업비트 서버를 단독으로 실행한다.
+// 봇의 UPBIT_OPEN_API_SERVER_URL을 이 서버 주소로 지정하면 실제 거래소 없이 전체 거래 루프를 실행할 수 있다.
+package main
+
+import (
+	"flag"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"trading-bot/upbitmock"
+)
+
+func main() {
+	addr := flag.String("addr", ":9999", "listen address")
+	accessKey := flag.String("access-key", "test-access-key", "accepted Upbit access key")
+	secretKey := flag.String("secret-key", "test-secret-key", "Upbit secret key used to verify JWTs")
+	markets := flag.String("markets", "KRW-BTC", "comma separated markets")
+	krw := flag.Float64("krw", 1000000, "initial KRW balance")
+	startPrice := flag.Float64("start-price", 50000000, "initial price of each market")
+	volatility := flag.Float64("volatility", 0.01, "per-step volatility of the generated random walk")
+	steps := flag.Int("steps", 1000, "number of generated price steps")
+	seed := flag.Int64("seed", 1, "random seed for the price path")
+	flag.Parse()
+
+	server := upbitmock.NewServer(*accessKey, *secretKey)
+	server.SetBalance("KRW", *krw, 0)
+
+	rng := rand.New(rand.NewSource(*seed))
+	for _, market := range strings.Split(*markets, ",") {
+		market = strings.TrimSpace(market)
+		if market == "" {
+			continue
+		}
+		server.AddMarket(upbitmock.Market{Market: market, KoreanName: market, EnglishName: market})
+		server.SetVolume(market, 10000000000)
+
+		// 랜덤 워크 가격 경로 생성 (/mock/prices로 덮어쓸 수 있음)
+		prices := make([]float64, *steps)
+		price := *startPrice
+		for i := range prices {
+			price *= math.Exp(rng.NormFloat64() * *volatility)
+			prices[i] = math.Round(price)
+		}
+		server.SetPricePath(market, prices...)
+	}
+
+	log.Printf("Fake Upbit server listening on %s (markets: %s)", *addr, *markets)
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatal("Failed to start server:", err)
+	}
+}
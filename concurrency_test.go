@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"trading-bot/upbitmock"
+)
+
+// 거래 주기와 제어 API를 동시에 실행해 bot.mu/tickMu 잠금을 확인 (go test -race로 실행)
+func TestTradeLoopConcurrentWithAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetBalance("KRW", 1000000, 0)
+	prices := flatPrices(100000, 20)
+	for i := 0; i < 10; i++ {
+		prices = append(prices, 90000, 120000)
+	}
+	mock.SetPricePath("KRW-BTC", prices...)
+
+	bot := newMockBot(t, mock, map[string]string{
+		"BUY_RULE":  "close < 95000",
+		"SELL_RULE": "close > 110000",
+	})
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	auth := newTestAuthService(t)
+	audit, err := openAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("openAuditLog failed: %v", err)
+	}
+	t.Cleanup(func() { audit.Close() })
+	router := setupRouter(bot, auth, audit)
+	token, _, err := auth.issueTokens("alice", RoleOperator)
+	if err != nil {
+		t.Fatalf("issueTokens failed: %v", err)
+	}
+
+	call := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	const rounds = 30
+	var wg sync.WaitGroup
+	run := func(f func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				f(i)
+			}
+		}()
+	}
+
+	// 거래 주기 (ticker 루프와 직접 호출이 겹쳐도 tickMu로 직렬화)
+	run(func(int) { bot.executeTradeLoop(ctx) })
+	run(func(int) { bot.executeTradeLoop(ctx) })
+	run(func(i int) {
+		if i%2 == 0 {
+			if err := bot.StartTrading(time.Millisecond); err != nil {
+				t.Errorf("StartTrading failed: %v", err)
+			}
+		} else {
+			bot.StopTrading()
+		}
+	})
+
+	// 제어 API
+	run(func(int) {
+		if code := call(http.MethodPost, "/api/start"); code != http.StatusOK {
+			t.Errorf("start status = %d", code)
+		}
+	})
+	run(func(int) {
+		if code := call(http.MethodPost, "/api/stop"); code != http.StatusOK {
+			t.Errorf("stop status = %d", code)
+		}
+	})
+	run(func(int) {
+		if code := call(http.MethodGet, "/api/status"); code != http.StatusOK {
+			t.Errorf("status status = %d", code)
+		}
+	})
+
+	// 보유 현황 주기 갱신
+	run(func(int) {
+		if err := bot.refreshPositions(ctx); err != nil {
+			t.Errorf("refreshPositions failed: %v", err)
+		}
+	})
+
+	wg.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	bot.StopTrading()
+	if err := waitGroupContext(shutdownCtx, &bot.background); err != nil {
+		t.Fatalf("trade loop did not stop")
+	}
+
+	// 동시 실행 중에도 같은 신호가 두 번 주문되지 않음
+	seen := make(map[string]bool)
+	for _, entry := range bot.journal.entries() {
+		if entry.Status != JournalSubmitted {
+			continue
+		}
+		if seen[entry.Identifier] {
+			t.Fatalf("identifier %s submitted twice", entry.Identifier)
+		}
+		seen[entry.Identifier] = true
+	}
+}
+
+// 종료 중에는 시작 요청을 거부
+func TestStartRejectedWhileShuttingDown(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 1000000, 0)
+
+	bot := newMockBot(t, mock, nil)
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if err := bot.StartTrading(time.Millisecond); err != nil {
+		t.Fatalf("StartTrading failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			bot.StartTrading(time.Millisecond)
+		}
+	}()
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := bot.Shutdown(shutdownCtx, ShutdownCancelNone); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	wg.Wait()
+
+	if err := bot.StartTrading(time.Millisecond); err == nil {
+		t.Fatalf("StartTrading succeeded after shutdown")
+	}
+	bot.mu.RLock()
+	running := bot.isRunning
+	bot.mu.RUnlock()
+	if running {
+		t.Fatalf("trade loop restarted during shutdown")
+	}
+}
+
+// 종료 시 감시/체결 동기화 고루틴이 멈춘 뒤에 기록 파일을 닫음
+func TestShutdownWaitsForBackgroundTasks(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC", MarketEvent: upbitmock.MarketEvent{Warning: true}})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 1000000, 0)
+	mock.SetBalance("BTC", 0.5, 100000)
+
+	// 청산 주문 요청을 잡아 두고 종료 시작
+	var requests atomic.Int64
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/orders" {
+			once.Do(func() {
+				close(entered)
+				<-release
+			})
+		}
+		mock.ServeHTTP(w, r)
+	})
+	defer close(release)
+
+	bot := newMockBot(t, handler, map[string]string{
+		"MARKET_EVENT_POLICY":      MarketEventPolicyLiquidate,
+		"POSITION_REFRESH_SECONDS": "1",
+	})
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if err := bot.StartTrading(time.Hour); err != nil {
+		t.Fatalf("StartTrading failed: %v", err)
+	}
+	bot.watcher.Interval = 10 * time.Millisecond
+	bot.ledger.Interval = 10 * time.Millisecond
+	if err := bot.startBackground(ctx); err != nil {
+		t.Fatalf("startBackground failed: %v", err)
+	}
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watcher did not liquidate the flagged market")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := bot.Shutdown(shutdownCtx, ShutdownCancelNone); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// 닫힌 뒤에는 감시/동기화 요청이 더 나가지 않음
+	after := requests.Load()
+	time.Sleep(100 * time.Millisecond)
+	if got := requests.Load(); got != after {
+		t.Fatalf("%d requests after shutdown, background tasks are still running", got-after)
+	}
+	if bot.tickMu.TryLock() {
+		t.Fatalf("tickMu was released after the logs were closed")
+	}
+	if err := bot.startBackground(ctx); err == nil {
+		t.Fatalf("startBackground succeeded after shutdown")
+	}
+}
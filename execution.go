@@ -0,0 +1,572 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 주문 실행 알고리즘
+const (
+	ExecAlgoDirect  = "direct"  // 단일 주문
+	ExecAlgoTWAP    = "twap"    // 일정 시간 동안 균등 분할
+	ExecAlgoIceberg = "iceberg" // 노출 수량(clip)만큼씩 지정가 주문
+	ExecAlgoPOV     = "pov"     // 시장 거래량의 일정 비율만큼 주문
+)
+
+// 부모 주문 상태
+const (
+	ExecutionRunning   = "running"
+	ExecutionCompleted = "completed"
+	ExecutionExpired   = "expired" // 최대 실행 시간 초과 (일부 체결)
+	ExecutionCancelled = "cancelled"
+	ExecutionFailed    = "failed"
+)
+
+// 자식 주문 제출이 연속으로 실패하면 부모 주문 중단
+const maxChildFailures = 3
+
+// 자식 주문 정리(취소/체결 확인) 재시도
+const (
+	settleAttempts   = 3
+	settleRetryDelay = time.Second
+)
+
+// 끝난 부모 주문 보관 (조회용) - 기간이 지나거나 개수를 넘으면 오래된 것부터 삭제
+const (
+	executionRetention    = 24 * time.Hour
+	maxFinishedExecutions = 100
+)
+
+// unsettledChildError - 자식 주문의 최종 체결 수량을 확인하지 못함
+// 체결 수량을 모르는 채로 다음 주문을 내면 초과 체결될 수 있으므로 부모 주문을 중단한다.
+type unsettledChildError struct {
+	uuid string
+	err  error
+}
+
+func (e *unsettledChildError) Error() string {
+	return fmt.Sprintf("child order %s could not be settled: %v", e.uuid, e.err)
+}
+
+// 부모 주문을 바로 중단해야 하는 자식 주문 오류인지 확인
+func isUnsettled(err error) bool {
+	_, ok := err.(*unsettledChildError)
+	return ok
+}
+
+// ChildOrder 구조체 - 부모 주문에서 나뉜 개별 주문
+type ChildOrder struct {
+	Identifier string    `json:"identifier"`
+	UUID       string    `json:"uuid"`
+	Volume     float64   `json:"volume"`   // 주문 수량
+	Executed   float64   `json:"executed"` // 체결 수량
+	State      string    `json:"state"`
+	Time       time.Time `json:"time"`
+}
+
+// ParentOrder 구조체 - 전략 신호 하나를 실행 알고리즘으로 나눈 주문
+type ParentOrder struct {
+	ID           string       `json:"id"`
+	Market       string       `json:"market"`
+	Side         string       `json:"side"` // 신호 타입 (buy, sell)
+	Algo         string       `json:"algo"`
+	TotalVolume  float64      `json:"total_volume"`
+	FilledVolume float64      `json:"filled_volume"`
+	Children     []ChildOrder `json:"children"`
+	Status       string       `json:"status"`
+	Error        string       `json:"error,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+
+	signal TradeSignal // 자식 주문 템플릿
+	cancel context.CancelFunc
+}
+
+// 남은 수량
+func (p *ParentOrder) remaining() float64 {
+	return p.TotalVolume - p.FilledVolume
+}
+
+// Executor 구조체 - 큰 주문을 실행 알고리즘으로 나눠 주문 관리자(executeTrade)로 제출
+type Executor struct {
+	Algo           string        // 기본 실행 알고리즘
+	MinNotional    float64       // 이 금액(KRW) 이상인 주문만 알고리즘 실행
+	MaxDuration    time.Duration // 부모 주문 최대 실행 시간 (iceberg, pov)
+	PollInterval   time.Duration // 자식 주문 체결 확인 주기
+	TWAPDuration   time.Duration
+	TWAPSlices     int
+	IcebergClip    float64       // 노출 금액 (KRW)
+	IcebergRefresh time.Duration // 미체결 clip 재호가 주기
+	POVRate        float64       // 시장 거래량 대비 참여율
+	POVInterval    time.Duration
+
+	mu      sync.Mutex
+	parents map[string]*ParentOrder
+	wg      sync.WaitGroup
+}
+
+// 환경 변수로 실행 알고리즘 설정
+func newExecutorFromEnv() *Executor {
+	return &Executor{
+		Algo:           getEnvOrDefault("EXEC_ALGO", ExecAlgoDirect),
+		MinNotional:    getEnvFloat("EXEC_MIN_NOTIONAL_KRW", 1000000),
+		MaxDuration:    time.Duration(getEnvInt("EXEC_MAX_DURATION_MINUTES", 60)) * time.Minute,
+		PollInterval:   time.Duration(getEnvInt("EXEC_POLL_SECONDS", 5)) * time.Second,
+		TWAPDuration:   time.Duration(getEnvInt("TWAP_DURATION_MINUTES", 10)) * time.Minute,
+		TWAPSlices:     getEnvInt("TWAP_SLICES", 5),
+		IcebergClip:    getEnvFloat("ICEBERG_CLIP_KRW", 200000),
+		IcebergRefresh: time.Duration(getEnvInt("ICEBERG_REFRESH_SECONDS", 30)) * time.Second,
+		POVRate:        getEnvFloat("POV_RATE", 0.1),
+		POVInterval:    time.Duration(getEnvInt("POV_INTERVAL_SECONDS", 30)) * time.Second,
+		parents:        make(map[string]*ParentOrder),
+	}
+}
+
+// 신호를 알고리즘으로 실행할지 결정
+func (e *Executor) shouldSlice(signal TradeSignal) bool {
+	if e.Algo == ExecAlgoDirect || e.Algo == "" {
+		return false
+	}
+	notional := signal.Notional
+	if notional <= 0 {
+		notional = signal.Volume * signal.Price
+	}
+	return notional >= e.MinNotional
+}
+
+// 마켓에 실행 중인 부모 주문이 있는지 확인
+func (e *Executor) activeFor(market string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, parent := range e.parents {
+		if parent.Market == market && parent.Status == ExecutionRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// 부모 주문 시작 - ctx가 취소되면 자식 주문을 정리하고 중단
+func (e *Executor) start(ctx context.Context, bot *TradingBot, signal TradeSignal, market string) (*ParentOrder, error) {
+	switch e.Algo {
+	case ExecAlgoTWAP, ExecAlgoIceberg, ExecAlgoPOV:
+	default:
+		return nil, fmt.Errorf("unknown execution algorithm: %s", e.Algo)
+	}
+	if signal.Volume <= 0 || signal.Price <= 0 {
+		return nil, fmt.Errorf("execution requires volume and reference price")
+	}
+
+	e.mu.Lock()
+	now := time.Now()
+	e.prune(now)
+	if _, exists := e.parents[signal.Identifier]; exists {
+		e.mu.Unlock()
+		return nil, fmt.Errorf("execution %s already exists", signal.Identifier)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	parent := &ParentOrder{
+		ID:          signal.Identifier,
+		Market:      market,
+		Side:        signal.Type,
+		Algo:        e.Algo,
+		TotalVolume: signal.Volume,
+		Children:    []ChildOrder{},
+		Status:      ExecutionRunning,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		signal:      signal,
+		cancel:      cancel,
+	}
+	e.parents[parent.ID] = parent
+	e.wg.Add(1)
+	e.mu.Unlock()
+
+	bot.logger.Info("Starting %s execution %s: %s %f %s", parent.Algo, parent.ID, parent.Side, parent.TotalVolume, market)
+
+	go func() {
+		defer e.wg.Done()
+		defer cancel()
+
+		var err error
+		switch parent.Algo {
+		case ExecAlgoTWAP:
+			err = e.runTWAP(runCtx, bot, parent)
+		case ExecAlgoIceberg:
+			err = e.runIceberg(runCtx, bot, parent)
+		case ExecAlgoPOV:
+			err = e.runPOV(runCtx, bot, parent)
+		}
+		e.finish(bot, parent, runCtx, err)
+	}()
+
+	return e.get(parent.ID), nil
+}
+
+// 끝난 부모 주문 정리 (e.mu를 잡은 상태에서 호출)
+func (e *Executor) prune(now time.Time) {
+	finished := make([]*ParentOrder, 0)
+	for id, parent := range e.parents {
+		if parent.Status == ExecutionRunning {
+			continue
+		}
+		if now.Sub(parent.UpdatedAt) > executionRetention {
+			delete(e.parents, id)
+			continue
+		}
+		finished = append(finished, parent)
+	}
+	if len(finished) <= maxFinishedExecutions {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].UpdatedAt.Before(finished[j].UpdatedAt) })
+	for _, parent := range finished[:len(finished)-maxFinishedExecutions] {
+		delete(e.parents, parent.ID)
+	}
+}
+
+// 부모 주문 종료 상태 기록
+func (e *Executor) finish(bot *TradingBot, parent *ParentOrder, ctx context.Context, err error) {
+	e.mu.Lock()
+	switch {
+	case parent.remaining()*parent.signal.Price < minOrderKRW:
+		parent.Status = ExecutionCompleted
+	case ctx.Err() != nil:
+		parent.Status = ExecutionCancelled
+	case err != nil:
+		parent.Status = ExecutionFailed
+		parent.Error = err.Error()
+	default:
+		parent.Status = ExecutionExpired
+	}
+	parent.UpdatedAt = time.Now()
+	status, filled, total := parent.Status, parent.FilledVolume, parent.TotalVolume
+	e.mu.Unlock()
+
+	bot.logger.Info("Execution %s %s: filled %f of %f", parent.ID, status, filled, total)
+}
+
+// TWAP - 전체 수량을 TWAPSlices개로 나눠 TWAPDuration 동안 균등 간격으로 주문
+// 각 구간이 끝나면 미체결 수량을 취소하고 다음 구간에 합산한다.
+func (e *Executor) runTWAP(ctx context.Context, bot *TradingBot, parent *ParentOrder) error {
+	slices := e.TWAPSlices
+	if slices <= 0 {
+		slices = 1
+	}
+	sliceInterval := e.TWAPDuration / time.Duration(slices)
+
+	failures := 0
+	for i := 0; i < slices; i++ {
+		volume := e.sliceVolume(parent, e.remaining(parent)/float64(slices-i))
+		if volume <= 0 {
+			return nil
+		}
+
+		deadline := time.Now().Add(sliceInterval)
+		if err := e.placeAndSettle(ctx, bot, parent, volume, parent.signal.OrderType, deadline); err != nil {
+			failures++
+			bot.logger.Error("Execution %s slice %d failed: %v", parent.ID, i+1, err)
+			if failures >= maxChildFailures || isUnsettled(err) {
+				return err
+			}
+		} else {
+			failures = 0
+		}
+		if !sleepUntil(ctx, deadline) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Iceberg - IcebergClip 금액만큼만 지정가로 노출하고, 체결되거나 재호가 주기가 지나면 다음 clip 주문
+func (e *Executor) runIceberg(ctx context.Context, bot *TradingBot, parent *ParentOrder) error {
+	clip := e.IcebergClip / parent.signal.Price
+	stop := parent.CreatedAt.Add(e.MaxDuration)
+
+	failures := 0
+	for time.Now().Before(stop) {
+		volume := e.sliceVolume(parent, clip)
+		if volume <= 0 {
+			return nil
+		}
+
+		deadline := time.Now().Add(e.IcebergRefresh)
+		if err := e.placeAndSettle(ctx, bot, parent, volume, OrderTypeLimit, deadline); err != nil {
+			failures++
+			bot.logger.Error("Execution %s clip failed: %v", parent.ID, err)
+			if failures >= maxChildFailures || isUnsettled(err) {
+				return err
+			}
+			if !sleepUntil(ctx, deadline) {
+				return ctx.Err()
+			}
+			continue
+		}
+		failures = 0
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// POV - POVInterval마다 시장 24시간 누적 거래량 증가분의 POVRate만큼 주문
+func (e *Executor) runPOV(ctx context.Context, bot *TradingBot, parent *ParentOrder) error {
+	stop := parent.CreatedAt.Add(e.MaxDuration)
+	lastVolume := -1.0
+	allowance := 0.0
+
+	failures := 0
+	for time.Now().Before(stop) {
+		tickers, err := bot.fetchTickers(ctx, []string{parent.Market})
+		if err != nil || len(tickers) == 0 {
+			bot.logger.Error("Execution %s failed to fetch market volume: %v", parent.ID, err)
+		} else {
+			traded := tickers[0].AccTradeVolume24h
+			if lastVolume >= 0 && traded > lastVolume {
+				allowance += (traded - lastVolume) * e.POVRate
+			}
+			lastVolume = traded
+		}
+
+		deadline := time.Now().Add(e.POVInterval)
+		if allowance*parent.signal.Price >= minOrderKRW {
+			volume := e.sliceVolume(parent, allowance)
+			if volume <= 0 {
+				return nil
+			}
+
+			before := e.filled(parent)
+			if err := e.placeAndSettle(ctx, bot, parent, volume, parent.signal.OrderType, deadline); err != nil {
+				failures++
+				bot.logger.Error("Execution %s child failed: %v", parent.ID, err)
+				if failures >= maxChildFailures || isUnsettled(err) {
+					return err
+				}
+			} else {
+				failures = 0
+			}
+			allowance -= e.filled(parent) - before
+		} else if e.remaining(parent)*parent.signal.Price < minOrderKRW {
+			return nil
+		}
+
+		if !sleepUntil(ctx, deadline) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// 자식 주문 수량 결정 - 남은 수량을 넘지 않고, 주문 후 남는 수량이 최소 주문 금액 미만이면 함께 주문
+func (e *Executor) sliceVolume(parent *ParentOrder, volume float64) float64 {
+	remaining := e.remaining(parent)
+	price := parent.signal.Price
+	if remaining*price < minOrderKRW {
+		return 0
+	}
+	if volume*price < minOrderKRW {
+		volume = minOrderKRW / price
+	}
+	if volume >= remaining || (remaining-volume)*price < minOrderKRW {
+		return remaining
+	}
+	return volume
+}
+
+// 자식 주문 제출 후 deadline까지 체결 대기, 미체결 수량은 취소하고 체결 수량 반영
+func (e *Executor) placeAndSettle(ctx context.Context, bot *TradingBot, parent *ParentOrder, volume float64, orderType string, deadline time.Time) error {
+	e.mu.Lock()
+	signal := parent.signal
+	signal.Volume = volume
+	signal.Notional = 0
+	signal.OrderType = orderType
+	signal.Identifier = fmt.Sprintf("%s-%d", parent.ID, len(parent.Children)+1)
+	e.mu.Unlock()
+
+	order, err := bot.executeTrade(ctx, signal, parent.Market)
+	if err != nil {
+		return err
+	}
+
+	child := ChildOrder{
+		Identifier: signal.Identifier,
+		UUID:       order.UUID,
+		Volume:     volume,
+		State:      order.State,
+		Time:       time.Now(),
+	}
+	e.mu.Lock()
+	parent.Children = append(parent.Children, child)
+	index := len(parent.Children) - 1
+	parent.UpdatedAt = time.Now()
+	e.mu.Unlock()
+
+	// 체결 또는 deadline까지 대기
+	for order.State == "wait" && time.Now().Before(deadline) {
+		if !sleepUntil(ctx, minTime(time.Now().Add(e.PollInterval), deadline)) {
+			break
+		}
+		latest, err := bot.getOrder(ctx, order.UUID)
+		if err != nil {
+			bot.logger.Error("Execution %s failed to poll order %s: %v", parent.ID, order.UUID, err)
+			continue
+		}
+		order = latest
+	}
+
+	// 취소된 경우에도 미체결 주문을 정리해야 하므로 별도 컨텍스트 사용
+	settleCtx, cancel := context.WithTimeout(context.Background(), upbitRequestTimeout*time.Duration(settleAttempts*2))
+	defer cancel()
+	order, settleErr := e.settle(settleCtx, bot, parent, order)
+
+	// 확인하지 못한 경우에도 마지막으로 확인한 체결 수량은 반영
+	executed, _ := strconv.ParseFloat(order.ExecutedVolume, 64)
+	e.mu.Lock()
+	parent.Children[index].Executed = executed
+	parent.Children[index].State = order.State
+	parent.FilledVolume += executed
+	parent.UpdatedAt = time.Now()
+	e.mu.Unlock()
+
+	if settleErr != nil {
+		return &unsettledChildError{uuid: order.UUID, err: settleErr}
+	}
+	return nil
+}
+
+// 미체결 자식 주문 취소 후 최종 상태 확인 (실패하면 재시도)
+// 주문이 done/cancel 상태로 확인되어야 체결 수량이 확정된 것으로 본다.
+func (e *Executor) settle(ctx context.Context, bot *TradingBot, parent *ParentOrder, order *Order) (*Order, error) {
+	var lastErr error
+	for attempt := 1; attempt <= settleAttempts; attempt++ {
+		if attempt > 1 && !sleepUntil(ctx, time.Now().Add(settleRetryDelay)) {
+			break
+		}
+
+		if order.State == "wait" {
+			if err := bot.cancelOrder(ctx, order.UUID); err != nil {
+				lastErr = fmt.Errorf("failed to cancel: %v", err)
+				bot.logger.Error("Execution %s failed to cancel child %s (attempt %d): %v", parent.ID, order.UUID, attempt, err)
+				// 그 사이 체결되었을 수 있으므로 상태 확인은 계속
+			}
+		}
+
+		latest, err := bot.getOrder(ctx, order.UUID)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to fetch order: %v", err)
+			bot.logger.Error("Execution %s failed to settle child %s (attempt %d): %v", parent.ID, order.UUID, attempt, err)
+			continue
+		}
+		order = latest
+		if order.State != "wait" {
+			return order, nil
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("order is still open")
+		}
+	}
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
+	return order, lastErr
+}
+
+func (e *Executor) remaining(parent *ParentOrder) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return parent.remaining()
+}
+
+func (e *Executor) filled(parent *ParentOrder) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return parent.FilledVolume
+}
+
+// 부모 주문 복사본
+func (e *Executor) get(id string) *ParentOrder {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	parent, ok := e.parents[id]
+	if !ok {
+		return nil
+	}
+	copied := *parent
+	copied.Children = append([]ChildOrder(nil), parent.Children...)
+	return &copied
+}
+
+// 부모 주문 목록 (최근 순)
+func (e *Executor) list() []ParentOrder {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	parents := make([]ParentOrder, 0, len(e.parents))
+	for _, parent := range e.parents {
+		copied := *parent
+		copied.Children = append([]ChildOrder(nil), parent.Children...)
+		parents = append(parents, copied)
+	}
+	sort.Slice(parents, func(i, j int) bool { return parents[i].CreatedAt.After(parents[j].CreatedAt) })
+	return parents
+}
+
+// 실행 중인 부모 주문 취소 - 진행 중인 자식 주문은 취소 후 체결 수량을 반영
+func (e *Executor) cancelExecution(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	parent, ok := e.parents[id]
+	if !ok {
+		return fmt.Errorf("execution %s not found", id)
+	}
+	if parent.Status != ExecutionRunning {
+		return fmt.Errorf("execution %s is already %s", id, parent.Status)
+	}
+	parent.cancel()
+	return nil
+}
+
+// 실행 중인 부모 주문이 모두 정리될 때까지 대기
+func (e *Executor) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 지정 시각까지 대기 (ctx가 먼저 취소되면 false)
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/upbitmock"
+)
+
+// 지정가 자식 주문이 체결되지 않고 남는 TWAP 실행 준비
+func newTWAPTestBot(t *testing.T, mock *upbitmock.Server) *TradingBot {
+	t.Helper()
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 10000000, 0)
+
+	bot := newMockBot(t, mock, map[string]string{"ORDER_PRICING": PricingPassive})
+	if _, err := bot.reconcile(context.Background()); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	bot.executor.Algo = ExecAlgoTWAP
+	bot.executor.TWAPSlices = 3
+	bot.executor.TWAPDuration = 1500 * time.Millisecond
+	bot.executor.PollInterval = 20 * time.Millisecond
+	return bot
+}
+
+func startTestExecution(t *testing.T, bot *TradingBot) *ParentOrder {
+	t.Helper()
+	signal := TradeSignal{
+		Type:       "buy",
+		Price:      100000,
+		Volume:     1,
+		OrderType:  OrderTypeLimit,
+		Identifier: "tb-exec-test",
+	}
+	parent, err := bot.executor.start(context.Background(), bot, signal, "KRW-BTC")
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	return parent
+}
+
+// 부모 주문이 끝날 때까지 대기
+func waitExecution(t *testing.T, bot *TradingBot, id string) *ParentOrder {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := bot.executor.wait(ctx); err != nil {
+		t.Fatalf("execution %s did not finish: %v", id, err)
+	}
+	return bot.executor.get(id)
+}
+
+func TestExecutionAbortsWhenChildCannotBeConfirmed(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	bot := newTWAPTestBot(t, mock)
+	parent := startTestExecution(t, bot)
+
+	// 첫 자식 주문이 나간 뒤부터 주문 조회 실패
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bot.executor.get(parent.ID).Children) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("first child order was not placed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mock.InjectFailure(upbitmock.Failure{Method: http.MethodGet, Path: "/v1/order", Status: http.StatusInternalServerError, Count: 1000})
+
+	result := waitExecution(t, bot, parent.ID)
+	if result.Status != ExecutionFailed || !strings.Contains(result.Error, "could not be settled") {
+		t.Fatalf("execution = %s (%s), want failed because the child could not be settled", result.Status, result.Error)
+	}
+	if len(result.Children) != 1 {
+		t.Fatalf("children = %d, want no further slices after the unsettled child", len(result.Children))
+	}
+}
+
+func TestExecutionAbortsWhenChildCannotBeCancelled(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	bot := newTWAPTestBot(t, mock)
+	mock.InjectFailure(upbitmock.Failure{Method: http.MethodDelete, Path: "/v1/order", Status: http.StatusInternalServerError, Count: 1000})
+
+	parent := startTestExecution(t, bot)
+	result := waitExecution(t, bot, parent.ID)
+
+	if result.Status != ExecutionFailed {
+		t.Fatalf("execution = %s (%s), want failed", result.Status, result.Error)
+	}
+	if len(result.Children) != 1 || result.Children[0].State != "wait" {
+		t.Fatalf("children = %+v, want a single open child", result.Children)
+	}
+	if result.FilledVolume != 0 {
+		t.Fatalf("filled volume = %f, want 0", result.FilledVolume)
+	}
+}
+
+func TestExecutorPrunesFinishedParents(t *testing.T) {
+	now := time.Now()
+	e := &Executor{parents: make(map[string]*ParentOrder)}
+	e.parents["running"] = &ParentOrder{ID: "running", Status: ExecutionRunning, UpdatedAt: now.Add(-48 * time.Hour)}
+	e.parents["stale"] = &ParentOrder{ID: "stale", Status: ExecutionCompleted, UpdatedAt: now.Add(-executionRetention - time.Minute)}
+	for i := 0; i < maxFinishedExecutions+20; i++ {
+		id := fmt.Sprintf("done-%03d", i)
+		e.parents[id] = &ParentOrder{ID: id, Status: ExecutionCompleted, UpdatedAt: now.Add(time.Duration(i-200) * time.Minute)}
+	}
+
+	e.mu.Lock()
+	e.prune(now)
+	e.mu.Unlock()
+
+	if _, ok := e.parents["running"]; !ok {
+		t.Fatalf("running parent was evicted")
+	}
+	if _, ok := e.parents["stale"]; ok {
+		t.Fatalf("parent past retention was kept")
+	}
+	if len(e.parents) != maxFinishedExecutions+1 {
+		t.Fatalf("parents = %d, want %d", len(e.parents), maxFinishedExecutions+1)
+	}
+	// 오래된 것부터 삭제
+	if _, ok := e.parents["done-000"]; ok {
+		t.Fatalf("oldest finished parent was kept")
+	}
+	if _, ok := e.parents[fmt.Sprintf("done-%03d", maxFinishedExecutions+19)]; !ok {
+		t.Fatalf("newest finished parent was evicted")
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 내보내기 형식
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// TradeRecord 구조체 - 회계용 체결 기록 한 건
+type TradeRecord struct {
+	Time        time.Time `json:"time"` // KST
+	Market      string    `json:"market"`
+	Side        string    `json:"side"` // buy, sell
+	Price       float64   `json:"price"`
+	Volume      float64   `json:"volume"`
+	Fee         float64   `json:"fee"`
+	Value       float64   `json:"krw_value"`    // 체결 금액 (수수료 제외)
+	RealizedPnL float64   `json:"realized_pnl"` // 매도 체결의 실현 손익 (매수는 0)
+	OrderUUID   string    `json:"order_uuid"`
+	TradeUUID   string    `json:"trade_uuid"`
+}
+
+// CSV 헤더 (TradeRecord 필드 순서)
+var tradeRecordHeader = []string{
+	"time_kst", "market", "side", "price", "volume", "fee", "krw_value", "realized_pnl", "order_uuid", "trade_uuid",
+}
+
+// 체결 목록을 회계용 기록으로 변환
+// 실현 손익은 전체 체결 내역 기준 원가로 계산한 뒤 [from, to) 구간만 반환한다 (0이면 제한 없음).
+func tradeRecords(fills []Fill, method string, from, to time.Time) []TradeRecord {
+	positions := make(map[string]*PositionPnL)
+	records := make([]TradeRecord, 0)
+
+	for _, fill := range fills {
+		if quote, _ := splitMarket(fill.Market); quote != "KRW" {
+			continue
+		}
+		position, ok := positions[fill.Market]
+		if !ok {
+			position = &PositionPnL{Market: fill.Market}
+			positions[fill.Market] = position
+		}
+		realized := position.apply(fill, method)
+
+		if (!from.IsZero() && fill.Time.Before(from)) || (!to.IsZero() && !fill.Time.Before(to)) {
+			continue
+		}
+		side := "buy"
+		if fill.Side == "ask" {
+			side = "sell"
+		}
+		records = append(records, TradeRecord{
+			Time:        fill.Time.In(kst),
+			Market:      fill.Market,
+			Side:        side,
+			Price:       fill.Price,
+			Volume:      fill.Volume,
+			Fee:         fill.Fee,
+			Value:       fill.Funds,
+			RealizedPnL: realized,
+			OrderUUID:   fill.OrderUUID,
+			TradeUUID:   fill.TradeUUID,
+		})
+	}
+
+	return records
+}
+
+// 회계용 기록을 CSV로 출력
+func writeTradeRecordsCSV(w io.Writer, records []TradeRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(tradeRecordHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %v", err)
+	}
+
+	formatNumber := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for _, record := range records {
+		row := []string{
+			record.Time.Format("2006-01-02 15:04:05"),
+			record.Market,
+			record.Side,
+			formatNumber(record.Price),
+			formatNumber(record.Volume),
+			formatNumber(record.Fee),
+			formatNumber(record.Value),
+			formatNumber(record.RealizedPnL),
+			record.OrderUUID,
+			record.TradeUUID,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write csv row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %v", err)
+	}
+	return nil
+}
+
+// 내보내기 기간 파싱 - 날짜(2006-01-02, KST) 또는 RFC3339 시각
+// 날짜로 지정한 to는 해당 일자를 포함한다.
+func parseExportRange(from, to string) (time.Time, time.Time, error) {
+	parse := func(value string, endOfDay bool) (time.Time, error) {
+		if value == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.ParseInLocation("2006-01-02", value, kst); err == nil {
+			if endOfDay {
+				t = t.AddDate(0, 0, 1)
+			}
+			return t, nil
+		}
+		// 쿼리 문자열에서 인코딩하지 않은 시간대 오프셋의 '+'는 공백으로 디코딩되므로 되돌림 (예: 09:00:00 09:00)
+		return time.Parse(time.RFC3339, strings.Replace(value, " ", "+", 1))
+	}
+
+	start, err := parse(from, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %s", from)
+	}
+	end, err := parse(to, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %s", to)
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+// 내보내기 형식 확인 (비어 있으면 csv)
+func parseExportFormat(format string) (string, error) {
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		return ExportFormatCSV, nil
+	case ExportFormatCSV, ExportFormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format: %s", format)
+	}
+}
+
+// GET /api/export/trades 쿼리 파라미터 파싱 (from, to, format)
+func parseExportQuery(c *gin.Context) (from, to time.Time, format string, err error) {
+	from, to, err = parseExportRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	format, err = parseExportFormat(c.Query("format"))
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	return from, to, format, nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseExportRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{name: "open range"},
+		{
+			name: "dates are KST and to includes the day", from: "2024-01-01", to: "2024-01-31",
+			wantFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, kst), wantTo: time.Date(2024, 2, 1, 0, 0, 0, 0, kst),
+		},
+		{
+			name: "same day", from: "2024-01-31", to: "2024-01-31",
+			wantFrom: time.Date(2024, 1, 31, 0, 0, 0, 0, kst), wantTo: time.Date(2024, 2, 1, 0, 0, 0, 0, kst),
+		},
+		{
+			name: "RFC3339 is exclusive", from: "2024-01-01T00:00:00Z", to: "2024-01-02T09:00:00+09:00",
+			wantFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "offset decoded from an unencoded plus", from: "2024-01-01T09:00:00 09:00",
+			wantFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "invalid from", from: "2024/01/01", wantErr: true},
+		{name: "invalid to", to: "yesterday", wantErr: true},
+		{name: "from after to", from: "2024-02-01", to: "2024-01-01", wantErr: true},
+		{name: "empty RFC3339 range", from: "2024-01-01T00:00:00Z", to: "2024-01-01T00:00:00Z", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseExportRange(tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s ~ %s", from, to)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseExportRange failed: %v", err)
+			}
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+				t.Errorf("got %s ~ %s, want %s ~ %s", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+// 쿼리 문자열의 '+'를 인코딩하지 않아도 오프셋이 유지됨
+func TestParseExportQueryAcceptsUnencodedOffset(t *testing.T) {
+	for _, rawQuery := range []string{
+		"from=2024-01-01T09:00:00+09:00&format=JSON",
+		"from=2024-01-01T09:00:00%2B09:00&format=JSON",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/export/trades?"+rawQuery, nil)
+
+		from, to, format, err := parseExportQuery(c)
+		if err != nil {
+			t.Fatalf("%s: parseExportQuery failed: %v", rawQuery, err)
+		}
+		if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !from.Equal(want) || !to.IsZero() {
+			t.Errorf("%s: got %s ~ %s, want %s ~ open", rawQuery, from, to, want)
+		}
+		if format != ExportFormatJSON {
+			t.Errorf("%s: format %q, want json", rawQuery, format)
+		}
+	}
+}
+
+func TestTradeRecords(t *testing.T) {
+	fill := func(at time.Time, market, side string, volume, price, fee float64, trade string) Fill {
+		return Fill{
+			Time:      at,
+			TradeUUID: trade,
+			OrderUUID: "order-" + trade,
+			Market:    market,
+			Side:      side,
+			Price:     price,
+			Volume:    volume,
+			Funds:     price * volume,
+			Fee:       fee,
+		}
+	}
+	// 장부는 시간순으로 기록됨
+	fills := []Fill{
+		fill(time.Date(2023, 12, 31, 10, 0, 0, 0, kst), "KRW-BTC", "bid", 1, 100, 1, "t1"),     // 기간 이전 매수
+		fill(time.Date(2024, 1, 1, 0, 0, 0, 0, kst), "KRW-BTC", "bid", 1, 200, 2, "t2"),        // 기간 시작
+		fill(time.Date(2024, 1, 15, 12, 0, 0, 0, kst), "BTC-ETH", "bid", 1, 0.05, 0, "t3"),     // KRW 마켓 아님
+		fill(time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), "KRW-ETH", "bid", 2, 50, 0.1, "t6"), // UTC로 기록된 체결
+		fill(time.Date(2024, 1, 31, 23, 59, 59, 0, kst), "KRW-BTC", "ask", 1, 300, 3, "t4"),    // 마지막 날 끝
+		fill(time.Date(2024, 2, 1, 0, 0, 0, 0, kst), "KRW-BTC", "ask", 1, 400, 4, "t5"),        // 다음 날 시작
+	}
+
+	from, to, err := parseExportRange("2024-01-01", "2024-01-31")
+	if err != nil {
+		t.Fatalf("parseExportRange failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		from, to   time.Time
+		wantTrades []string
+		wantPnL    map[string]float64
+	}{
+		{
+			name: "fifo uses lots bought before the range", method: CostMethodFIFO, from: from, to: to,
+			wantTrades: []string{"t2", "t6", "t4"},
+			// 첫 매수(100 + 수수료 1)를 먼저 소진: 300 - 3 - 101
+			wantPnL: map[string]float64{"t2": 0, "t6": 0, "t4": 300 - 3 - 101},
+		},
+		{
+			name: "average cost", method: CostMethodAverage, from: from, to: to,
+			wantTrades: []string{"t2", "t6", "t4"},
+			wantPnL:    map[string]float64{"t4": 300 - 3 - (101+202)/2.0},
+		},
+		{
+			name: "open range", method: CostMethodFIFO,
+			wantTrades: []string{"t1", "t2", "t6", "t4", "t5"},
+			wantPnL:    map[string]float64{"t5": 400 - 4 - 202},
+		},
+		{
+			name: "to only", method: CostMethodFIFO, to: from,
+			wantTrades: []string{"t1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := tradeRecords(fills, tt.method, tt.from, tt.to)
+			trades := make([]string, 0, len(records))
+			for _, record := range records {
+				trades = append(trades, record.TradeUUID)
+				if record.Time.Location() != kst {
+					t.Errorf("%s: time %s is not in KST", record.TradeUUID, record.Time)
+				}
+				if want, ok := tt.wantPnL[record.TradeUUID]; ok && !approxEqual(record.RealizedPnL, want) {
+					t.Errorf("%s: realized %f, want %f", record.TradeUUID, record.RealizedPnL, want)
+				}
+			}
+			if !reflect.DeepEqual(trades, tt.wantTrades) {
+				t.Errorf("trades = %v, want %v", trades, tt.wantTrades)
+			}
+		})
+	}
+
+	records := tradeRecords(fills, CostMethodFIFO, from, to)
+	sell := records[len(records)-1]
+	if sell.Side != "sell" || sell.Market != "KRW-BTC" || sell.Value != 300 || sell.Fee != 3 || sell.OrderUUID != "order-t4" {
+		t.Errorf("sell record = %+v", sell)
+	}
+	if buy := records[0]; buy.Side != "buy" || buy.RealizedPnL != 0 {
+		t.Errorf("buy record = %+v", buy)
+	}
+}
+
+func TestWriteTradeRecordsCSV(t *testing.T) {
+	records := []TradeRecord{
+		{
+			Time: time.Date(2024, 1, 31, 23, 59, 59, 0, kst), Market: "KRW-BTC", Side: "sell",
+			Price: 61234567.5, Volume: 0.00012345, Fee: 3.78, Value: 7559.4, RealizedPnL: -12.25,
+			OrderUUID: "order-1", TradeUUID: "trade-1",
+		},
+		{
+			Time: time.Date(2024, 1, 31, 15, 0, 0, 0, time.UTC).In(kst), Market: "KRW-XRP", Side: "buy",
+			Price: 0.5, Volume: 1000, Value: 500, OrderUUID: "order-2", TradeUUID: "trade,2",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTradeRecordsCSV(&buf, records); err != nil {
+		t.Fatalf("writeTradeRecordsCSV failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	want := [][]string{
+		tradeRecordHeader,
+		{"2024-01-31 23:59:59", "KRW-BTC", "sell", "61234567.5", "0.00012345", "3.78", "7559.4", "-12.25", "order-1", "trade-1"},
+		{"2024-02-01 00:00:00", "KRW-XRP", "buy", "0.5", "1000", "0", "500", "0", "order-2", "trade,2"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	// 기록이 없어도 헤더는 출력
+	buf.Reset()
+	if err := writeTradeRecordsCSV(&buf, nil); err != nil {
+		t.Fatalf("writeTradeRecordsCSV failed: %v", err)
+	}
+	if got := buf.String(); got != "time_kst,market,side,price,volume,fee,krw_value,realized_pnl,order_uuid,trade_uuid\n" {
+		t.Errorf("empty export = %q", got)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"trading-bot/upbitmock"
+)
+
+// 가짜 업비트 서버에 연결된 봇 생성 (저널/기록 파일은 테스트 임시 디렉토리 사용)
+func newMockBot(t *testing.T, mock http.Handler, env map[string]string) *TradingBot {
+	t.Helper()
+
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	defaults := map[string]string{
+		"UPBIT_OPEN_API_SERVER_URL":  srv.URL,
+		"TRADING_MARKET":             "KRW-BTC",
+		"ORDER_JOURNAL_PATH":         filepath.Join(dir, "orders.jsonl"),
+		"SIGNAL_LOG_PATH":            filepath.Join(dir, "signals.jsonl"),
+		"FILL_LEDGER_PATH":           filepath.Join(dir, "fills.jsonl"),
+		"STOP_STATE_PATH":            filepath.Join(dir, "stops.json"),
+		"ENTRY_ORDER_TYPE":           OrderTypePrice,
+		"EXIT_ORDER_TYPE":            OrderTypeMarket,
+		"SIZING_MODE":                SizingFixedNotional,
+		"SIZING_NOTIONAL_KRW":        "100000",
+		"SIZING_SCALE_BY_CONFIDENCE": "false",
+	}
+	for key, value := range env {
+		defaults[key] = value
+	}
+	for key, value := range defaults {
+		t.Setenv(key, value)
+	}
+
+	bot, err := NewTradingBot(Config{AccessKey: "ak", SecretKey: "sk"})
+	if err != nil {
+		t.Fatalf("NewTradingBot failed: %v", err)
+	}
+	t.Cleanup(func() {
+		bot.journal.Close()
+		bot.signals.Close()
+		bot.ledger.Close()
+	})
+	return bot
+}
+
+// 가격 n개를 같은 값으로 채운 경로
+func flatPrices(price float64, n int) []float64 {
+	prices := make([]float64, n)
+	for i := range prices {
+		prices[i] = price
+	}
+	return prices
+}
+
+// 저널에서 방향별 접수된 주문 수
+func submittedOrders(bot *TradingBot, side string) int {
+	count := 0
+	for _, entry := range bot.journal.entries() {
+		if entry.Side == side && (entry.Status == JournalSubmitted || entry.Status == JournalRecovered) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTradeLoopBuysAndSells(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetBalance("KRW", 1000000, 0)
+
+	// 분석에 필요한 가격을 쌓은 뒤 하락(매수 규칙), 횡보, 상승(매도 규칙)
+	prices := flatPrices(100000, 25)
+	prices = append(prices, 90000, 100000, 120000)
+	mock.SetPricePath("KRW-BTC", prices...)
+
+	bot := newMockBot(t, mock, map[string]string{
+		"BUY_RULE":  "close < 95000",
+		"SELL_RULE": "close > 110000",
+	})
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	for i := 0; i < len(prices); i++ {
+		bot.executeTradeLoop(ctx)
+		if i == 25 && submittedOrders(bot, "bid") != 1 {
+			t.Fatalf("expected a buy order after the price drop, journal: %+v", bot.journal.entries())
+		}
+	}
+
+	if got := submittedOrders(bot, "bid"); got != 1 {
+		t.Fatalf("buy orders = %d, want 1", got)
+	}
+	if got := submittedOrders(bot, "ask"); got != 1 {
+		t.Fatalf("sell orders = %d, want 1", got)
+	}
+
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		t.Fatalf("getBalance failed: %v", err)
+	}
+	btc, _, _ := accountBalance(accounts, "BTC")
+	// 주문 수량은 소수점 8자리까지 전송되므로 그 이하 잔량은 남을 수 있음
+	if btc >= 1e-8 {
+		t.Fatalf("BTC balance = %g, want 0 after selling", btc)
+	}
+	krw, _, _ := accountBalance(accounts, "KRW")
+	if krw <= 1000000 {
+		t.Fatalf("KRW balance = %f, want a profit after buying at 90000 and selling at 120000", krw)
+	}
+
+	// 체결 장부와 손익
+	if _, err := bot.ledger.sync(ctx, bot); err != nil {
+		t.Fatalf("ledger sync failed: %v", err)
+	}
+	report, err := bot.pnlReport(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("pnl report failed: %v", err)
+	}
+	if report.Fills != 2 || report.Realized <= 0 {
+		t.Fatalf("pnl report = %+v, want 2 fills with positive realized PnL", report)
+	}
+	if !approxEqual(report.Equity, krw) {
+		t.Fatalf("equity = %f, want %f", report.Equity, krw)
+	}
+}
+
+func TestTradeLoopRespectsBalances(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	// 최소 주문 금액보다 적은 KRW로는 매수하지 않음
+	mock.SetBalance("KRW", 3000, 0)
+
+	prices := flatPrices(100000, 25)
+	prices = append(prices, 90000, 120000)
+	mock.SetPricePath("KRW-BTC", prices...)
+
+	bot := newMockBot(t, mock, map[string]string{
+		"BUY_RULE":  "close < 95000",
+		"SELL_RULE": "close > 110000",
+	})
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	for i := 0; i < len(prices); i++ {
+		bot.executeTradeLoop(ctx)
+	}
+
+	if entries := bot.journal.entries(); len(entries) != 0 {
+		t.Fatalf("expected no orders, journal: %+v", entries)
+	}
+}
+
+func TestReconcileRecoversPendingOrders(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 1000000, 0)
+
+	bot := newMockBot(t, mock, nil)
+	ctx := context.Background()
+
+	// 1. 거래소에 접수되었지만 응답 전에 종료된 주문 (intent 상태로 남음)
+	placed := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 90000, Identifier: "tb-placed"}
+	if err := bot.journal.record(newJournalEntry(placed, JournalIntent)); err != nil {
+		t.Fatalf("journal record failed: %v", err)
+	}
+	if _, err := bot.placeOrder(ctx, placed); err != nil {
+		t.Fatalf("placeOrder failed: %v", err)
+	}
+
+	// 2. 거래소에 도달하지 못한 주문
+	lost := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 80000, Identifier: "tb-lost"}
+	if err := bot.journal.record(newJournalEntry(lost, JournalIntent)); err != nil {
+		t.Fatalf("journal record failed: %v", err)
+	}
+
+	// 3. 봇이 제출하지 않은 미체결 주문 (식별자 없음)
+	manual := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 70000}
+	if _, err := bot.placeOrder(ctx, manual); err != nil {
+		t.Fatalf("placeOrder failed: %v", err)
+	}
+
+	report, err := bot.reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if entry, _ := bot.journal.lookup("tb-placed"); entry.Status != JournalRecovered || entry.UUID == "" {
+		t.Fatalf("placed order = %+v, want recovered with uuid", entry)
+	}
+	if entry, _ := bot.journal.lookup("tb-lost"); entry.Status != JournalFailed {
+		t.Fatalf("lost order = %+v, want failed", entry)
+	}
+	if report.OpenOrders != 2 {
+		t.Fatalf("open orders = %d, want 2", report.OpenOrders)
+	}
+	if len(report.Discrepancies) != 1 {
+		t.Fatalf("discrepancies = %v, want only the manual order", report.Discrepancies)
+	}
+
+	// 묶인 KRW가 보유 현황에 반영
+	bot.mu.RLock()
+	krw := bot.positions.Positions["KRW"]
+	bot.mu.RUnlock()
+	if krw.Locked <= 0 {
+		t.Fatalf("KRW position = %+v, want locked balance for open orders", krw)
+	}
+
+	// 재시작 후 저널로 중복 제출 방지
+	if _, err := bot.submitOrder(ctx, placed); err == nil {
+		t.Fatalf("expected duplicate submission of %s to be rejected", placed.Identifier)
+	}
+}
+
+func TestScreenerKeepsHeldMarkets(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	for market, volume := range map[string]float64{"KRW-BTC": 5e12, "KRW-ETH": 1e12, "KRW-XRP": 5e11} {
+		mock.AddMarket(upbitmock.Market{Market: market})
+		mock.SetPricePath(market, 1000)
+		mock.SetVolume(market, volume)
+	}
+	mock.SetBalance("KRW", 1000000, 0)
+
+	bot := newMockBot(t, mock, map[string]string{
+		"SCREENER_AUTO_SELECT":    "true",
+		"SCREENER_TOP_N":          "1",
+		"SCREENER_MIN_VOLUME_KRW": "0",
+	})
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	// 시작 이후에 생긴 보유 코인 (대조 시점의 보유 현황에는 없음)
+	mock.SetBalance("XRP", 100, 1000)
+
+	result, err := bot.screener.refresh(ctx, bot)
+	if err != nil {
+		t.Fatalf("screener refresh failed: %v", err)
+	}
+	want := []string{"KRW-BTC", "KRW-XRP"}
+	if strings.Join(result.Selected, ",") != strings.Join(want, ",") {
+		t.Fatalf("selected = %v, want %v", result.Selected, want)
+	}
+	if active := bot.activeMarkets(); strings.Join(active, ",") != strings.Join(want, ",") {
+		t.Fatalf("active markets = %v, want %v", active, want)
+	}
+}
+
+func TestWatcherLiquidatesHeldMarketOutsideActiveSet(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.AddMarket(upbitmock.Market{Market: "KRW-XRP", MarketEvent: upbitmock.MarketEvent{Warning: true}})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetPricePath("KRW-XRP", 1000)
+	mock.SetBalance("KRW", 1000000, 0)
+
+	bot := newMockBot(t, mock, map[string]string{
+		"MARKET_EVENT_POLICY": MarketEventPolicyLiquidate,
+	})
+	ctx := context.Background()
+	if _, err := bot.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if err := bot.StartTrading(time.Hour); err != nil {
+		t.Fatalf("StartTrading failed: %v", err)
+	}
+	defer bot.StopTrading()
+
+	// 거래 대상(KRW-BTC)이 아닌 보유 코인
+	mock.SetBalance("XRP", 100, 1000)
+
+	if err := bot.watcher.refresh(ctx, bot); err != nil {
+		t.Fatalf("watcher refresh failed: %v", err)
+	}
+
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		t.Fatalf("getBalance failed: %v", err)
+	}
+	if xrp, _, _ := accountBalance(accounts, "XRP"); xrp != 0 {
+		t.Fatalf("XRP balance = %f, want 0 after liquidation", xrp)
+	}
+	if got := submittedOrders(bot, "ask"); got != 1 {
+		t.Fatalf("sell orders = %d, want 1", got)
+	}
+}
+
+// 미체결/최근 주문이 한 페이지(100건)를 넘어도 저널과 어긋나지 않음
+func TestReconcilePaginatesOrders(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 100000000, 0)
+
+	bot := newMockBot(t, mock, nil)
+	ctx := context.Background()
+
+	const filled, resting = ordersPageSize + 20, ordersPageSize + 10
+	for i := 0; i < filled; i++ {
+		req := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypePrice, Price: 10000, Identifier: fmt.Sprintf("tb-filled-%03d", i)}
+		if _, err := bot.submitOrder(ctx, req); err != nil {
+			t.Fatalf("submitOrder failed: %v", err)
+		}
+	}
+	for i := 0; i < resting; i++ {
+		req := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 50000, Identifier: fmt.Sprintf("tb-resting-%03d", i)}
+		if _, err := bot.submitOrder(ctx, req); err != nil {
+			t.Fatalf("submitOrder failed: %v", err)
+		}
+	}
+
+	report, err := bot.reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if report.OpenOrders != resting || report.RecentOrders < filled {
+		t.Fatalf("report = %+v, want %d open and at least %d recent orders", report, resting, filled)
+	}
+	if len(report.Discrepancies) != 0 {
+		t.Fatalf("discrepancies = %v, want none", report.Discrepancies)
+	}
+}
+
+// TRADING_MARKET 없이 리밸런싱하면 목표 마켓이 감시/가격 조회 대상
+func TestRebalanceTargetsAreActiveWithoutTradingMarket(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.AddMarket(upbitmock.Market{Market: "KRW-ETH"})
+
+	bot := newMockBot(t, mock, map[string]string{
+		"TRADING_MARKET":    "",
+		"STRATEGY_MODE":     StrategyModeRebalance,
+		"REBALANCE_TARGETS": "KRW-BTC:50,KRW-ETH:30,KRW:20",
+	})
+
+	markets := bot.activeMarkets()
+	if len(markets) != 2 || !containsString(markets, "KRW-BTC") || !containsString(markets, "KRW-ETH") {
+		t.Fatalf("active markets = %v, want the rebalance targets", markets)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 주문 저널 상태
+const (
+	JournalIntent    = "intent"    // 주문 제출 직전 의도 기록
+	JournalSubmitted = "submitted" // 거래소 접수 확인
+	JournalFailed    = "failed"    // 제출 실패 (거래소에 주문 없음 확인)
+	JournalRecovered = "recovered" // 재시작 후 거래소에서 주문 확인
+)
+
+// JournalEntry 구조체 - 주문 저널 한 줄(JSON Lines)
+type JournalEntry struct {
+	Time        time.Time `json:"time"`
+	Identifier  string    `json:"identifier"`
+	Status      string    `json:"status"`
+	Market      string    `json:"market"`
+	Side        string    `json:"side"`
+	OrdType     string    `json:"ord_type"`
+	Volume      float64   `json:"volume,omitempty"`
+	Price       float64   `json:"price,omitempty"`
+	TimeInForce string    `json:"time_in_force,omitempty"`
+	UUID        string    `json:"uuid,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// OrderJournal 구조체 - 주문 의도와 결과를 append-only 파일에 기록
+type OrderJournal struct {
+	mu     sync.Mutex
+	file   *os.File
+	orders map[string]JournalEntry // identifier → 최신 상태
+}
+
+// 저널 파일을 열고 기존 기록을 읽어 식별자별 최신 상태를 복원
+func openOrderJournal(path string) (*OrderJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %v", err)
+	}
+
+	journal := &OrderJournal{
+		file:   file,
+		orders: make(map[string]JournalEntry),
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// 기록 도중 종료되어 잘린 마지막 줄은 무시
+			continue
+		}
+		journal.orders[entry.Identifier] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read journal: %v", err)
+	}
+
+	return journal, nil
+}
+
+// 저널에 기록 추가 - 디스크 동기화까지 완료된 후 반환
+func (j *OrderJournal) record(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %v", err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal entry: %v", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %v", err)
+	}
+
+	j.orders[entry.Identifier] = entry
+	return nil
+}
+
+// 식별자의 최신 저널 상태 조회
+func (j *OrderJournal) lookup(identifier string) (JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.orders[identifier]
+	return entry, ok
+}
+
+// 제출 결과를 알 수 없는 주문(intent 상태) 목록
+func (j *OrderJournal) pending() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var entries []JournalEntry
+	for _, entry := range j.orders {
+		if entry.Status == JournalIntent {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// 완료된 기록을 메모리에 유지하는 기간
+// 재조정 대조 기간과 가장 긴 신호 식별자 구간(1일)보다 길게 두어 같은 식별자를 다시 제출하지 않도록 한다.
+const journalRetention = 2 * reconcileLookback
+
+// 오래된 완료 기록을 메모리에서 정리하고 정리한 개수를 반환 (파일은 그대로 두며 재시작 시 다시 정리)
+// 결과를 알 수 없는 intent 기록과 아직 미체결인 주문의 기록은 남긴다.
+func (j *OrderJournal) compact(before time.Time, openOrders []Order) int {
+	open := make(map[string]bool, len(openOrders))
+	for _, order := range openOrders {
+		if order.Identifier != "" {
+			open[order.Identifier] = true
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	removed := 0
+	for identifier, entry := range j.orders {
+		if entry.Status == JournalIntent || open[identifier] || !entry.Time.Before(before) {
+			continue
+		}
+		delete(j.orders, identifier)
+		removed++
+	}
+	return removed
+}
+
+// 저널 파일 닫기
+func (j *OrderJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+// 주문 요청으로부터 저널 기록 생성
+func newJournalEntry(req OrderRequest, status string) JournalEntry {
+	return JournalEntry{
+		Identifier:  req.Identifier,
+		Status:      status,
+		Market:      req.Market,
+		Side:        req.Side,
+		OrdType:     req.OrdType,
+		Volume:      req.Volume,
+		Price:       req.Price,
+		TimeInForce: req.TimeInForce,
+	}
+}
+
+// 신호별 결정적 주문 식별자 생성
+// 같은 마켓, 같은 방향, 같은 거래 주기 구간의 신호는 항상 같은 식별자를 갖는다.
+func signalIdentifier(market, signalType string, at time.Time, interval time.Duration) string {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	bucket := at.Truncate(interval).Unix()
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", market, signalType, bucket)))
+	return "tb-" + hex.EncodeToString(hash[:])[:32]
+}
+
+// 저널 기반 주문 제출 - 의도를 먼저 기록한 후 거래소에 전송하여 재시작 시 중복 주문을 방지
+func (bot *TradingBot) submitOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	if bot.journal == nil {
+		return nil, fmt.Errorf("order journal is not available")
+	}
+	if req.Identifier == "" {
+		return nil, fmt.Errorf("order identifier is required")
+	}
+
+	// 이미 처리된 식별자는 다시 제출하지 않음
+	if entry, ok := bot.journal.lookup(req.Identifier); ok && entry.Status != JournalFailed {
+		return nil, fmt.Errorf("order %s already recorded with status %s", req.Identifier, entry.Status)
+	}
+
+	if err := bot.journal.record(newJournalEntry(req, JournalIntent)); err != nil {
+		return nil, err
+	}
+
+	order, err := bot.placeOrder(ctx, req)
+	if err != nil {
+		// 응답을 받지 못했을 수 있으므로 거래소에서 식별자로 확인
+		// 제출 요청이 취소된 경우에도 결과를 확인해야 하므로 별도 컨텍스트 사용
+		lookupCtx, cancel := context.WithTimeout(context.Background(), upbitRequestTimeout)
+		existing, lookupErr := bot.getOrderByIdentifier(lookupCtx, req.Identifier)
+		cancel()
+		switch {
+		case lookupErr != nil:
+			bot.logger.Error("Order %s outcome unknown, will reconcile later: %v", req.Identifier, lookupErr)
+		case existing != nil:
+			entry := newJournalEntry(req, JournalSubmitted)
+			entry.UUID = existing.UUID
+			if recordErr := bot.journal.record(entry); recordErr != nil {
+				bot.logger.Error("Failed to record order %s: %v", req.Identifier, recordErr)
+			}
+			return existing, nil
+		default:
+			entry := newJournalEntry(req, JournalFailed)
+			entry.Error = err.Error()
+			if recordErr := bot.journal.record(entry); recordErr != nil {
+				bot.logger.Error("Failed to record order %s: %v", req.Identifier, recordErr)
+			}
+		}
+		return nil, err
+	}
+
+	entry := newJournalEntry(req, JournalSubmitted)
+	entry.UUID = order.UUID
+	if err := bot.journal.record(entry); err != nil {
+		bot.logger.Error("Failed to record order %s: %v", req.Identifier, err)
+	}
+
+	return order, nil
+}
+
+// 재시작 시 제출 결과를 알 수 없는 주문을 거래소 기록과 대조
+func (bot *TradingBot) recoverPendingOrders(ctx context.Context) error {
+	if bot.journal == nil {
+		return fmt.Errorf("order journal is not available")
+	}
+
+	for _, entry := range bot.journal.pending() {
+		order, err := bot.getOrderByIdentifier(ctx, entry.Identifier)
+		if err != nil {
+			return fmt.Errorf("failed to look up order %s: %v", entry.Identifier, err)
+		}
+
+		entry.Time = time.Time{}
+		if order != nil {
+			entry.Status = JournalRecovered
+			entry.UUID = order.UUID
+			bot.logger.Info("Recovered order %s (uuid: %s, state: %s)", entry.Identifier, order.UUID, order.State)
+		} else {
+			entry.Status = JournalFailed
+			entry.Error = "order not found on exchange after restart"
+			bot.logger.Info("Order %s was never placed, marking as failed", entry.Identifier)
+		}
+
+		if err := bot.journal.record(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 식별자별 최신 저널 상태 목록
+func (j *OrderJournal) entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]JournalEntry, 0, len(j.orders))
+	for _, entry := range j.orders {
+		entries = append(entries, entry)
+	}
+	return entries
+}
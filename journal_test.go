@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"trading-bot/upbitmock"
+)
+
+// 주문은 거래소에 접수시키고 응답만 실패로 바꾸는 핸들러 (응답 유실)
+func dropOrderResponses(mock http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/orders" {
+			mock.ServeHTTP(httptest.NewRecorder(), r)
+			http.Error(w, `{"error":{"name":"server_error","message":"connection reset"}}`, http.StatusInternalServerError)
+			return
+		}
+		mock.ServeHTTP(w, r)
+	})
+}
+
+func newJournalTestMock() *upbitmock.Server {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetBalance("KRW", 1000000, 0)
+	return mock
+}
+
+func TestSubmitOrderFindsOrderAfterPlaceError(t *testing.T) {
+	mock := newJournalTestMock()
+	bot := newMockBot(t, dropOrderResponses(mock), nil)
+	ctx := context.Background()
+
+	req := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 90000, Identifier: "tb-dropped"}
+	order, err := bot.submitOrder(ctx, req)
+	if err != nil {
+		t.Fatalf("submitOrder should recover the placed order, got %v", err)
+	}
+	if order == nil || order.UUID == "" {
+		t.Fatalf("order = %+v, want the order found by identifier", order)
+	}
+
+	entry, ok := bot.journal.lookup(req.Identifier)
+	if !ok || entry.Status != JournalSubmitted || entry.UUID != order.UUID {
+		t.Fatalf("journal entry = %+v, want submitted with uuid %s", entry, order.UUID)
+	}
+
+	// 같은 식별자는 다시 제출하지 않음
+	if _, err := bot.submitOrder(ctx, req); err == nil {
+		t.Fatalf("expected duplicate submission to be rejected")
+	}
+	if open, err := bot.getAllOrders(ctx, "wait"); err != nil || len(open) != 1 {
+		t.Fatalf("open orders = %d (%v), want 1", len(open), err)
+	}
+}
+
+func TestSubmitOrderKeepsIntentWhenLookupFails(t *testing.T) {
+	mock := newJournalTestMock()
+	bot := newMockBot(t, dropOrderResponses(mock), nil)
+	ctx := context.Background()
+
+	mock.InjectFailure(upbitmock.Failure{Method: http.MethodGet, Path: "/v1/order", Status: http.StatusInternalServerError})
+
+	req := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 90000, Identifier: "tb-unknown"}
+	if _, err := bot.submitOrder(ctx, req); err == nil {
+		t.Fatalf("expected submitOrder to fail when the outcome is unknown")
+	}
+
+	entry, ok := bot.journal.lookup(req.Identifier)
+	if !ok || entry.Status != JournalIntent {
+		t.Fatalf("journal entry = %+v, want intent", entry)
+	}
+	if pending := bot.journal.pending(); len(pending) != 1 || pending[0].Identifier != req.Identifier {
+		t.Fatalf("pending = %+v, want %s", pending, req.Identifier)
+	}
+
+	// 결과를 확인하기 전까지 다시 제출하지 않음 (거래소에는 이미 주문이 있음)
+	if _, err := bot.submitOrder(ctx, req); err == nil {
+		t.Fatalf("expected resubmission of an unresolved order to be rejected")
+	}
+}
+
+func TestRecoverPendingOrdersAfterRestart(t *testing.T) {
+	mock := newJournalTestMock()
+	journalPath := filepath.Join(t.TempDir(), "orders.jsonl")
+	env := map[string]string{"ORDER_JOURNAL_PATH": journalPath}
+	ctx := context.Background()
+
+	// 첫 실행: 접수된 주문과 거래소에 도달하지 못한 주문 모두 결과 확인 전에 종료
+	bot := newMockBot(t, dropOrderResponses(mock), env)
+	placed := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 90000, Identifier: "tb-placed"}
+	mock.InjectFailure(upbitmock.Failure{Method: http.MethodGet, Path: "/v1/order", Status: http.StatusInternalServerError})
+	if _, err := bot.submitOrder(ctx, placed); err == nil {
+		t.Fatalf("expected submitOrder to fail when the outcome is unknown")
+	}
+	lost := OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 0.1, Price: 80000, Identifier: "tb-lost"}
+	if err := bot.journal.record(newJournalEntry(lost, JournalIntent)); err != nil {
+		t.Fatalf("journal record failed: %v", err)
+	}
+	bot.journal.Close()
+
+	// 재시작: 저널 파일에서 intent 상태를 읽어 거래소와 대조
+	restarted := newMockBot(t, mock, env)
+	if pending := restarted.journal.pending(); len(pending) != 2 {
+		t.Fatalf("pending after restart = %+v, want 2", pending)
+	}
+	if err := restarted.recoverPendingOrders(ctx); err != nil {
+		t.Fatalf("recoverPendingOrders failed: %v", err)
+	}
+
+	if entry, _ := restarted.journal.lookup(placed.Identifier); entry.Status != JournalRecovered || entry.UUID == "" {
+		t.Fatalf("placed order = %+v, want recovered with uuid", entry)
+	}
+	if entry, _ := restarted.journal.lookup(lost.Identifier); entry.Status != JournalFailed {
+		t.Fatalf("lost order = %+v, want failed", entry)
+	}
+	if pending := restarted.journal.pending(); len(pending) != 0 {
+		t.Fatalf("pending after recovery = %+v, want none", pending)
+	}
+
+	// 접수된 주문은 다시 제출하지 않고, 도달하지 못한 주문만 다시 제출 가능
+	if _, err := restarted.submitOrder(ctx, placed); err == nil {
+		t.Fatalf("expected duplicate submission of %s to be rejected", placed.Identifier)
+	}
+	if _, err := restarted.submitOrder(ctx, lost); err != nil {
+		t.Fatalf("resubmitting a failed order should succeed: %v", err)
+	}
+	if open, err := restarted.getAllOrders(ctx, "wait"); err != nil || len(open) != 2 {
+		t.Fatalf("open orders = %d (%v), want 2", len(open), err)
+	}
+}
+
+func TestOrderJournalCompact(t *testing.T) {
+	journal, err := openOrderJournal(filepath.Join(t.TempDir(), "orders.jsonl"))
+	if err != nil {
+		t.Fatalf("openOrderJournal failed: %v", err)
+	}
+	defer journal.Close()
+
+	now := time.Now()
+	old := now.Add(-journalRetention - time.Hour)
+	for _, entry := range []JournalEntry{
+		{Time: old, Identifier: "old-submitted", Status: JournalSubmitted, UUID: "u1"},
+		{Time: old, Identifier: "old-failed", Status: JournalFailed},
+		{Time: old, Identifier: "old-recovered", Status: JournalRecovered, UUID: "u2"},
+		{Time: old, Identifier: "old-intent", Status: JournalIntent},
+		{Time: old, Identifier: "old-open", Status: JournalSubmitted, UUID: "u3"},
+		{Time: now, Identifier: "recent", Status: JournalSubmitted, UUID: "u4"},
+	} {
+		if err := journal.record(entry); err != nil {
+			t.Fatalf("journal record failed: %v", err)
+		}
+	}
+
+	openOrders := []Order{{UUID: "u3", Identifier: "old-open"}, {UUID: "manual"}}
+	if removed := journal.compact(now.Add(-journalRetention), openOrders); removed != 3 {
+		t.Fatalf("removed = %d, want 3", removed)
+	}
+	for _, identifier := range []string{"old-intent", "old-open", "recent"} {
+		if _, ok := journal.lookup(identifier); !ok {
+			t.Errorf("%s should be kept", identifier)
+		}
+	}
+	for _, identifier := range []string{"old-submitted", "old-failed", "old-recovered"} {
+		if _, ok := journal.lookup(identifier); ok {
+			t.Errorf("%s should be compacted", identifier)
+		}
+	}
+}
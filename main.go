@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"crypto/sha512"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,15 +10,16 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"sort"
 	"strconv" // 이 라인 추가
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
@@ -33,39 +32,61 @@ type TechnicalIndicators struct {
 type Logger struct {
 	EnableDebug bool
 	LogFile     *os.File
+	mu          sync.Mutex // 여러 고루틴의 로그 파일 쓰기 보호
 }
 
 // 로깅 함수들
 func (l *Logger) Info(format string, v ...interface{}) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logMsg := fmt.Sprintf("[INFO] %s: %s\n", timestamp, fmt.Sprintf(format, v...))
-	log.Print(logMsg)
-	if l.LogFile != nil {
-		l.LogFile.WriteString(logMsg)
-	}
+	l.write("INFO", format, v...)
 }
 
 func (l *Logger) Debug(format string, v ...interface{}) {
 	if !l.EnableDebug {
 		return
 	}
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logMsg := fmt.Sprintf("[DEBUG] %s: %s\n", timestamp, fmt.Sprintf(format, v...))
-	log.Print(logMsg)
-	if l.LogFile != nil {
-		l.LogFile.WriteString(logMsg)
-	}
+	l.write("DEBUG", format, v...)
 }
 
 func (l *Logger) Error(format string, v ...interface{}) {
+	l.write("ERROR", format, v...)
+}
+
+func (l *Logger) write(level, format string, v ...interface{}) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logMsg := fmt.Sprintf("[ERROR] %s: %s\n", timestamp, fmt.Sprintf(format, v...))
+	logMsg := fmt.Sprintf("[%s] %s: %s\n", level, timestamp, fmt.Sprintf(format, v...))
 	log.Print(logMsg)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.LogFile != nil {
 		l.LogFile.WriteString(logMsg)
 	}
 }
 
+// 로그 파일 flush 후 닫기
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.LogFile == nil {
+		return nil
+	}
+	if err := l.LogFile.Sync(); err != nil {
+		return err
+	}
+	err := l.LogFile.Close()
+	l.LogFile = nil
+	return err
+}
+
+// 분석용 가격 데이터 복사본 (잠금 밖에서 계산할 때 사용)
+func (t *TechnicalIndicators) snapshot() *TechnicalIndicators {
+	return &TechnicalIndicators{
+		Prices: append([]float64(nil), t.Prices...),
+		Volume: append([]float64(nil), t.Volume...),
+	}
+}
+
 // 이동평균 계산
 func (t *TechnicalIndicators) calculateMA(period int) float64 {
 	if len(t.Prices) < period {
@@ -128,6 +149,10 @@ type Config struct {
 	AccessKey string
 	SecretKey string
 	Port      string
+
+	APIJWTSecret string // 제어 API 토큰 서명 키 (업비트 시크릿과 별도)
+	APIUsers     string // 제어 API 사용자 목록 (이름:권한:해시)
+	APIKeys      string // 제어 API 키 목록 (키ID:권한:해시)
 }
 
 // JWT 클레임 구조체
@@ -140,15 +165,39 @@ type Claims struct {
 }
 
 // 1. TradingBot 구조체에 cancelFunc 필드 추가
+// mu는 공유 상태만 보호하며 네트워크 호출 중에는 잡지 않는다. tickMu는 거래 주기가 겹치지 않도록 한다.
 type TradingBot struct {
 	config      Config
-	indicators  *TechnicalIndicators
+	indicators  map[string]*TechnicalIndicators // 마켓별 가격 데이터
+	markets     []string                        // 거래 대상 마켓 (활성 세트)
+	stops       map[string]*PositionStop        // 마켓별 보유 포지션 손절 상태
+	screener    *MarketScreener
+	pricer      *OrderPricer
+	executor    *Executor
+	watcher     *MarketWatcher
+	timeframes  *MultiTimeframe // 마켓별 분석 시간대
+	rebalancer  *Rebalancer     // 목표 비중 유지 (STRATEGY_MODE=rebalance)
+	notifier    Notifier
 	strategy    *TradingStrategy
 	riskManager *RiskManager
 	isRunning   bool
+	stopping    bool // 종료 중이면 거래 시작/리밸런싱 거부
 	mu          sync.RWMutex
+	tickMu      sync.Mutex
 	logger      *Logger
 	cancelFunc  context.CancelFunc
+	journal     *OrderJournal
+	signals     *SignalLog // 신호 판단 근거 기록
+	ledger      *Ledger    // 체결 내역 (손익 계산)
+	interval    time.Duration
+	signer      *UpbitSigner
+
+	background     sync.WaitGroup     // 거래 루프와 주기 작업 고루틴 (종료 시 모두 대기)
+	stopBackground context.CancelFunc // 주기 작업(스크리너, 감시, 보유 현황, 체결 동기화) 중지
+
+	positions       *PositionBook         // 거래소 기준 보유 현황
+	reconciliation  *ReconciliationReport // 마지막 대조 결과 (nil이면 거래 불가)
+	reconcileStrict bool                  // 불일치가 있으면 거래 시작 거부
 }
 
 // 2. 트레이딩 타입 변환 함수 추가
@@ -165,64 +214,73 @@ func convertSignalTypeToUpbitSide(signalType string) string {
 
 // TradeSignal 구조체
 type TradeSignal struct {
-	Type       string // "buy", "sell", "hold"
-	Price      float64
-	Volume     float64
-	Confidence float64
+	Type        string // "buy", "sell", "hold"
+	Price       float64
+	Volume      float64
+	Confidence  float64
+	Notional    float64 // 주문 명목 금액 (KRW)
+	OrderType   string  // 주문 유형 (limit, price, market, best)
+	TimeInForce string  // 주문 체결 조건 (ioc, fok)
+	Identifier  string  // 클라이언트 주문 식별자
 }
 
 // RiskManager 구조체 및 메서드
 type RiskManager struct {
-	MaxPositionSize float64
+	MaxPositionSize float64 // 주문당 최대 명목 금액 (KRW)
 	StopLoss        float64
 	TakeProfit      float64
 	MaxDrawdown     float64
 	DailyLimit      float64
+	MaxRiskPerTrade float64 // 손절 시 허용 손실 비율 (잔고 대비)
+	Sizer           *PositionSizer
+
+	ExitPolicy       string  // 매도 신호 청산 정책 (full, partial)
+	PartialExitRatio float64 // partial 정책에서 매도할 보유 수량 비율
+
+	Stops *StopRules // 추적/본전/시간 청산 규칙
 }
 
-func (rm *RiskManager) calculatePositionSize(signal TradeSignal, balance float64, currentPrice float64) float64 {
-	// 1. 기본 포지션 크기 계산
-	baseSize := balance * 0.02 // 기본적으로 계좌의 2% 사용
+// 포지션 크기 계산 - KRW 명목 금액을 먼저 정하고 현재가로 코인 수량을 환산한다.
+func (rm *RiskManager) calculatePositionSize(signal TradeSignal, balance float64, currentPrice float64, atr float64) PositionSize {
+	if balance <= 0 || currentPrice <= 0 || rm.Sizer == nil {
+		return PositionSize{}
+	}
 
-	// 2. 신뢰도에 따른 조정
-	adjustedSize := baseSize * signal.Confidence
+	// 1. 산정 방식에 따른 명목 금액 계산
+	notional := rm.Sizer.targetNotional(signal.Confidence, balance, currentPrice, atr)
 
-	// 3. 최대 포지션 크기 제한
-	if adjustedSize > rm.MaxPositionSize {
-		adjustedSize = rm.MaxPositionSize
+	// 2. 최대 포지션 크기 제한
+	if rm.MaxPositionSize > 0 && notional > rm.MaxPositionSize {
+		notional = rm.MaxPositionSize
 	}
 
-	// 4. 스탑로스 기반 포지션 크기 조정
-	riskAmount := currentPrice * (rm.StopLoss / 100)
-	if riskAmount > 0 {
-		maxSizeByRisk := (balance * 0.02) / riskAmount // 최대 2% 리스크
-		if adjustedSize > maxSizeByRisk {
-			adjustedSize = maxSizeByRisk
+	// 3. 스탑로스 기반 제한 - 손절 시 손실이 잔고의 MaxRiskPerTrade를 넘지 않도록
+	if rm.StopLoss > 0 && rm.MaxRiskPerTrade > 0 {
+		maxNotionalByRisk := (balance * rm.MaxRiskPerTrade) / (rm.StopLoss / 100)
+		if notional > maxNotionalByRisk {
+			notional = maxNotionalByRisk
 		}
 	}
 
-	return adjustedSize
-}
-
-// 리스크 체크
-func (rm *RiskManager) checkRisk(position float64, currentPrice float64, entryPrice float64) bool {
-	// 스탑로스 체크
-	loss := ((entryPrice - currentPrice) / entryPrice) * 100
-	if loss > rm.StopLoss {
-		return false
+	// 4. 수수료를 포함해 잔고를 초과하지 않도록 제한
+	maxNotionalByBalance := balance / (1 + rm.Sizer.FeeRate)
+	if notional > maxNotionalByBalance {
+		notional = maxNotionalByBalance
 	}
 
-	// 익절 체크
-	profit := ((currentPrice - entryPrice) / entryPrice) * 100
-	if profit > rm.TakeProfit {
-		return false
+	// 5. 최소 주문 금액 미만이면 주문하지 않음
+	if notional < rm.Sizer.MinOrder || notional <= 0 {
+		return PositionSize{}
 	}
 
-	return true
+	return PositionSize{
+		Notional: notional,
+		Volume:   notional / currentPrice,
+	}
 }
 
 // fetchCurrentPrice 함수 수정 - 더 많은 오류 검사 추가
-func (bot *TradingBot) fetchCurrentPrice(market string) (float64, error) {
+func (bot *TradingBot) fetchCurrentPrice(ctx context.Context, market string) (float64, error) {
 	if market == "" {
 		return 0, fmt.Errorf("market parameter is empty")
 	}
@@ -233,8 +291,8 @@ func (bot *TradingBot) fetchCurrentPrice(market string) (float64, error) {
 
 	bot.logger.Debug("Fetching price from: %s", apiUrl)
 
-	client := &http.Client{Timeout: time.Second * 10}
-	req, err := http.NewRequest("GET", apiUrl, nil)
+	client := &http.Client{Timeout: upbitRequestTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", apiUrl, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -278,12 +336,13 @@ func (bot *TradingBot) fetchCurrentPrice(market string) (float64, error) {
 	return tickers[0].TradePrice, nil
 }
 
-// TradingStrategy 수정된 분석 함수
-func (ts *TradingStrategy) analyzeSignals(indicators *TechnicalIndicators) TradeSignal {
+// TradingStrategy 수정된 분석 함수 - 신호와 함께 조건별 판정 근거를 반환
+// 반환된 근거의 Time, Market, Source는 호출하는 쪽에서 채운다.
+func (ts *TradingStrategy) analyzeSignals(indicators *TechnicalIndicators) (TradeSignal, *SignalExplanation) {
 	shortMA := indicators.calculateMA(ts.ShortMA)
 	longMA := indicators.calculateMA(ts.LongMA)
 	rsi := indicators.calculateRSI(ts.RSIPeriod)
-	_, upperBB, lowerBB := indicators.calculateBollingerBands(ts.BBPeriod, ts.BBStdDev)
+	middleBB, upperBB, lowerBB := indicators.calculateBollingerBands(ts.BBPeriod, ts.BBStdDev)
 
 	currentPrice := indicators.Prices[len(indicators.Prices)-1]
 	signal := TradeSignal{
@@ -291,25 +350,52 @@ func (ts *TradingStrategy) analyzeSignals(indicators *TechnicalIndicators) Trade
 		Price: currentPrice,
 	}
 
+	explanation := &SignalExplanation{
+		Signal: "hold",
+		Inputs: SignalInputs{
+			Price:    currentPrice,
+			ShortMA:  shortMA,
+			LongMA:   longMA,
+			RSI:      rsi,
+			BBMiddle: middleBB,
+			BBUpper:  upperBB,
+			BBLower:  lowerBB,
+			Samples:  len(indicators.Prices),
+		},
+	}
+
+	buy, buyConditions := ts.BuyRule.evaluate(indicators, "buy")
+	sell, sellConditions := ts.SellRule.evaluate(indicators, "sell")
+	explanation.Conditions = append(buyConditions, sellConditions...)
+
 	// 매수 신호
-	if shortMA > longMA && rsi < 30 && currentPrice < lowerBB {
+	if buy {
+		breakdown := ts.scoreConfidence(indicators, "buy")
 		signal.Type = "buy"
 		signal.Volume = 0.0 // 실제 거래량은 RiskManager에서 계산
-		signal.Confidence = calculateConfidence(shortMA, longMA, rsi, currentPrice, lowerBB)
+		signal.Confidence = breakdown.Total
+		signal.OrderType = ts.EntryOrderType
+		signal.TimeInForce = ts.TimeInForce
+		explanation.Confidence = &breakdown
 	}
 
-	// 매도 신호
-	if shortMA < longMA && rsi > 70 && currentPrice > upperBB {
+	// 매도 신호 (매수/매도 규칙이 동시에 충족되면 매도 우선)
+	if sell {
+		breakdown := ts.scoreConfidence(indicators, "sell")
 		signal.Type = "sell"
 		signal.Volume = 0.0 // 실제 거래량은 RiskManager에서 계산
-		signal.Confidence = calculateConfidence(shortMA, longMA, rsi, currentPrice, upperBB)
+		signal.Confidence = breakdown.Total
+		signal.OrderType = ts.ExitOrderType
+		signal.TimeInForce = ts.TimeInForce
+		explanation.Confidence = &breakdown
 	}
 
-	return signal
+	explanation.Signal = signal.Type
+	return signal, explanation
 }
 
-// 신뢰도 계산 함수 (0~1 사이 값 반환)
-func calculateConfidence(shortMA, longMA, rsi, price, band float64) float64 {
+// 신뢰도 계산 함수 - raw 방식 (구성 요소와 0~1 사이 최종 값 반환)
+func calculateConfidence(shortMA, longMA, rsi, price, band float64, weights ConfidenceWeights) ConfidenceBreakdown {
 	// MA 시그널 강도
 	maSignal := math.Abs(shortMA-longMA) / longMA
 
@@ -325,16 +411,26 @@ func calculateConfidence(shortMA, longMA, rsi, price, band float64) float64 {
 	bandSignal := math.Abs(price-band) / band
 
 	// 종합 신뢰도 계산 (각 지표의 가중 평균)
-	confidence := (maSignal*0.4 + rsiSignal*0.3 + bandSignal*0.3)
+	breakdown := ConfidenceBreakdown{
+		Mode:          ConfidenceRaw,
+		MAStrength:    maSignal,
+		RSIStrength:   rsiSignal,
+		BandStrength:  bandSignal,
+		MAComponent:   maSignal * weights.MA,
+		RSIComponent:  rsiSignal * weights.RSI,
+		BandComponent: bandSignal * weights.Band,
+	}
+	confidence := breakdown.MAComponent + breakdown.RSIComponent + breakdown.BandComponent
 
 	// 0~1 사이로 정규화
 	if confidence > 1 {
 		confidence = 1
 	}
+	breakdown.Total = confidence
 
-	return confidence
+	return breakdown
 }
-func NewTradingBot(config Config) *TradingBot {
+func NewTradingBot(config Config) (*TradingBot, error) {
 	// 로그 디렉토리 확인 및 생성
 	if err := os.MkdirAll("/app/logs", 0755); err != nil {
 		log.Printf("Warning: Failed to create log directory: %v", err)
@@ -348,9 +444,22 @@ func NewTradingBot(config Config) *TradingBot {
 		EnableDebug: true,
 		LogFile:     logFile,
 	}
-	// 환경 변수 검증
-	market := os.Getenv("TRADING_MARKET")
-	if market == "" {
+	// 환경 변수 검증 (쉼표로 여러 마켓 지정 가능)
+	markets := parseMarkets(os.Getenv("TRADING_MARKET"))
+	rebalancer, err := newRebalancerFromEnv()
+	if err != nil {
+		logger.Close()
+		return nil, err
+	}
+	// 리밸런싱 방식은 REBALANCE_TARGETS의 마켓을 사용 - 감시/가격 조회 대상에도 포함
+	if rebalancer.enabled() {
+		for _, market := range rebalancer.markets() {
+			if !containsString(markets, market) {
+				markets = append(markets, market)
+			}
+		}
+	}
+	if len(markets) == 0 && !rebalancer.enabled() {
 		logger.Error("TRADING_MARKET environment variable is not set")
 	}
 	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL")
@@ -358,50 +467,146 @@ func NewTradingBot(config Config) *TradingBot {
 		logger.Error("UPBIT_OPEN_API_SERVER_URL environment variable is not set. Using https://api.upbit.com as default.")
 		os.Setenv("UPBIT_OPEN_API_SERVER_URL", "https://api.upbit.com")
 	}
+	scorer, err := newConfidenceScorerFromEnv()
+	if err != nil {
+		logger.Close()
+		return nil, err
+	}
+	strategy := &TradingStrategy{
+		ShortMA:        10,
+		LongMA:         20,
+		RSIPeriod:      14,
+		BBPeriod:       20,
+		BBStdDev:       2.0,
+		EntryOrderType: getEnvOrDefault("ENTRY_ORDER_TYPE", OrderTypeLimit),
+		ExitOrderType:  getEnvOrDefault("EXIT_ORDER_TYPE", OrderTypeLimit),
+		TimeInForce:    os.Getenv("ORDER_TIME_IN_FORCE"),
+		Scorer:         scorer,
+	}
+	// 매수/매도 규칙 (설정하지 않으면 기존 반전 전략 조건)
+	// 분석 이력은 가격 샘플 maxPriceSamples개, 다중 시간대 분석이면 마감된 캔들 maxCandleCount-1개까지
+	timeframes, err := newMultiTimeframeFromEnv()
+	if err != nil {
+		logger.Close()
+		return nil, err
+	}
+	pricer, err := newOrderPricerFromEnv()
+	if err != nil {
+		logger.Close()
+		return nil, err
+	}
+	maxDataPoints := maxPriceSamples
+	if timeframes.Default != nil {
+		maxDataPoints = maxCandleCount - 1
+	}
+	if strategy.BuyRule, err = newSignalRuleFromEnv("BUY_RULE", strategy.defaultBuyRule(), maxDataPoints); err != nil {
+		logger.Close()
+		return nil, err
+	}
+	if strategy.SellRule, err = newSignalRuleFromEnv("SELL_RULE", strategy.defaultSellRule(), maxDataPoints); err != nil {
+		logger.Close()
+		return nil, err
+	}
+	// 주문 저널 열기 - 저널 없이는 주문을 제출하지 않음
+	journal, err := openOrderJournal(getEnvOrDefault("ORDER_JOURNAL_PATH", "/app/logs/orders.jsonl"))
+	if err != nil {
+		logger.Error("Failed to open order journal: %v. Orders will be rejected.", err)
+	}
+	// 신호 판단 근거 기록 - 실패해도 거래는 계속 진행
+	signals, err := openSignalLog(getEnvOrDefault("SIGNAL_LOG_PATH", "/app/logs/signals.jsonl"),
+		getEnvInt("SIGNAL_HISTORY_SIZE", 1000))
+	if err != nil {
+		logger.Error("Failed to open signal log: %v. Signal explanations will not be recorded.", err)
+	}
+	// 체결 장부 - 실패해도 거래는 계속 진행 (손익 조회만 불가)
+	ledger, err := openLedger(getEnvOrDefault("FILL_LEDGER_PATH", "/app/logs/fills.jsonl"),
+		getEnvOrDefault("PNL_COST_METHOD", CostMethodFIFO),
+		time.Duration(getEnvInt("FILL_SYNC_INTERVAL_SECONDS", 300))*time.Second)
+	if err != nil {
+		logger.Error("Failed to open fill ledger: %v. PnL will not be available.", err)
+	}
+	// 보유 포지션 청산 규칙 - 추적 손절 최고가는 파일에서 이어받음
+	stopRules := newStopRulesFromEnv()
+	stops, err := loadPositionStops(stopRules.StatePath)
+	if err != nil {
+		logger.Error("Failed to load position stops: %v. Trailing stops restart from current prices.", err)
+	}
 	return &TradingBot{
-		config:     config,
-		indicators: &TechnicalIndicators{},
-		strategy: &TradingStrategy{
-			ShortMA:   10,
-			LongMA:    20,
-			RSIPeriod: 14,
-			BBPeriod:  20,
-			BBStdDev:  2.0,
-		},
+		config:          config,
+		indicators:      make(map[string]*TechnicalIndicators),
+		markets:         markets,
+		stops:           stops,
+		strategy:        strategy,
+		journal:         journal,
+		signals:         signals,
+		ledger:          ledger,
+		screener:        newMarketScreenerFromEnv(),
+		pricer:          pricer,
+		executor:        newExecutorFromEnv(),
+		watcher:         newMarketWatcherFromEnv(),
+		timeframes:      timeframes,
+		rebalancer:      rebalancer,
+		notifier:        newNotifierFromEnv(logger),
+		signer:          &UpbitSigner{AccessKey: config.AccessKey, SecretKey: config.SecretKey},
+		reconcileStrict: os.Getenv("RECONCILE_STRICT") == "true",
 		riskManager: &RiskManager{
-			MaxPositionSize: 1000.0,
-			StopLoss:        2.0,
-			TakeProfit:      3.0,
-			MaxDrawdown:     5.0,
-			DailyLimit:      10000.0,
+			MaxPositionSize:  100000.0,
+			StopLoss:         getEnvFloat("STOP_LOSS_PERCENT", 2.0),
+			TakeProfit:       getEnvFloat("TAKE_PROFIT_PERCENT", 3.0),
+			MaxDrawdown:      5.0,
+			DailyLimit:       10000.0,
+			MaxRiskPerTrade:  0.02,
+			Sizer:            newPositionSizerFromEnv(),
+			ExitPolicy:       getEnvOrDefault("EXIT_POLICY", ExitPolicyFull),
+			PartialExitRatio: getEnvFloat("PARTIAL_EXIT_RATIO", 0.5),
+			Stops:            stopRules,
 		},
 		logger: logger,
-	}
+	}, nil
 }
 
 // StartTrading 함수 수정 - 컨텍스트 추가
-func (bot *TradingBot) StartTrading(interval time.Duration) {
+func (bot *TradingBot) StartTrading(interval time.Duration) error {
 	bot.mu.Lock()
 	if bot.isRunning {
 		bot.logger.Info("Trading bot is already running")
 		bot.mu.Unlock()
-		return
+		return nil
+	}
+	if bot.stopping {
+		bot.mu.Unlock()
+		return fmt.Errorf("trading bot is shutting down")
+	}
+	// 시작 시 대조가 끝나기 전에는 거래 루프를 실행하지 않음
+	if bot.reconciliation == nil {
+		bot.mu.Unlock()
+		return fmt.Errorf("reconciliation has not completed")
+	}
+	if bot.reconcileStrict && len(bot.reconciliation.Discrepancies) > 0 {
+		bot.mu.Unlock()
+		return fmt.Errorf("reconciliation found %d discrepancies", len(bot.reconciliation.Discrepancies))
 	}
 	bot.isRunning = true
+	bot.interval = interval
+	// 종료 중이 아닐 때만 추가되므로 Shutdown의 Wait 이후에는 늘어나지 않음
+	bot.background.Add(1)
+
+	// 컨텍스트로 취소 처리 - 취소 함수는 잠금 안에서 저장해 StopTrading과 경쟁하지 않도록 함
+	ctx, cancel := context.WithCancel(context.Background())
+	bot.cancelFunc = cancel
 	bot.mu.Unlock()
 
 	bot.logger.Info("Starting trading with interval: %v", interval)
 
-	// 컨텍스트로 취소 처리
-	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
+		defer bot.background.Done()
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				bot.executeTradeLoop()
+				bot.executeTradeLoop(ctx)
 			case <-ctx.Done():
 				bot.logger.Info("Trading stopped")
 				return
@@ -409,8 +614,68 @@ func (bot *TradingBot) StartTrading(interval time.Duration) {
 		}
 	}()
 
-	// 취소 함수를 저장하면 나중에 StopTrading에서 사용 가능
-	bot.cancelFunc = cancel
+	return nil
+}
+
+// 주기 작업 시작 - 모두 background에 등록되어 Shutdown이 끝날 때까지 대기한다.
+func (bot *TradingBot) startBackground(ctx context.Context) error {
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+
+	if bot.stopping {
+		return fmt.Errorf("trading bot is shutting down")
+	}
+	if bot.stopBackground != nil {
+		return fmt.Errorf("background tasks are already running")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	bot.stopBackground = cancel
+
+	refreshInterval := time.Duration(getEnvInt("POSITION_REFRESH_SECONDS", 60)) * time.Second
+	tasks := []func(){
+		// 마켓 스크리너 주기 실행
+		func() { bot.screener.run(ctx, bot) },
+		// 유의/주의 종목 감시
+		func() { bot.watcher.run(ctx, bot) },
+		// 보유 현황 주기 갱신
+		func() { bot.runPositionRefresh(ctx, refreshInterval) },
+	}
+	// 체결 내역 주기 동기화
+	if bot.ledger != nil {
+		tasks = append(tasks, func() { bot.ledger.run(ctx, bot) })
+	}
+	for _, task := range tasks {
+		bot.background.Add(1)
+		go func(task func()) {
+			defer bot.background.Done()
+			task()
+		}(task)
+	}
+	return nil
+}
+
+// 상태 조회용 스냅샷 (짧은 읽기 잠금)
+func (bot *TradingBot) status() gin.H {
+	bot.mu.RLock()
+	defer bot.mu.RUnlock()
+
+	samples := make(map[string]int, len(bot.indicators))
+	for market, indicators := range bot.indicators {
+		samples[market] = len(indicators.Prices)
+	}
+
+	return gin.H{
+		"is_running":      bot.isRunning,
+		"strategy":        bot.strategy,
+		"reconciliation":  bot.reconciliation,
+		"markets":         bot.markets,
+		"flagged_markets": bot.watcher.snapshot(),
+		"position_stops":  bot.positionStopsLocked(),
+		"price_samples":   samples,
+		"timeframes":      bot.timeframes.describe(bot.markets),
+		"strategy_mode":   bot.rebalancer.Mode,
+		"last_rebalance":  bot.rebalancer.lastPlan(),
+	}
 }
 
 // 5. StopTrading 함수 추가
@@ -435,7 +700,40 @@ func (bot *TradingBot) StopTrading() {
 // Market Event 구조체
 type MarketEvent struct {
 	Warning bool   `json:"warning"` // 유의종목 여부
-	Caution string `json:"caution"` // 주의종목 타입
+	Caution string `json:"caution"` // 주의종목 타입 (여러 개이면 쉼표로 구분)
+}
+
+// 주의종목 정보는 문자열 또는 {"PRICE_FLUCTUATIONS": true, ...} 형태의 플래그 객체로 올 수 있음
+func (e *MarketEvent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Warning bool            `json:"warning"`
+		Caution json.RawMessage `json:"caution"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	e.Warning = raw.Warning
+	e.Caution = ""
+	if len(raw.Caution) == 0 || string(raw.Caution) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw.Caution, &e.Caution); err == nil {
+		return nil
+	}
+	var flags map[string]bool
+	if err := json.Unmarshal(raw.Caution, &flags); err != nil {
+		return fmt.Errorf("invalid market_event.caution: %s", string(raw.Caution))
+	}
+	cautions := make([]string, 0, len(flags))
+	for name, active := range flags {
+		if active {
+			cautions = append(cautions, name)
+		}
+	}
+	sort.Strings(cautions)
+	e.Caution = strings.Join(cautions, ",")
+	return nil
 }
 
 // Market 구조체
@@ -446,15 +744,34 @@ type Market struct {
 	MarketEvent MarketEvent `json:"market_event"` // 시장 경고 정보
 }
 
-// 마켓 정보를 가져오는 함수
-func (bot *TradingBot) fetchMarkets() ([]Market, error) {
-	apiUrl := "https://api.upbit.com/v1/market/all?is_details=true"
+// 마켓 정보를 가져오는 함수 (유의/주의 종목 제외)
+func (bot *TradingBot) fetchMarkets(ctx context.Context) ([]Market, error) {
+	markets, err := bot.fetchAllMarkets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 안전한 마켓만 필터링 (옵션)
+	safeMarkets := make([]Market, 0)
+	for _, market := range markets {
+		// 경고나 주의가 없는 마켓만 선택
+		if isMarketSafe(market) {
+			safeMarkets = append(safeMarkets, market)
+		}
+	}
+
+	return safeMarkets, nil
+}
+
+// 전체 마켓 정보와 경고 정보 조회
+func (bot *TradingBot) fetchAllMarkets(ctx context.Context) ([]Market, error) {
+	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL") + "/v1/market/all?is_details=true"
 
 	client := &http.Client{
-		Timeout: time.Second * 10,
+		Timeout: upbitRequestTimeout,
 	}
 
-	req, err := http.NewRequest("GET", apiUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiUrl, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -467,21 +784,18 @@ func (bot *TradingBot) fetchMarkets() ([]Market, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned non-200 status: %d, body: %s",
+			resp.StatusCode, string(bodyBytes))
+	}
+
 	var markets []Market
 	if err := json.NewDecoder(resp.Body).Decode(&markets); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %v", err)
 	}
 
-	// 안전한 마켓만 필터링 (옵션)
-	safeMarkets := make([]Market, 0)
-	for _, market := range markets {
-		// 경고나 주의가 없는 마켓만 선택
-		if !market.MarketEvent.Warning && market.MarketEvent.Caution == "" {
-			safeMarkets = append(safeMarkets, market)
-		}
-	}
-
-	return safeMarkets, nil
+	return markets, nil
 }
 
 type TradingStrategy struct {
@@ -490,6 +804,39 @@ type TradingStrategy struct {
 	RSIPeriod int
 	BBPeriod  int
 	BBStdDev  float64
+
+	EntryOrderType string // 매수 신호 주문 유형
+	ExitOrderType  string // 매도 신호 주문 유형
+	TimeInForce    string // best/limit 주문의 체결 조건
+
+	BuyRule  *SignalRule // 매수 신호 규칙
+	SellRule *SignalRule // 매도 신호 규칙
+
+	Scorer *ConfidenceScorer // 신뢰도 계산 설정
+}
+
+// 기본 매수 규칙 - 상승 추세 중 과매도, 볼린저 하단 이탈
+func (ts *TradingStrategy) defaultBuyRule() string {
+	return fmt.Sprintf("sma(%d) > sma(%d) and rsi(%d) < 30 and close < bb_lower(%d, %s)",
+		ts.ShortMA, ts.LongMA, ts.RSIPeriod, ts.BBPeriod, formatRuleNumber(ts.BBStdDev))
+}
+
+// 기본 매도 규칙 - 하락 추세 중 과매수, 볼린저 상단 이탈
+func (ts *TradingStrategy) defaultSellRule() string {
+	return fmt.Sprintf("sma(%d) < sma(%d) and rsi(%d) > 70 and close > bb_upper(%d, %s)",
+		ts.ShortMA, ts.LongMA, ts.RSIPeriod, ts.BBPeriod, formatRuleNumber(ts.BBStdDev))
+}
+
+// 신호 분석에 필요한 최소 가격 개수 (판단 근거용 지표와 매수/매도 규칙 기준)
+func (ts *TradingStrategy) minDataPoints() int {
+	points := max(max(ts.LongMA, ts.BBPeriod), ts.RSIPeriod) + 1
+	if ts.BuyRule != nil {
+		points = max(points, ts.BuyRule.minDataPoints())
+	}
+	if ts.SellRule != nil {
+		points = max(points, ts.SellRule.minDataPoints())
+	}
+	return points
 }
 
 // 특정 마켓이 거래하기에 안전한지 확인하는 함수
@@ -507,9 +854,15 @@ func isMarketSafe(market Market) bool {
 		"CONCENTRATION_OF_SMALL_ACCOUNTS", // 소수 계정 집중
 	}
 
-	for _, cautionType := range cautionTypes {
-		if market.MarketEvent.Caution == cautionType {
-			return false
+	for _, caution := range strings.Split(market.MarketEvent.Caution, ",") {
+		caution = strings.TrimSpace(caution)
+		if caution == "" {
+			continue
+		}
+		for _, cautionType := range cautionTypes {
+			if caution == cautionType {
+				return false
+			}
 		}
 	}
 
@@ -529,60 +882,171 @@ func printSafeMarkets(markets []Market) {
 	}
 }
 
-// Price 데이터를 가져오는 함수
-func (bot *TradingBot) fetchPriceData() ([]float64, error) {
-	// 특정 코인(예: BTC) 가격 조회
-	price, err := bot.fetchCurrentPrice("KRW-SUI")
-	if err != nil {
-		return nil, err
-	}
+// 마켓별로 보관하는 가격 샘플 수 (틱 분석 이력)
+const maxPriceSamples = 100
 
+// 마켓 가격 데이터 추가 후 분석용 스냅샷 반환 (최대 maxPriceSamples개 유지)
+func (bot *TradingBot) recordPrice(market string, price float64) *TechnicalIndicators {
 	bot.mu.Lock()
-	bot.indicators.Prices = append(bot.indicators.Prices, price)
+	defer bot.mu.Unlock()
 
-	// 최대 100개의 가격 데이터만 유지
-	if len(bot.indicators.Prices) > 100 {
-		bot.indicators.Prices = bot.indicators.Prices[1:]
+	indicators, ok := bot.indicators[market]
+	if !ok {
+		indicators = &TechnicalIndicators{}
+		bot.indicators[market] = indicators
+	}
+	indicators.Prices = append(indicators.Prices, price)
+	if len(indicators.Prices) > maxPriceSamples {
+		indicators.Prices = indicators.Prices[1:]
 	}
-	bot.mu.Unlock()
 
-	return bot.indicators.Prices, nil
+	return indicators.snapshot()
 }
 
-// 4. executeTradeLoop 함수 개선 - 로깅 일관성
-func (bot *TradingBot) executeTradeLoop() {
+// 쉼표로 구분된 마켓 목록 파싱 (중복/공백 제거)
+func parseMarkets(value string) []string {
+	markets := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, market := range strings.Split(value, ",") {
+		market = strings.ToUpper(strings.TrimSpace(market))
+		if market == "" || seen[market] {
+			continue
+		}
+		seen[market] = true
+		markets = append(markets, market)
+	}
+	return markets
+}
+
+// 현재 거래 대상 마켓 목록 복사본
+func (bot *TradingBot) activeMarkets() []string {
+	bot.mu.RLock()
+	defer bot.mu.RUnlock()
+
+	return append([]string(nil), bot.markets...)
+}
+
+// 거래 대상 마켓 교체 - 빠진 마켓의 가격 데이터는 제거
+func (bot *TradingBot) setActiveMarkets(markets []string) {
 	bot.mu.Lock()
 	defer bot.mu.Unlock()
 
-	market := os.Getenv("TRADING_MARKET")
-	if market == "" {
-		bot.logger.Error("TRADING_MARKET environment variable is not set")
+	active := make(map[string]bool, len(markets))
+	for _, market := range markets {
+		active[market] = true
+	}
+	for market := range bot.indicators {
+		if !active[market] {
+			delete(bot.indicators, market)
+		}
+	}
+	bot.markets = append([]string(nil), markets...)
+}
+
+// 4. executeTradeLoop 함수 개선 - 로깅 일관성
+// 공유 상태는 짧은 잠금 안에서 갱신/복사하고, 네트워크 호출은 잠금 없이 수행한다.
+func (bot *TradingBot) executeTradeLoop(ctx context.Context) {
+	// 이전 거래 주기가 아직 진행 중이면 이번 주기는 건너뜀
+	if !bot.tickMu.TryLock() {
+		bot.logger.Info("Previous trade loop is still running, skipping this tick")
 		return
 	}
+	defer bot.tickMu.Unlock()
+
+	// 리밸런싱 방식은 신호 분석 대신 목표 비중 유지
+	if bot.rebalancer.enabled() {
+		bot.mu.RLock()
+		interval := bot.interval
+		bot.mu.RUnlock()
+		bot.rebalancer.tick(ctx, bot, interval)
+		return
+	}
+
+	markets := bot.activeMarkets()
+	if len(markets) == 0 {
+		bot.logger.Error("No active trading markets (TRADING_MARKET is not set)")
+		return
+	}
+
+	// 보유 포지션 청산 조건 확인용 잔고 (주기마다 한 번 조회)
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		bot.logger.Error("Error fetching balance: %v", err)
+		return
+	}
+	if err := bot.updatePositions(accounts); err != nil {
+		bot.logger.Error("Error updating positions: %v", err)
+	}
+
+	for _, market := range markets {
+		if ctx.Err() != nil {
+			return
+		}
+		bot.tradeMarket(ctx, market, accounts)
+	}
+}
+
+// 단일 마켓 거래 주기 - 가격 조회, 분석, 주문 실행
+func (bot *TradingBot) tradeMarket(ctx context.Context, market string, accounts []Account) {
 	bot.logger.Info("Starting trade loop for market: %s", market)
 
+	// 분할 실행 중인 주문이 있으면 끝날 때까지 새 신호를 처리하지 않음
+	if bot.executor.activeFor(market) {
+		bot.logger.Debug("Execution in progress for %s, skipping signal analysis", market)
+		return
+	}
+
 	// 1. 현재 가격 조회
-	currentPrice, err := bot.fetchCurrentPrice(market)
+	currentPrice, err := bot.fetchCurrentPrice(ctx, market)
 	if err != nil {
 		bot.logger.Error("Error fetching current price: %v", err) // log.Printf 대신 bot.logger 사용
 		return
 	}
 	bot.logger.Debug("Current price: %f", currentPrice)
 
-	// 2. 가격 데이터 업데이트
-	bot.indicators.Prices = append(bot.indicators.Prices, currentPrice)
-	if len(bot.indicators.Prices) > 100 {
-		bot.indicators.Prices = bot.indicators.Prices[1:]
-	}
+	// 2. 가격 데이터 업데이트 후 분석용 스냅샷 생성
+	indicators := bot.recordPrice(market, currentPrice)
+	bot.mu.RLock()
+	interval := bot.interval
+	bot.mu.RUnlock()
 
-	minDataPoints := max(bot.strategy.LongMA, bot.strategy.BBPeriod) + 1
-	if len(bot.indicators.Prices) < minDataPoints {
-		bot.logger.Info("Not enough price data for analysis. Have %d, need %d",
-			len(bot.indicators.Prices), minDataPoints)
+	// 보유 포지션의 손절/익절/시간 청산 조건 확인
+	atr := indicators.calculateATR(bot.riskManager.Sizer.ATRPeriod)
+	if bot.checkStops(ctx, market, accounts, currentPrice, atr) {
 		return
 	}
-	// 3. 기술적 분석 수행
-	signal := bot.strategy.analyzeSignals(bot.indicators)
+
+	// 3. 기술적 분석 수행 - 시간대가 설정된 마켓은 마감된 캔들 기준
+	var signal TradeSignal
+	var explanation *SignalExplanation
+	if set := bot.timeframes.setFor(market); set != nil {
+		signal, explanation, err = bot.analyzeTimeframes(ctx, market, set, currentPrice)
+		if err != nil {
+			bot.logger.Error("Error analyzing %s timeframes: %v", market, err)
+			return
+		}
+	} else {
+		minDataPoints := bot.strategy.minDataPoints()
+		if len(indicators.Prices) < minDataPoints {
+			bot.logger.Info("Not enough price data for analysis. Have %d, need %d",
+				len(indicators.Prices), minDataPoints)
+			return
+		}
+		signal, explanation = bot.strategy.analyzeSignals(indicators)
+		explanation.Source = "tick"
+	}
+
+	// 유의/주의 종목은 신규 매수 차단 (매도는 허용)
+	if signal.Type == "buy" {
+		if flag, ok := bot.watcher.isFlagged(market); ok {
+			bot.logger.Info("Skipping buy signal on flagged market %s (warning: %t, caution: %s)",
+				market, flag.Warning, flag.Caution)
+			signal = TradeSignal{Type: "hold", Price: currentPrice}
+			explanation.Signal = signal.Type
+			explanation.Note = fmt.Sprintf("buy blocked on flagged market (warning: %t, caution: %s)", flag.Warning, flag.Caution)
+		}
+	}
+	bot.recordSignal(market, explanation)
 	bot.logger.Debug("Trade signal: %+v", signal)
 
 	// 4. 거래 실행
@@ -590,42 +1054,49 @@ func (bot *TradingBot) executeTradeLoop() {
 		bot.logger.Debug("No trade signal, holding position")
 		return
 	}
-	// 계좌 잔고 조회
-	accounts, err := bot.getBalance()
+
+	// 계좌 잔고 조회 (같은 주기에 다른 마켓 주문이 있었을 수 있으므로 다시 조회)
+	accounts, err = bot.getBalance(ctx)
 	if err != nil {
 		bot.logger.Error("Error fetching balance: %v", err)
 		return
 	}
 
-	// 적절한 계좌 찾기
-	var balance float64
-	for _, account := range accounts {
-		if account.Currency == "KRW" {
-			balance, err = strconv.ParseFloat(account.Balance, 64)
-			if err != nil {
-				bot.logger.Error("Error parsing balance: %v", err)
-				return
-			}
-			break
-		}
+	// 매수는 KRW 잔고, 매도는 보유 코인 잔고 기준으로 주문 수량 계산
+	var ok bool
+	if signal.Type == "buy" {
+		signal, ok = bot.sizeBuySignal(signal, accounts, market, currentPrice, atr)
+	} else {
+		signal, ok = bot.sizeSellSignal(signal, accounts, market, currentPrice)
+	}
+	if !ok {
+		return
 	}
 
-	if balance <= 0 {
-		bot.logger.Error("No KRW balance available for trading")
+	// 중지 요청 이후에는 새 주문을 제출하지 않음
+	if ctx.Err() != nil {
+		bot.logger.Info("Trading stopped before order submission, dropping %s signal", signal.Type)
 		return
 	}
-	bot.logger.Debug("Available balance: %f KRW", balance)
 
-	// 포지션 크기 계산
-	volume := bot.riskManager.calculatePositionSize(signal, balance, currentPrice)
-	if volume <= 0 {
-		bot.logger.Debug("Calculated trade volume is too small: %f", volume)
+	// 신호별 결정적 식별자 부여 (재시작 후 중복 주문 방지, 캔들 기준 신호는 이미 부여됨)
+	if signal.Identifier == "" {
+		signal.Identifier = signalIdentifier(market, signal.Type, time.Now(), interval)
+	}
+
+	// 큰 주문은 실행 알고리즘으로 분할 (거래 중지 시 함께 취소)
+	if bot.executor.shouldSlice(signal) {
+		parent, err := bot.executor.start(ctx, bot, signal, market)
+		if err != nil {
+			bot.logger.Error("Error starting execution: %v", err)
+			return
+		}
+		bot.logger.Info("Execution started: %s (%s)", parent.ID, parent.Algo)
 		return
 	}
-	signal.Volume = volume
 
 	// 주문 실행
-	order, err := bot.executeTrade(signal, market)
+	order, err := bot.executeTrade(ctx, signal, market)
 	if err != nil {
 		bot.logger.Error("Error executing trade: %v", err) // log.Printf 대신 bot.logger 사용
 		return
@@ -634,23 +1105,96 @@ func (bot *TradingBot) executeTradeLoop() {
 	bot.logger.Info("Order executed: %+v", order) // log.Printf 대신 bot.logger 사용
 }
 
+// 매수 신호 - KRW 잔고로 포지션 크기 계산
+func (bot *TradingBot) sizeBuySignal(signal TradeSignal, accounts []Account, market string, currentPrice float64, atr float64) (TradeSignal, bool) {
+	quote, _ := splitMarket(market)
+	balance, _, err := accountBalance(accounts, quote)
+	if err != nil {
+		bot.logger.Error("Error parsing balance: %v", err)
+		return signal, false
+	}
+
+	if balance <= 0 {
+		bot.logger.Error("No %s balance available for trading", quote)
+		return signal, false
+	}
+	bot.logger.Debug("Available balance: %f %s", balance, quote)
+
+	// 포지션 크기 계산 (KRW 명목 금액 → 코인 수량)
+	size := bot.riskManager.calculatePositionSize(signal, balance, currentPrice, atr)
+	if size.Volume <= 0 {
+		bot.logger.Debug("Calculated position size is too small: %+v", size)
+		return signal, false
+	}
+	signal.Notional = size.Notional
+	signal.Volume = size.Volume
+	bot.logger.Debug("Position size: %.2f KRW (%.8f)", size.Notional, size.Volume)
+
+	return signal, true
+}
+
+// 매도 신호 - 보유 코인 잔고와 청산 정책으로 매도 수량 계산
+func (bot *TradingBot) sizeSellSignal(signal TradeSignal, accounts []Account, market string, currentPrice float64) (TradeSignal, bool) {
+	_, base := splitMarket(market)
+	available, locked, err := accountBalance(accounts, base)
+	if err != nil {
+		bot.logger.Error("Error parsing %s balance: %v", base, err)
+		return signal, false
+	}
+
+	if locked > 0 {
+		bot.logger.Debug("%s locked in open orders: %f", base, locked)
+	}
+	if available <= 0 {
+		bot.logger.Info("No %s holdings available to sell, skipping sell signal", base)
+		return signal, false
+	}
+
+	size := bot.riskManager.calculateExitSize(available, currentPrice)
+	if size.Volume <= 0 {
+		bot.logger.Info("%s holdings below minimum order amount, skipping sell signal (available: %f)", base, available)
+		return signal, false
+	}
+	signal.Notional = size.Notional
+	signal.Volume = size.Volume
+	bot.logger.Debug("Exit size: %.8f %s (%.2f KRW)", size.Volume, base, size.Notional)
+
+	return signal, true
+}
+
 // 설정 로드 함수
 func loadConfig() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found")
 	}
 
-	// loadConfig 함수에서
+	// 업비트 API 키는 시크릿 공급자(환경 변수, 마운트 파일, 암호화 키 저장소)에서 로드
+	provider, err := newSecretsProviderFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := provider.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets from %s provider: %v", provider.Name(), err)
+	}
+
 	config := &Config{
-		AccessKey: os.Getenv("UPBIT_OPEN_API_ACCESS_KEY"), // ACCESS_KEY -> UPBIT_OPEN_API_ACCESS_KEY
-		SecretKey: os.Getenv("UPBIT_OPEN_API_SECRET_KEY"), // SECRET_KEY -> UPBIT_OPEN_API_SECRET_KEY
+		AccessKey: creds.AccessKey,
+		SecretKey: creds.SecretKey,
 		Port:      os.Getenv("PORT"),
+
+		APIJWTSecret: os.Getenv("API_JWT_SECRET"),
+		APIUsers:     os.Getenv("API_USERS"),
+		APIKeys:      os.Getenv("API_KEYS"),
 	}
 
 	if config.AccessKey == "" || config.SecretKey == "" {
-		return nil, fmt.Errorf("required environment variables are not set")
+		return nil, fmt.Errorf("upbit API keys are not set (secrets provider: %s)", provider.Name())
 	}
 
+	// 로드한 비밀 값은 Config에만 보관하고 환경 변수에서는 제거
+	clearSecretEnv()
+
 	if config.Port == "" {
 		config.Port = "8888"
 	}
@@ -658,31 +1202,6 @@ func loadConfig() (*Config, error) {
 	return config, nil
 }
 
-// JWT 토큰 생성 함수
-func generateToken(config Config, params map[string]string) (string, error) {
-	nonce := uuid.New().String()
-	claims := Claims{
-		AccessKey: config.AccessKey,
-		Nonce:     nonce,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 10).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-	}
-
-	if len(params) > 0 {
-		queryHash, err := generateQueryHash(params)
-		if err != nil {
-			return "", err
-		}
-		claims.QueryHash = queryHash
-		claims.QueryHashAlg = "SHA512"
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.SecretKey))
-}
-
 // Account 구조체
 type Account struct {
 	Currency            string `json:"currency"`
@@ -693,53 +1212,69 @@ type Account struct {
 	UnitCurrency        string `json:"unit_currency"`
 }
 
-// Order 구조체
-type Order struct {
-	UUID            string `json:"uuid"`
-	Side            string `json:"side"` // "ask"(매도) 또는 "bid"(매수)
-	OrdType         string `json:"ord_type"`
-	Price           string `json:"price"`
-	State           string `json:"state"`
-	Market          string `json:"market"`
-	Volume          string `json:"volume"`
-	RemainingVolume string `json:"remaining_volume"`
-	ExecutedVolume  string `json:"executed_volume"`
-}
-
-// 잔고 조회 함수
-func (bot *TradingBot) getBalance() ([]Account, error) {
-	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL") + "/v1/accounts"
-
-	// Payload 생성
-	payload := map[string]interface{}{
-		"access_key": os.Getenv("UPBIT_OPEN_API_ACCESS_KEY"),
-		"nonce":      uuid.New().String(),
-	}
-
-	// JWT 토큰 생성
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(payload))
-	jwtToken, err := token.SignedString([]byte(os.Getenv("UPBIT_OPEN_API_SECRET_KEY")))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create JWT token: %v", err)
+// 마켓 코드를 기준 통화와 거래 통화로 분리 (예: KRW-BTC → KRW, BTC)
+func splitMarket(market string) (quote, base string) {
+	parts := strings.SplitN(market, "-", 2)
+	if len(parts) != 2 {
+		return "", market
 	}
+	return parts[0], parts[1]
+}
 
-	// HTTP 요청
-	client := &http.Client{Timeout: time.Second * 10}
-	req, err := http.NewRequest("GET", apiUrl, nil)
-	if err != nil {
-		return nil, err
+// 통화별 주문 가능 잔고와 주문 중 묶인 잔고 조회 (계좌가 없으면 0 반환)
+// 업비트의 balance는 locked를 제외한 주문 가능 수량이다.
+func accountBalance(accounts []Account, currency string) (available, locked float64, err error) {
+	for _, account := range accounts {
+		if account.Currency != currency {
+			continue
+		}
+		available, err = strconv.ParseFloat(account.Balance, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if account.Locked != "" {
+			locked, err = strconv.ParseFloat(account.Locked, 64)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+		return available, locked, nil
 	}
+	return 0, 0, nil
+}
 
-	req.Header.Set("Authorization", "Bearer "+jwtToken)
+// Order 구조체
+type Order struct {
+	UUID            string  `json:"uuid"`
+	Side            string  `json:"side"` // "ask"(매도) 또는 "bid"(매수)
+	OrdType         string  `json:"ord_type"`
+	Price           string  `json:"price"`
+	State           string  `json:"state"`
+	Market          string  `json:"market"`
+	Volume          string  `json:"volume"`
+	RemainingVolume string  `json:"remaining_volume"`
+	ExecutedVolume  string  `json:"executed_volume"`
+	Identifier      string  `json:"identifier"`
+	CreatedAt       string  `json:"created_at"`
+	PaidFee         string  `json:"paid_fee"`
+	Trades          []Trade `json:"trades,omitempty"` // 개별 주문 조회 시에만 포함
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// Trade 구조체 - 주문의 개별 체결 내역
+type Trade struct {
+	Market    string `json:"market"`
+	UUID      string `json:"uuid"`
+	Price     string `json:"price"`
+	Volume    string `json:"volume"`
+	Funds     string `json:"funds"`
+	Side      string `json:"side"`
+	CreatedAt string `json:"created_at"`
+}
 
+// 잔고 조회 함수
+func (bot *TradingBot) getBalance(ctx context.Context) ([]Account, error) {
 	var accounts []Account
-	if err := json.NewDecoder(resp.Body).Decode(&accounts); err != nil {
+	if err := bot.doPrivate(ctx, http.MethodGet, "/v1/accounts", nil, &accounts); err != nil {
 		return nil, err
 	}
 
@@ -747,180 +1282,332 @@ func (bot *TradingBot) getBalance() ([]Account, error) {
 }
 
 // 3. 주문 실행 함수 개선 - 신호 타입 변환 및 오류 처리 추가
-func (bot *TradingBot) executeTrade(signal TradeSignal, market string) (*Order, error) {
-	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL") + "/v1/orders"
-
-	// 신호 타입을 Upbit API에 맞게 변환
-	side := convertSignalTypeToUpbitSide(signal.Type)
-	if side == "" {
-		return nil, fmt.Errorf("invalid trade signal type: %s", signal.Type)
-	}
-
-	// 주문 파라미터 설정
-	params := map[string]string{
-		"market":   market,
-		"side":     side, // 변환된 타입 사용
-		"volume":   fmt.Sprintf("%.8f", signal.Volume),
-		"price":    fmt.Sprintf("%.2f", signal.Price),
-		"ord_type": "limit", // 지정가 주문
-	}
-
-	// Query string 생성 및 해시
-	values := make(url.Values)
-	for key, value := range params {
-		values.Add(key, value)
-	}
-	queryString := values.Encode()
-
-	// SHA512 해시 생성
-	hash := sha512.New()
-	hash.Write([]byte(queryString))
-	queryHash := hex.EncodeToString(hash.Sum(nil))
-
-	// JWT payload 생성
-	payload := map[string]interface{}{
-		"access_key":     os.Getenv("UPBIT_OPEN_API_ACCESS_KEY"),
-		"nonce":          uuid.New().String(),
-		"query_hash":     queryHash,
-		"query_hash_alg": "SHA512",
-	}
-
-	// JWT 토큰 생성
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(payload))
-	jwtToken, err := token.SignedString([]byte(os.Getenv("UPBIT_OPEN_API_SECRET_KEY")))
+func (bot *TradingBot) executeTrade(ctx context.Context, signal TradeSignal, market string) (*Order, error) {
+	// 호가 기준으로 주문 가격을 정하고 예상 슬리피지 확인
+	book, err := bot.fetchOrderbook(ctx, market)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create JWT token: %v", err)
+		return nil, fmt.Errorf("failed to fetch orderbook: %v", err)
 	}
-
-	// HTTP 요청
-	client := &http.Client{Timeout: time.Second * 10}
-	req, err := http.NewRequest("POST", apiUrl, strings.NewReader(queryString))
+	signal, estimate, err := bot.pricer.apply(*book, signal)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("order rejected by pricing: %v", err)
 	}
+	bot.logger.Debug("Order pricing for %s %s: price %f, estimate %+v", signal.Type, market, signal.Price, estimate)
 
-	req.Header.Set("Authorization", "Bearer "+jwtToken)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := client.Do(req)
+	// 신호를 주문 유형에 맞는 주문 요청으로 변환
+	orderReq, err := newOrderRequest(signal, market)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid order request: %v", err)
 	}
-	defer resp.Body.Close()
 
-	// 응답 상태 코드 확인
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned error status: %d, body: %s",
-			resp.StatusCode, string(bodyBytes))
-	}
+	return bot.submitOrder(ctx, orderReq)
+}
 
+// 주문 요청을 업비트 API로 전송
+func (bot *TradingBot) placeOrder(ctx context.Context, orderReq OrderRequest) (*Order, error) {
 	var order Order
-	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
-		return nil, fmt.Errorf("failed to decode order response: %v", err)
+	if err := bot.doPrivate(ctx, http.MethodPost, "/v1/orders", orderReq.params(), &order); err != nil {
+		return nil, err
 	}
 
 	return &order, nil
 }
 
-// 주문 취소 함수
-func (bot *TradingBot) cancelOrder(tuuid string) error {
-	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL") + "/v1/order"
-
-	// Query string 생성 및 해시
-	params := map[string]string{"uuid": tuuid}
+// 주문 UUID로 주문 조회
+func (bot *TradingBot) getOrder(ctx context.Context, tuuid string) (*Order, error) {
 	values := url.Values{}
-	for key, value := range params {
-		values.Add(key, value)
-	}
-	queryString := values.Encode()
+	values.Set("uuid", tuuid)
 
-	hash := sha512.New()
-	hash.Write([]byte(queryString))
-	queryHash := hex.EncodeToString(hash.Sum(nil))
-
-	// JWT payload 생성
-	payload := map[string]interface{}{
-		"access_key":     os.Getenv("UPBIT_OPEN_API_ACCESS_KEY"),
-		"nonce":          uuid.New().String(),
-		"query_hash":     queryHash,
-		"query_hash_alg": "SHA512",
+	var order Order
+	if err := bot.doPrivate(ctx, http.MethodGet, "/v1/order", values, &order); err != nil {
+		return nil, err
 	}
 
-	// JWT 토큰 생성
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(payload))
-	jwtToken, err := token.SignedString([]byte(os.Getenv("UPBIT_OPEN_API_SECRET_KEY")))
-	if err != nil {
-		return fmt.Errorf("failed to create JWT token: %v", err)
-	}
+	return &order, nil
+}
 
-	// HTTP 요청
-	client := &http.Client{Timeout: time.Second * 10}
-	req, err := http.NewRequest("DELETE", apiUrl, nil)
-	if err != nil {
-		return err
+// 클라이언트 식별자로 주문 조회 (주문이 없으면 nil 반환)
+func (bot *TradingBot) getOrderByIdentifier(ctx context.Context, identifier string) (*Order, error) {
+	values := url.Values{}
+	values.Set("identifier", identifier)
+
+	var order Order
+	if err := bot.doPrivate(ctx, http.MethodGet, "/v1/order", values, &order); err != nil {
+		// 주문이 존재하지 않음
+		if apiErr, ok := err.(*UpbitAPIError); ok && apiErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+jwtToken)
-	q := req.URL.Query()
-	q.Add("uuid", tuuid)
-	req.URL.RawQuery = q.Encode()
+	return &order, nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// 주문 취소 함수
+func (bot *TradingBot) cancelOrder(ctx context.Context, tuuid string) error {
+	values := url.Values{}
+	values.Set("uuid", tuuid)
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to cancel order: %s", resp.Status)
+	if err := bot.doPrivate(ctx, http.MethodDelete, "/v1/order", values, nil); err != nil {
+		return fmt.Errorf("failed to cancel order: %v", err)
 	}
 
 	return nil
 }
 
 // 6. API 라우터 수정 - StopTrading 함수 사용
-func setupRouter(bot *TradingBot) *gin.Engine {
+func setupRouter(bot *TradingBot, auth *AuthService, audit *AuditLog) *gin.Engine {
 	r := gin.Default()
+	// 로그인 제한과 감사 로그의 IP를 클라이언트가 위조하지 못하도록 신뢰할 프록시만 지정
+	if err := setTrustedProxies(r, os.Getenv("TRUSTED_PROXIES")); err != nil {
+		bot.logger.Error("Invalid TRUSTED_PROXIES: %v. No proxies are trusted.", err)
+	}
 
-	// 토큰 생성 엔드포인트
-	r.POST("/token", func(c *gin.Context) {
-		var params map[string]string
-		if err := c.BindJSON(&params); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-			return
-		}
-
-		token, err := generateToken(bot.config, params)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{"token": token})
-	})
+	// 운영자 로그인/토큰 갱신 엔드포인트
+	auth.registerRoutes(r)
 
 	// 트레이딩 봇 제어 API
 	protected := r.Group("/api")
-	protected.Use(authMiddleware(bot.config))
+	protected.Use(auth.middleware(), audit.middleware(bot.logger))
 	{
+		operator := requireRole(RoleOperator)
+		viewer := requireRole(RoleViewer)
+
 		// 트레이딩 시작
-		protected.POST("/start", func(c *gin.Context) {
-			go bot.StartTrading(time.Second * 30) // 30초마다 거래 체크
+		protected.POST("/start", operator, func(c *gin.Context) {
+			if err := bot.StartTrading(time.Second * 30); err != nil { // 30초마다 거래 체크
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusOK, gin.H{"message": "Trading started"})
 		})
 
 		// 트레이딩 중지 - 개선된 메서드 사용
-		protected.POST("/stop", func(c *gin.Context) {
+		protected.POST("/stop", operator, func(c *gin.Context) {
 			bot.StopTrading() // 단순 플래그 설정 대신 적절한 StopTrading 함수 사용
 			c.JSON(http.StatusOK, gin.H{"message": "Trading stopped"})
 		})
 
 		// 현재 상태 조회
-		protected.GET("/status", func(c *gin.Context) {
+		protected.GET("/status", viewer, func(c *gin.Context) {
+			c.JSON(http.StatusOK, bot.status())
+		})
+
+		// 거래소 잔고/주문 재대조
+		protected.POST("/reconcile", operator, func(c *gin.Context) {
+			// 클라이언트 연결이 끊기거나 제한 시간이 지나면 거래소 호출도 중단
+			ctx, cancel := context.WithTimeout(c.Request.Context(), reconcileTimeout)
+			defer cancel()
+			report, err := bot.reconcile(ctx)
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				return
+			}
+			bot.mu.RLock()
+			positions := bot.positions
+			bot.mu.RUnlock()
+			c.JSON(http.StatusOK, gin.H{"reconciliation": report, "positions": positions})
+		})
+
+		// 분할 실행 주문 목록과 진행 상황
+		protected.GET("/executions", viewer, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"executions": bot.executor.list()})
+		})
+
+		protected.GET("/executions/:id", viewer, func(c *gin.Context) {
+			parent := bot.executor.get(c.Param("id"))
+			if parent == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+				return
+			}
+			c.JSON(http.StatusOK, parent)
+		})
+
+		// 분할 실행 취소 (진행 중인 자식 주문도 취소)
+		protected.POST("/executions/:id/cancel", operator, func(c *gin.Context) {
+			if err := bot.executor.cancelExecution(c.Param("id")); err != nil {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Execution cancel requested"})
+		})
+
+		// 마켓 스크리너 결과 (refresh=true이면 즉시 재평가)
+		protected.GET("/markets/screener", viewer, func(c *gin.Context) {
+			result := bot.screener.latest()
+			if result == nil || c.Query("refresh") == "true" {
+				var err error
+				result, err = bot.screener.refresh(c.Request.Context(), bot)
+				if err != nil {
+					c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+					return
+				}
+			}
+			c.JSON(http.StatusOK, result)
+		})
+
+		// 최근 신호 판단 근거 조회 (market, signal, limit 필터)
+		protected.GET("/signals", viewer, func(c *gin.Context) {
+			market, signal, limit, err := parseSignalQuery(c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if bot.signals == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signal log is not available"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"signals": bot.signals.query(market, signal, limit)})
+		})
+
+		// 신뢰도 구간별 적중률 (신호 기록 파일 전체 기준)
+		protected.GET("/signals/calibration", viewer, func(c *gin.Context) {
+			market, horizon, buckets, err := parseCalibrationQuery(c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if bot.signals == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signal log is not available"})
+				return
+			}
+			explanations, err := bot.signals.readAll()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, calibrate(explanations, market, horizon, buckets))
+		})
+
+		// 실현/평가 손익, 수수료, 계좌 평가 금액과 일/주별 요약 (refresh=true이면 체결 내역 먼저 동기화)
+		protected.GET("/pnl", viewer, func(c *gin.Context) {
+			market, limit, err := parsePnLQuery(c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if bot.ledger == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "fill ledger is not available"})
+				return
+			}
+			ctx, cancel := context.WithTimeout(c.Request.Context(), reconcileTimeout)
+			defer cancel()
+			if c.Query("refresh") == "true" {
+				if _, err := bot.ledger.sync(ctx, bot); err != nil {
+					c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+					return
+				}
+			}
+			report, err := bot.pnlReport(ctx, market, limit)
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, report)
+		})
+
+		// 회계용 체결 내역 내보내기 (from/to는 KST 날짜 또는 RFC3339, format=csv|json)
+		// 주문 저널이 아니라 체결 장부(FILL_LEDGER_PATH)를 읽으며, 장부는 FILL_SYNC_INTERVAL_SECONDS마다 동기화되므로
+		// 최근 체결까지 포함하려면 refresh=true로 체결 내역을 먼저 동기화한다.
+		protected.GET("/export/trades", viewer, func(c *gin.Context) {
+			from, to, format, err := parseExportQuery(c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if bot.ledger == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "fill ledger is not available"})
+				return
+			}
+			if c.Query("refresh") == "true" {
+				ctx, cancel := context.WithTimeout(c.Request.Context(), reconcileTimeout)
+				defer cancel()
+				if _, err := bot.ledger.sync(ctx, bot); err != nil {
+					c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+					return
+				}
+			}
+			records := tradeRecords(bot.ledger.snapshot(), bot.ledger.Method, from, to)
+			if format == ExportFormatJSON {
+				c.JSON(http.StatusOK, gin.H{"trades": records})
+				return
+			}
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="trades.csv"`)
+			c.Status(http.StatusOK)
+			if err := writeTradeRecordsCSV(c.Writer, records); err != nil {
+				bot.logger.Error("Failed to export trades: %v", err)
+			}
+		})
+
+		// 목표 비중 대비 현재 비중과 리밸런싱 주문 계획 (주문하지 않음)
+		protected.GET("/rebalance", viewer, func(c *gin.Context) {
+			plan, err := bot.rebalancer.plan(c.Request.Context(), bot, time.Now())
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"mode":     bot.rebalancer.Mode,
+				"targets":  bot.rebalancer.Targets,
+				"band":     bot.rebalancer.Band,
+				"interval": bot.rebalancer.Interval.String(),
+				"plan":     plan,
+				"last":     bot.rebalancer.lastPlan(),
+			})
+		})
+
+		// 비중 이탈과 관계없이 즉시 리밸런싱 (거래 주기와 겹치면 거부)
+		protected.POST("/rebalance", operator, func(c *gin.Context) {
+			if !bot.tickMu.TryLock() {
+				c.JSON(http.StatusConflict, gin.H{"error": "trade loop is running, try again"})
+				return
+			}
+			defer bot.tickMu.Unlock()
+
+			bot.mu.RLock()
+			stopping := bot.stopping
+			bot.mu.RUnlock()
+			if stopping {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "trading bot is shutting down"})
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), reconcileTimeout)
+			defer cancel()
+			plan, err := bot.rebalancer.plan(ctx, bot, time.Now())
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				return
+			}
+			plan.Reason = RebalanceReasonManual
+			bot.mu.RLock()
+			interval := bot.interval
+			bot.mu.RUnlock()
+			if err := bot.rebalancer.execute(ctx, bot, plan, interval); err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, plan)
+		})
+
+		// 감사 로그 조회 (principal, action, from, to, limit 필터)
+		protected.GET("/audit", operator, func(c *gin.Context) {
+			q, err := parseAuditQuery(c)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			entries, brokenAt, err := audit.query(q)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusOK, gin.H{
-				"is_running": bot.isRunning,
-				"strategy":   bot.strategy,
+				"entries":   entries,
+				"verified":  brokenAt == 0,
+				"broken_at": brokenAt,
 			})
 		})
 	}
@@ -929,12 +1616,26 @@ func setupRouter(bot *TradingBot) *gin.Engine {
 }
 
 func main() {
+	// 보조 명령 실행
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// 환경변수 로드
 	config, err := loadConfig()
 	if err != nil {
 		log.Fatal("Failed to load config:", err)
 	}
 
+	// 제어 API 인증 설정
+	auth, err := newAuthService(config.APIJWTSecret, config.APIUsers, config.APIKeys)
+	if err != nil {
+		log.Fatal("Failed to configure API auth:", err)
+	}
+
 	// Gin 모드 설정
 	ginMode := os.Getenv("GIN_MODE")
 	if ginMode == "release" {
@@ -942,76 +1643,193 @@ func main() {
 	}
 
 	// 트레이딩 봇 초기화
-	bot := NewTradingBot(*config)
-
-	// 라우터 설정
-	r := setupRouter(bot)
+	bot, err := NewTradingBot(*config)
+	if err != nil {
+		log.Fatal("Failed to configure trading bot:", err)
+	}
 
-	// 서버 시작
-	if err := r.Run(":" + config.Port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// 제어 API 감사 로그
+	audit, err := openAuditLog(getEnvOrDefault("AUDIT_LOG_PATH", "/app/logs/audit.jsonl"))
+	if err != nil {
+		log.Fatal("Failed to open audit log:", err)
 	}
-}
 
-// 쿼리 해시 생성 함수
-func generateQueryHash(params map[string]string) (string, error) {
-	// 파라미터 키를 정렬
-	var keys []string
-	for k := range params {
-		keys = append(keys, k)
+	// SIGINT/SIGTERM 수신 시 정상 종료 (시작 대조 중에도 취소 가능)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 거래소 잔고/주문과 로컬 저널 대조 (완료 전에는 거래 시작 불가)
+	reconcileCtx, cancelReconcile := context.WithTimeout(ctx, reconcileTimeout)
+	if _, err := bot.reconcile(reconcileCtx); err != nil {
+		bot.logger.Error("Startup reconciliation failed: %v", err)
 	}
-	sort.Strings(keys)
+	cancelReconcile()
 
-	// 정렬된 키=값 문자열 생성
-	var pairs []string
-	for _, k := range keys {
-		pairs = append(pairs, fmt.Sprintf("%s=%s", k, params[k]))
+	// 스크리너, 종목 감시, 보유 현황 갱신, 체결 동기화 주기 실행
+	if err := bot.startBackground(ctx); err != nil {
+		log.Fatal("Failed to start background tasks:", err)
 	}
-	queryString := strings.Join(pairs, "&")
 
-	// SHA512 해시 생성
-	hash := sha512.New()
-	hash.Write([]byte(queryString))
-	return hex.EncodeToString(hash.Sum(nil)), nil
-}
+	// 라우터 설정
+	r := setupRouter(bot, auth, audit)
 
-// 미들웨어: JWT 토큰 검증
-func authMiddleware(config Config) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
-			c.Abort()
-			return
+	// 서버 시작
+	srv := &http.Server{
+		Addr:    ":" + config.Port,
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
 		}
+	}()
 
-		// "Bearer " 제거
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	<-ctx.Done()
+	stop()
 
-		// 토큰 파싱 및 검증
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(config.SecretKey), nil
-		})
+	timeout := time.Duration(getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// HTTP 서버를 먼저 종료해 새 시작/리밸런싱 요청을 막은 뒤
+	// 거래 루프 중지, 미체결 주문 정리, 저널/로그 flush
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	}
+	if err := bot.Shutdown(shutdownCtx, getEnvOrDefault("CANCEL_ORDERS_ON_SHUTDOWN", ShutdownCancelNone)); err != nil {
+		log.Printf("Bot shutdown error: %v", err)
+	}
+	if err := audit.Close(); err != nil {
+		log.Printf("Failed to close audit log: %v", err)
+	}
+	log.Printf("Server stopped")
+}
 
+// 보조 명령
+//
+//	hash-secret:   echo -n 'secret' | trading-bot hash-secret
+//	keystore-init: printf 'ACCESS_KEY\nSECRET_KEY\n' | KEYSTORE_PATH=... KEYSTORE_PASSPHRASE=... trading-bot keystore-init
+//	check-rule:    echo 'rsi(14) < 30' | trading-bot check-rule
+//	calibrate:     CALIBRATION_HORIZON_MINUTES=60 trading-bot calibrate < signals.jsonl
+//	export-trades: EXPORT_FROM=2024-01-01 EXPORT_TO=2024-12-31 EXPORT_FORMAT=csv trading-bot export-trades < fills.jsonl
+func runCommand(command string) error {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read stdin: %v", err)
+	}
+
+	switch command {
+	case "hash-secret":
+		// 제어 API 사용자/API 키 시크릿 해시 생성
+		hash, err := hashSecret(strings.TrimRight(string(input), "\r\n"))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
+			return fmt.Errorf("failed to hash secret: %v", err)
 		}
-
-		if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-			// claims를 컨텍스트에 저장
-			c.Set("claims", claims)
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
+		fmt.Println(hash)
+	case "keystore-init":
+		// 업비트 API 키를 암호화 키 저장소로 저장
+		lines := strings.Fields(string(input))
+		if len(lines) != 2 {
+			return fmt.Errorf("expected access key and secret key on separate lines")
+		}
+		os.Setenv("SECRETS_PROVIDER", "keystore")
+		provider, err := newSecretsProviderFromEnv()
+		if err != nil {
+			return err
+		}
+		keystore := provider.(*keystoreSecretsProvider)
+		if err := keystore.Save(UpbitCredentials{AccessKey: lines[0], SecretKey: lines[1]}); err != nil {
+			return fmt.Errorf("failed to write keystore: %v", err)
+		}
+		fmt.Printf("Keystore written to %s\n", keystore.Path)
+	case "check-rule":
+		// 신호 규칙 문법 확인
+		rule, err := compileRule(strings.TrimSpace(string(input)))
+		if err != nil {
+			return fmt.Errorf("invalid rule: %v", err)
+		}
+		fmt.Printf("Rule: %s\n", rule.expr)
+		points := rule.minDataPoints()
+		fmt.Printf("Minimum data points: %d\n", points)
+		if points > maxCandleCount-1 {
+			return fmt.Errorf("rule needs more than the %d closed candles available", maxCandleCount-1)
+		}
+		if points > maxPriceSamples {
+			fmt.Printf("Needs more than %d price samples; use SIGNAL_TIMEFRAMES (up to %d candles)\n", maxPriceSamples, maxCandleCount-1)
+		}
+	case "calibrate":
+		// 신호 기록(또는 같은 형식의 백테스트 결과)으로 신뢰도 보정 보고서 출력
+		explanations, err := readSignalExplanations(strings.NewReader(string(input)))
+		if err != nil {
+			return err
 		}
+		horizon := time.Duration(getEnvInt("CALIBRATION_HORIZON_MINUTES", 60)) * time.Minute
+		report := calibrate(explanations, os.Getenv("CALIBRATION_MARKET"), horizon, getEnvInt("CALIBRATION_BUCKETS", 5))
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		fmt.Println(string(output))
+	case "export-trades":
+		// 체결 장부를 회계용 체결 내역(CSV/JSON)으로 출력
+		fills, err := readFills(strings.NewReader(string(input)))
+		if err != nil {
+			return err
+		}
+		sortFills(fills)
+		from, to, err := parseExportRange(os.Getenv("EXPORT_FROM"), os.Getenv("EXPORT_TO"))
+		if err != nil {
+			return err
+		}
+		format, err := parseExportFormat(os.Getenv("EXPORT_FORMAT"))
+		if err != nil {
+			return err
+		}
+		method := getEnvOrDefault("PNL_COST_METHOD", CostMethodFIFO)
+		if method != CostMethodFIFO && method != CostMethodAverage {
+			return fmt.Errorf("invalid cost method: %s", method)
+		}
+		records := tradeRecords(fills, method, from, to)
+		if format == ExportFormatJSON {
+			output, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode trades: %v", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+		return writeTradeRecordsCSV(os.Stdout, records)
+	default:
+		return fmt.Errorf("unknown command: %s", command)
 	}
+
+	return nil
+}
+
+// 환경 변수 값이 없으면 기본값 반환
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// 환경 변수를 실수로 파싱, 실패하면 기본값 반환
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// 환경 변수를 정수로 파싱, 실패하면 기본값 반환
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
 }
 
 // max 함수 추가 (Go 1.21 미만에서 필요)
@@ -1021,3 +1839,10 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 유의/주의 종목 지정 시 처리 정책
+const (
+	MarketEventPolicyBlock     = "block"     // 신규 매수만 차단
+	MarketEventPolicyLiquidate = "liquidate" // 신규 매수 차단 후 보유 포지션 시장가 청산
+)
+
+// FlaggedMarket 구조체 - 유의/주의 종목으로 지정된 마켓
+type FlaggedMarket struct {
+	Market  string    `json:"market"`
+	Warning bool      `json:"warning"`
+	Caution string    `json:"caution"`
+	Since   time.Time `json:"since"` // 봇이 처음 감지한 시각
+}
+
+// MarketWatcher 구조체 - 마켓 경고 정보를 주기적으로 갱신
+type MarketWatcher struct {
+	Interval time.Duration // 갱신 주기
+	Policy   string        // 처리 정책 (block, liquidate)
+
+	mu      sync.RWMutex
+	flagged map[string]FlaggedMarket
+}
+
+// 환경 변수로 마켓 감시 설정
+func newMarketWatcherFromEnv() *MarketWatcher {
+	return &MarketWatcher{
+		Interval: time.Duration(getEnvInt("MARKET_WATCH_INTERVAL_SECONDS", 60)) * time.Second,
+		Policy:   getEnvOrDefault("MARKET_EVENT_POLICY", MarketEventPolicyBlock),
+		flagged:  make(map[string]FlaggedMarket),
+	}
+}
+
+// 마켓이 유의/주의 종목인지 확인
+func (w *MarketWatcher) isFlagged(market string) (FlaggedMarket, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	flag, ok := w.flagged[market]
+	return flag, ok
+}
+
+// 유의/주의 종목 목록 (마켓 코드순)
+func (w *MarketWatcher) snapshot() []FlaggedMarket {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	flagged := make([]FlaggedMarket, 0, len(w.flagged))
+	for _, flag := range w.flagged {
+		flagged = append(flagged, flag)
+	}
+	sort.Slice(flagged, func(i, j int) bool { return flagged[i].Market < flagged[j].Market })
+	return flagged
+}
+
+// 주기적 감시 - ctx가 취소될 때까지 실행
+func (w *MarketWatcher) run(ctx context.Context, bot *TradingBot) {
+	if w.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.refresh(ctx, bot); err != nil && ctx.Err() == nil {
+			bot.logger.Error("Market watcher failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 마켓 경고 정보 갱신 후 새로 지정/해제된 마켓 알림, 정책에 따라 청산
+func (w *MarketWatcher) refresh(ctx context.Context, bot *TradingBot) error {
+	markets, err := bot.fetchAllMarkets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch market events: %v", err)
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	previous := w.flagged
+	current := make(map[string]FlaggedMarket)
+	for _, market := range markets {
+		if isMarketSafe(market) {
+			continue
+		}
+		flag := FlaggedMarket{
+			Market:  market.Market,
+			Warning: market.MarketEvent.Warning,
+			Caution: market.MarketEvent.Caution,
+			Since:   now,
+		}
+		if old, ok := previous[market.Market]; ok {
+			flag.Since = old.Since
+		}
+		current[market.Market] = flag
+	}
+	w.flagged = current
+	w.mu.Unlock()
+
+	// 거래 대상 마켓과 보유 중인 마켓(거래소 잔고 기준)의 지정/해제만 알림
+	watched := bot.activeMarkets()
+	for _, market := range bot.heldMarkets(ctx) {
+		if !containsString(watched, market) {
+			watched = append(watched, market)
+		}
+	}
+	for _, market := range watched {
+		flag, flagged := current[market]
+		_, wasFlagged := previous[market]
+		switch {
+		case flagged && !wasFlagged:
+			bot.notify(ctx, "market_flagged", market,
+				"market flagged (warning: %t, caution: %s), new entries blocked (policy: %s)",
+				flag.Warning, flag.Caution, w.Policy)
+		case !flagged && wasFlagged:
+			bot.notify(ctx, "market_cleared", market, "market event cleared, new entries allowed")
+		}
+	}
+
+	// 거래가 중지된 상태에서는 주문을 내지 않음
+	bot.mu.RLock()
+	running := bot.isRunning
+	bot.mu.RUnlock()
+	if w.Policy != MarketEventPolicyLiquidate || !running {
+		return nil
+	}
+
+	// 거래 주기와 겹치지 않도록 tickMu를 잡고 청산
+	// 청산이 끝날 때까지 매 주기마다 재시도 (식별자는 주기 단위로 중복 방지)
+	bot.tickMu.Lock()
+	defer bot.tickMu.Unlock()
+	for _, market := range watched {
+		if _, flagged := current[market]; !flagged {
+			continue
+		}
+		if err := bot.liquidateMarket(ctx, market, w.Interval); err != nil {
+			bot.logger.Error("Failed to liquidate %s: %v", market, err)
+			bot.notify(ctx, "liquidation_failed", market, "failed to liquidate flagged market: %v", err)
+		}
+	}
+	return nil
+}
+
+// 마켓 보유 포지션 전량 시장가 청산 - 봇의 미체결 주문을 먼저 취소해 묶인 수량을 해제
+func (bot *TradingBot) liquidateMarket(ctx context.Context, market string, bucket time.Duration) error {
+	orders, err := bot.getAllOrders(ctx, "wait")
+	if err != nil {
+		return fmt.Errorf("failed to fetch open orders: %v", err)
+	}
+	for _, order := range orders {
+		if order.Market != market || order.Identifier == "" || bot.journal == nil {
+			continue
+		}
+		if _, ok := bot.journal.lookup(order.Identifier); !ok {
+			continue
+		}
+		if err := bot.cancelOrder(ctx, order.UUID); err != nil {
+			return err
+		}
+		bot.logger.Info("Cancelled open order %s on flagged market %s", order.UUID, market)
+	}
+
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance: %v", err)
+	}
+	_, base := splitMarket(market)
+	available, _, err := accountBalance(accounts, base)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s balance: %v", base, err)
+	}
+	if available <= 0 {
+		return nil
+	}
+
+	price, err := bot.fetchCurrentPrice(ctx, market)
+	if err != nil {
+		return err
+	}
+	if available*price < minOrderKRW {
+		bot.logger.Debug("Remaining %s position is below the minimum order size, skipping liquidation", base)
+		return nil
+	}
+
+	signal := TradeSignal{
+		Type:       "sell",
+		Price:      price,
+		Volume:     available,
+		OrderType:  OrderTypeMarket,
+		Identifier: signalIdentifier(market, "liquidate", time.Now(), bucket),
+	}
+	// 강제 청산은 슬리피지 한도를 적용하지 않음
+	orderReq, err := newOrderRequest(signal, market)
+	if err != nil {
+		return fmt.Errorf("invalid liquidation order: %v", err)
+	}
+	order, err := bot.submitOrder(ctx, orderReq)
+	if err != nil {
+		return err
+	}
+
+	bot.notify(ctx, "position_liquidated", market,
+		"liquidated %f %s at market (order: %s)", available, base, order.UUID)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Notification 구조체 - 운영자 알림
+type Notification struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`  // 알림 종류 (예: market_flagged)
+	Market  string    `json:"market"` // 관련 마켓 (없으면 빈 문자열)
+	Message string    `json:"message"`
+}
+
+// Notifier 인터페이스 - 알림 전송 방식
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// 로그로만 남기는 알림
+type logNotifier struct {
+	logger *Logger
+}
+
+func (n *logNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.logger.Info("[NOTIFY] %s %s: %s", notification.Event, notification.Market, notification.Message)
+	return nil
+}
+
+// 웹훅으로 JSON을 전송하는 알림 (로그에도 기록)
+type webhookNotifier struct {
+	URL    string
+	logger *Logger
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.logger.Info("[NOTIFY] %s %s: %s", notification.Event, notification.Market, notification.Message)
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: time.Second * 5}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// 환경 변수로 알림 방식 선택 (NOTIFY_WEBHOOK_URL이 없으면 로그만 기록)
+func newNotifierFromEnv(logger *Logger) Notifier {
+	if webhookUrl := os.Getenv("NOTIFY_WEBHOOK_URL"); webhookUrl != "" {
+		return &webhookNotifier{URL: webhookUrl, logger: logger}
+	}
+	return &logNotifier{logger: logger}
+}
+
+// 알림 전송 (실패해도 거래 흐름은 계속 진행)
+func (bot *TradingBot) notify(ctx context.Context, event, market, format string, v ...interface{}) {
+	if bot.notifier == nil {
+		return
+	}
+
+	notification := Notification{
+		Time:    time.Now(),
+		Event:   event,
+		Market:  market,
+		Message: fmt.Sprintf(format, v...),
+	}
+	if err := bot.notifier.Notify(ctx, notification); err != nil {
+		bot.logger.Error("Failed to send notification %s: %v", event, err)
+	}
+}
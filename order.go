@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// 업비트 주문 유형 (ord_type)
+const (
+	OrderTypeLimit  = "limit"  // 지정가 주문 (수량, 단가 지정)
+	OrderTypePrice  = "price"  // 시장가 매수 (KRW 총액 지정)
+	OrderTypeMarket = "market" // 시장가 매도 (수량 지정)
+	OrderTypeBest   = "best"   // 최유리 지정가 주문 (time_in_force 필수)
+)
+
+// 주문 체결 조건 (time_in_force)
+const (
+	TimeInForceIOC = "ioc" // 즉시 체결 가능한 수량만 체결 후 잔량 취소
+	TimeInForceFOK = "fok" // 전량 체결 불가 시 주문 전체 취소
+)
+
+// OrderRequest 구조체 - 업비트 주문 API로 전송되는 주문 정보
+type OrderRequest struct {
+	Market      string
+	Side        string  // "bid"(매수) 또는 "ask"(매도)
+	OrdType     string  // limit, price, market, best
+	Volume      float64 // 주문 수량 (코인 단위)
+	Price       float64 // 지정가 단가 또는 시장가/최유리 매수 총액 (KRW)
+	TimeInForce string  // ioc, fok (best 주문은 필수)
+	Identifier  string  // 멱등성 보장을 위한 클라이언트 주문 식별자
+}
+
+// 신호에 지정된 주문 유형을 주문 방향에 맞게 보정
+// 매수에 "market"이 지정되면 "price"로, 매도에 "price"가 지정되면 "market"으로 변환한다.
+func resolveOrderType(orderType, side string) string {
+	switch orderType {
+	case "":
+		return OrderTypeLimit
+	case OrderTypeMarket:
+		if side == "bid" {
+			return OrderTypePrice
+		}
+	case OrderTypePrice:
+		if side == "ask" {
+			return OrderTypeMarket
+		}
+	}
+	return orderType
+}
+
+// 신호로부터 주문 요청 생성
+func newOrderRequest(signal TradeSignal, market string) (OrderRequest, error) {
+	side := convertSignalTypeToUpbitSide(signal.Type)
+	if side == "" {
+		return OrderRequest{}, fmt.Errorf("invalid trade signal type: %s", signal.Type)
+	}
+
+	req := OrderRequest{
+		Market:      market,
+		Side:        side,
+		OrdType:     resolveOrderType(signal.OrderType, side),
+		Volume:      signal.Volume,
+		Price:       signal.Price,
+		TimeInForce: signal.TimeInForce,
+		Identifier:  signal.Identifier,
+	}
+
+	// 시장가/최유리 매수는 수량 대신 KRW 총액으로 주문
+	notional := signal.Notional
+	if notional <= 0 {
+		notional = signal.Volume * signal.Price
+	}
+
+	switch req.OrdType {
+	case OrderTypePrice:
+		req.Price = notional
+		req.Volume = 0
+		// 시장가 주문은 체결 조건을 지정할 수 없음 (ORDER_TIME_IN_FORCE는 limit/best에만 적용)
+		req.TimeInForce = ""
+	case OrderTypeMarket:
+		req.Price = 0
+		req.TimeInForce = ""
+	case OrderTypeBest:
+		if req.TimeInForce == "" {
+			req.TimeInForce = TimeInForceIOC
+		}
+		if side == "bid" {
+			req.Price = notional
+			req.Volume = 0
+		} else {
+			req.Price = 0
+		}
+	}
+
+	return req, req.validate()
+}
+
+// 주문 유형별 필수/금지 파라미터 검증
+func (r OrderRequest) validate() error {
+	if r.Market == "" {
+		return fmt.Errorf("market is required")
+	}
+	if r.Side != "bid" && r.Side != "ask" {
+		return fmt.Errorf("invalid order side: %s", r.Side)
+	}
+	if r.TimeInForce != "" && r.TimeInForce != TimeInForceIOC && r.TimeInForce != TimeInForceFOK {
+		return fmt.Errorf("invalid time_in_force: %s", r.TimeInForce)
+	}
+
+	switch r.OrdType {
+	case OrderTypeLimit:
+		if r.Volume <= 0 || r.Price <= 0 {
+			return fmt.Errorf("limit order requires positive volume and price")
+		}
+	case OrderTypePrice:
+		if r.Side != "bid" {
+			return fmt.Errorf("price order is only allowed for bid")
+		}
+		if r.Price <= 0 {
+			return fmt.Errorf("price order requires positive total price")
+		}
+		if r.TimeInForce != "" {
+			return fmt.Errorf("price order does not accept time_in_force")
+		}
+	case OrderTypeMarket:
+		if r.Side != "ask" {
+			return fmt.Errorf("market order is only allowed for ask")
+		}
+		if r.Volume <= 0 {
+			return fmt.Errorf("market order requires positive volume")
+		}
+		if r.TimeInForce != "" {
+			return fmt.Errorf("market order does not accept time_in_force")
+		}
+	case OrderTypeBest:
+		if r.TimeInForce == "" {
+			return fmt.Errorf("best order requires time_in_force")
+		}
+		if r.Side == "bid" && r.Price <= 0 {
+			return fmt.Errorf("best bid order requires positive total price")
+		}
+		if r.Side == "ask" && r.Volume <= 0 {
+			return fmt.Errorf("best ask order requires positive volume")
+		}
+	default:
+		return fmt.Errorf("unsupported order type: %s", r.OrdType)
+	}
+
+	return nil
+}
+
+// 업비트 주문 API 파라미터 생성 (주문 유형에 따라 불필요한 값은 제외)
+func (r OrderRequest) params() url.Values {
+	params := url.Values{}
+	params.Set("market", r.Market)
+	params.Set("side", r.Side)
+	params.Set("ord_type", r.OrdType)
+	if r.Volume > 0 {
+		params.Set("volume", strconv.FormatFloat(r.Volume, 'f', 8, 64))
+	}
+	if r.Price > 0 {
+		// 1원 미만 호가 단위 마켓의 가격이 잘리지 않도록 필요한 자릿수만큼 표기
+		params.Set("price", strconv.FormatFloat(r.Price, 'f', -1, 64))
+	}
+	if r.TimeInForce != "" {
+		params.Set("time_in_force", r.TimeInForce)
+	}
+	if r.Identifier != "" {
+		params.Set("identifier", r.Identifier)
+	}
+	return params
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveOrderType(t *testing.T) {
+	tests := []struct {
+		orderType, side, want string
+	}{
+		{"", "bid", OrderTypeLimit},
+		{"", "ask", OrderTypeLimit},
+		{OrderTypeMarket, "bid", OrderTypePrice},
+		{OrderTypeMarket, "ask", OrderTypeMarket},
+		{OrderTypePrice, "bid", OrderTypePrice},
+		{OrderTypePrice, "ask", OrderTypeMarket},
+		{OrderTypeBest, "bid", OrderTypeBest},
+		{OrderTypeLimit, "ask", OrderTypeLimit},
+	}
+
+	for _, tt := range tests {
+		if got := resolveOrderType(tt.orderType, tt.side); got != tt.want {
+			t.Errorf("resolveOrderType(%q, %q) = %q, want %q", tt.orderType, tt.side, got, tt.want)
+		}
+	}
+}
+
+func TestNewOrderRequestParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal TradeSignal
+		want   url.Values
+	}{
+		{
+			name:   "limit buy",
+			signal: TradeSignal{Type: "buy", Price: 50000000, Volume: 0.002, Identifier: "id-1"},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"limit"},
+				"volume": {"0.00200000"}, "price": {"50000000"}, "identifier": {"id-1"},
+			},
+		},
+		{
+			name:   "limit sell with sub-won price",
+			signal: TradeSignal{Type: "sell", OrderType: OrderTypeLimit, Price: 0.1234, Volume: 1000},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"ask"}, "ord_type": {"limit"},
+				"volume": {"1000.00000000"}, "price": {"0.1234"},
+			},
+		},
+		{
+			name:   "limit ioc",
+			signal: TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 50000000, Volume: 0.002, TimeInForce: TimeInForceIOC},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"limit"},
+				"volume": {"0.00200000"}, "price": {"50000000"}, "time_in_force": {"ioc"},
+			},
+		},
+		{
+			name:   "limit fok",
+			signal: TradeSignal{Type: "sell", OrderType: OrderTypeLimit, Price: 50000000, Volume: 0.002, TimeInForce: TimeInForceFOK},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"ask"}, "ord_type": {"limit"},
+				"volume": {"0.00200000"}, "price": {"50000000"}, "time_in_force": {"fok"},
+			},
+		},
+		{
+			name:   "price buy uses the notional",
+			signal: TradeSignal{Type: "buy", OrderType: OrderTypePrice, Price: 50000000, Volume: 0.002, Notional: 100000},
+			want:   url.Values{"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"price"}, "price": {"100000"}},
+		},
+		{
+			name:   "market buy becomes price and derives the notional",
+			signal: TradeSignal{Type: "buy", OrderType: OrderTypeMarket, Price: 50000000, Volume: 0.002},
+			want:   url.Values{"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"price"}, "price": {"100000"}},
+		},
+		{
+			name:   "price buy drops time_in_force",
+			signal: TradeSignal{Type: "buy", OrderType: OrderTypePrice, Notional: 100000, TimeInForce: TimeInForceIOC},
+			want:   url.Values{"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"price"}, "price": {"100000"}},
+		},
+		{
+			name:   "market sell sends only the volume",
+			signal: TradeSignal{Type: "sell", OrderType: OrderTypeMarket, Price: 50000000, Volume: 0.002, TimeInForce: TimeInForceFOK},
+			want:   url.Values{"market": {"KRW-BTC"}, "side": {"ask"}, "ord_type": {"market"}, "volume": {"0.00200000"}},
+		},
+		{
+			name:   "price sell becomes market",
+			signal: TradeSignal{Type: "sell", OrderType: OrderTypePrice, Price: 50000000, Volume: 0.002},
+			want:   url.Values{"market": {"KRW-BTC"}, "side": {"ask"}, "ord_type": {"market"}, "volume": {"0.00200000"}},
+		},
+		{
+			name:   "best buy defaults to ioc",
+			signal: TradeSignal{Type: "buy", OrderType: OrderTypeBest, Price: 50000000, Volume: 0.002, Notional: 100000},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"best"},
+				"price": {"100000"}, "time_in_force": {"ioc"},
+			},
+		},
+		{
+			name:   "best buy fok",
+			signal: TradeSignal{Type: "buy", OrderType: OrderTypeBest, Notional: 100000, TimeInForce: TimeInForceFOK},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"bid"}, "ord_type": {"best"},
+				"price": {"100000"}, "time_in_force": {"fok"},
+			},
+		},
+		{
+			name:   "best sell ioc",
+			signal: TradeSignal{Type: "sell", OrderType: OrderTypeBest, Price: 50000000, Volume: 0.002, TimeInForce: TimeInForceIOC},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"ask"}, "ord_type": {"best"},
+				"volume": {"0.00200000"}, "time_in_force": {"ioc"},
+			},
+		},
+		{
+			name:   "best sell defaults to ioc",
+			signal: TradeSignal{Type: "sell", OrderType: OrderTypeBest, Price: 50000000, Volume: 0.002},
+			want: url.Values{
+				"market": {"KRW-BTC"}, "side": {"ask"}, "ord_type": {"best"},
+				"volume": {"0.00200000"}, "time_in_force": {"ioc"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newOrderRequest(tt.signal, "KRW-BTC")
+			if err != nil {
+				t.Fatalf("newOrderRequest failed: %v", err)
+			}
+			if got := req.params(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOrderRequestRejectsInvalidSignals(t *testing.T) {
+	tests := []struct {
+		name    string
+		signal  TradeSignal
+		market  string
+		wantErr string
+	}{
+		{"hold signal", TradeSignal{Type: "hold", Price: 50000000, Volume: 0.002}, "KRW-BTC", "invalid trade signal type"},
+		{"missing market", TradeSignal{Type: "buy", Price: 50000000, Volume: 0.002}, "", "market is required"},
+		{"limit without price", TradeSignal{Type: "buy", Volume: 0.002}, "KRW-BTC", "limit order requires"},
+		{"limit without volume", TradeSignal{Type: "sell", Price: 50000000}, "KRW-BTC", "limit order requires"},
+		{"price buy without notional", TradeSignal{Type: "buy", OrderType: OrderTypePrice}, "KRW-BTC", "price order requires"},
+		{"market sell without volume", TradeSignal{Type: "sell", OrderType: OrderTypeMarket, Price: 50000000}, "KRW-BTC", "market order requires"},
+		{"best buy without notional", TradeSignal{Type: "buy", OrderType: OrderTypeBest, Volume: 0.002}, "KRW-BTC", "best bid order requires"},
+		{"best sell without volume", TradeSignal{Type: "sell", OrderType: OrderTypeBest, Price: 50000000}, "KRW-BTC", "best ask order requires"},
+		{"unknown time_in_force", TradeSignal{Type: "buy", Price: 50000000, Volume: 0.002, TimeInForce: "gtc"}, "KRW-BTC", "invalid time_in_force"},
+		{"unknown order type", TradeSignal{Type: "buy", OrderType: "stop", Price: 50000000, Volume: 0.002}, "KRW-BTC", "unsupported order type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newOrderRequest(tt.signal, tt.market)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// newOrderRequest가 보정하지 않는 조합 (직접 만든 요청)
+func TestOrderRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     OrderRequest
+		wantErr string
+	}{
+		{"valid limit", OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeLimit, Volume: 1, Price: 100}, ""},
+		{"invalid side", OrderRequest{Market: "KRW-BTC", Side: "buy", OrdType: OrderTypeLimit, Volume: 1, Price: 100}, "invalid order side"},
+		{"price order for ask", OrderRequest{Market: "KRW-BTC", Side: "ask", OrdType: OrderTypePrice, Price: 100}, "only allowed for bid"},
+		{"price order with time_in_force", OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypePrice, Price: 100, TimeInForce: TimeInForceIOC}, "does not accept time_in_force"},
+		{"market order for bid", OrderRequest{Market: "KRW-BTC", Side: "bid", OrdType: OrderTypeMarket, Volume: 1}, "only allowed for ask"},
+		{"market order with time_in_force", OrderRequest{Market: "KRW-BTC", Side: "ask", OrdType: OrderTypeMarket, Volume: 1, TimeInForce: TimeInForceFOK}, "does not accept time_in_force"},
+		{"best order without time_in_force", OrderRequest{Market: "KRW-BTC", Side: "ask", OrdType: OrderTypeBest, Volume: 1}, "requires time_in_force"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// 지정가 주문 가격 결정 방식
+const (
+	PricingLast       = "last"       // 최근 체결가 (기존 방식)
+	PricingPassive    = "passive"    // 같은 쪽 최우선 호가 (매수: 최우선 매수호가, 매도: 최우선 매도호가)
+	PricingAggressive = "aggressive" // 주문 수량이 모두 체결되는 반대쪽 호가까지
+)
+
+// OrderbookUnit 구조체 - 호가 단위
+type OrderbookUnit struct {
+	AskPrice float64 `json:"ask_price"`
+	BidPrice float64 `json:"bid_price"`
+	AskSize  float64 `json:"ask_size"`
+	BidSize  float64 `json:"bid_size"`
+}
+
+// Orderbook 구조체 - 업비트 호가 응답
+type Orderbook struct {
+	Market         string          `json:"market"`
+	TotalAskSize   float64         `json:"total_ask_size"`
+	TotalBidSize   float64         `json:"total_bid_size"`
+	OrderbookUnits []OrderbookUnit `json:"orderbook_units"`
+}
+
+// FillEstimate 구조체 - 호가 깊이 기준 예상 체결 결과
+type FillEstimate struct {
+	Mid           float64 `json:"mid"`
+	SpreadBps     float64 `json:"spread_bps"`
+	ExpectedPrice float64 `json:"expected_price"` // 물량 가중 평균 예상 체결가
+	WorstPrice    float64 `json:"worst_price"`    // 마지막으로 닿는 호가
+	Volume        float64 `json:"volume"`         // 예상 체결 수량
+	SlippageBps   float64 `json:"slippage_bps"`   // 중간가 대비 불리한 방향 차이 (bp)
+	Complete      bool    `json:"complete"`       // 호가 깊이 안에서 전량 체결 가능 여부
+}
+
+// 최우선 매수/매도 호가 중간가, 호가가 없으면 0
+func (o Orderbook) mid() float64 {
+	if len(o.OrderbookUnits) == 0 {
+		return 0
+	}
+	best := o.OrderbookUnits[0]
+	if best.AskPrice <= 0 || best.BidPrice <= 0 {
+		return 0
+	}
+	return (best.AskPrice + best.BidPrice) / 2
+}
+
+// 최우선 호가 스프레드 (bp), 호가가 없으면 -1
+func (o Orderbook) spreadBps() float64 {
+	mid := o.mid()
+	if mid <= 0 {
+		return -1
+	}
+	best := o.OrderbookUnits[0]
+	return (best.AskPrice - best.BidPrice) / mid * 10000
+}
+
+// 중간가 대비 불리한 방향 가격 차이 (bp) - 매수는 비쌀수록, 매도는 쌀수록 양수
+func slippageBps(side string, price, mid float64) float64 {
+	if mid <= 0 {
+		return 0
+	}
+	if side == "bid" {
+		return (price - mid) / mid * 10000
+	}
+	return (mid - price) / mid * 10000
+}
+
+// 반대쪽 호가를 따라가며 예상 체결가 계산
+// 매수는 volume 또는 notional(KRW) 중 하나로, 매도는 volume으로 크기를 지정한다.
+func (o Orderbook) estimateFill(side string, volume, notional float64) FillEstimate {
+	estimate := FillEstimate{Mid: o.mid(), SpreadBps: o.spreadBps()}
+
+	filledVolume, filledNotional := 0.0, 0.0
+	for _, unit := range o.OrderbookUnits {
+		price, size := unit.AskPrice, unit.AskSize
+		if side == "ask" {
+			price, size = unit.BidPrice, unit.BidSize
+		}
+		if price <= 0 || size <= 0 {
+			continue
+		}
+
+		take := size
+		if volume > 0 {
+			take = math.Min(size, volume-filledVolume)
+		} else if notional > 0 {
+			take = math.Min(size, (notional-filledNotional)/price)
+		}
+		if take <= 0 {
+			break
+		}
+
+		filledVolume += take
+		filledNotional += take * price
+		estimate.WorstPrice = price
+
+		if volume > 0 && filledVolume >= volume*(1-1e-9) {
+			estimate.Complete = true
+			break
+		}
+		if volume <= 0 && notional > 0 && filledNotional >= notional*(1-1e-9) {
+			estimate.Complete = true
+			break
+		}
+	}
+
+	if filledVolume > 0 {
+		estimate.Volume = filledVolume
+		estimate.ExpectedPrice = filledNotional / filledVolume
+		estimate.SlippageBps = slippageBps(side, estimate.ExpectedPrice, estimate.Mid)
+	}
+	return estimate
+}
+
+// 단일 마켓 호가 조회
+func (bot *TradingBot) fetchOrderbook(ctx context.Context, market string) (*Orderbook, error) {
+	orderbooks, err := bot.fetchOrderbooks(ctx, []string{market})
+	if err != nil {
+		return nil, err
+	}
+	if len(orderbooks) == 0 || len(orderbooks[0].OrderbookUnits) == 0 {
+		return nil, fmt.Errorf("no orderbook data available for market: %s", market)
+	}
+	return &orderbooks[0], nil
+}
+
+// 호가 일괄 조회
+func (bot *TradingBot) fetchOrderbooks(ctx context.Context, markets []string) ([]Orderbook, error) {
+	orderbooks := make([]Orderbook, 0, len(markets))
+	for start := 0; start < len(markets); start += quotationBatchSize {
+		end := start + quotationBatchSize
+		if end > len(markets) {
+			end = len(markets)
+		}
+
+		var batch []Orderbook
+		if err := bot.getQuotation(ctx, "/v1/orderbook", markets[start:end], &batch); err != nil {
+			return nil, err
+		}
+		orderbooks = append(orderbooks, batch...)
+	}
+	return orderbooks, nil
+}
+
+// OrderPricer 구조체 - 호가 기준 주문 가격 결정 및 슬리피지 제한
+type OrderPricer struct {
+	Mode           string  // 지정가 가격 결정 방식 (last, passive, aggressive)
+	MaxSlippageBps float64 // 허용 최대 예상 슬리피지 (bp, 0 이하이면 확인 안 함)
+}
+
+// 환경 변수로 가격 결정 방식 설정 (알 수 없는 방식이면 에러)
+func newOrderPricerFromEnv() (*OrderPricer, error) {
+	mode := getEnvOrDefault("ORDER_PRICING", PricingPassive)
+	if !validPricingMode(mode) {
+		return nil, fmt.Errorf("unknown ORDER_PRICING: %q", mode)
+	}
+
+	return &OrderPricer{
+		Mode:           mode,
+		MaxSlippageBps: getEnvFloat("MAX_SLIPPAGE_BPS", 30),
+	}, nil
+}
+
+// 호가를 반영해 신호의 주문 가격을 정하고 예상 슬리피지가 한도를 넘으면 거부
+func (p *OrderPricer) apply(book Orderbook, signal TradeSignal) (TradeSignal, FillEstimate, error) {
+	side := convertSignalTypeToUpbitSide(signal.Type)
+	if side == "" {
+		return signal, FillEstimate{}, fmt.Errorf("invalid trade signal type: %s", signal.Type)
+	}
+	if book.mid() <= 0 {
+		return signal, FillEstimate{}, fmt.Errorf("orderbook for %s has no best bid/ask", book.Market)
+	}
+
+	orderType := resolveOrderType(signal.OrderType, side)
+	notional := signal.Notional
+	if notional <= 0 {
+		notional = signal.Volume * signal.Price
+	}
+
+	var estimate FillEstimate
+	if side == "bid" && (orderType == OrderTypePrice || orderType == OrderTypeBest) {
+		estimate = book.estimateFill(side, 0, notional)
+	} else {
+		estimate = book.estimateFill(side, signal.Volume, 0)
+	}
+
+	slippage := estimate.SlippageBps
+	if orderType == OrderTypeLimit {
+		// 지정가는 주문 가격보다 불리하게 체결되지 않으므로 주문 가격 기준으로 평가 (aggressive는 물량 가중 예상 체결가)
+		best := book.OrderbookUnits[0]
+		switch p.Mode {
+		case PricingPassive:
+			if side == "bid" {
+				signal.Price = best.BidPrice
+			} else {
+				signal.Price = best.AskPrice
+			}
+		case PricingAggressive:
+			if !estimate.Complete {
+				return signal, estimate, fmt.Errorf("orderbook depth is insufficient for %f %s", signal.Volume, book.Market)
+			}
+			signal.Price = estimate.WorstPrice
+		}
+		if p.Mode != PricingAggressive {
+			slippage = slippageBps(side, signal.Price, estimate.Mid)
+		}
+
+		// 매수 가격이 바뀌면 명목 금액을 유지하도록 수량 재계산
+		if side == "bid" && signal.Notional > 0 && signal.Price > 0 {
+			signal.Volume = signal.Notional / signal.Price
+		}
+	} else if !estimate.Complete {
+		return signal, estimate, fmt.Errorf("orderbook depth is insufficient for %s %s order", book.Market, orderType)
+	}
+
+	if p.MaxSlippageBps > 0 && slippage > p.MaxSlippageBps {
+		return signal, estimate, fmt.Errorf("estimated slippage %.1f bps exceeds limit %.1f bps", slippage, p.MaxSlippageBps)
+	}
+	estimate.SlippageBps = slippage
+	return signal, estimate, nil
+}
+
+// 주문 가격 결정 방식 검증
+func validPricingMode(mode string) bool {
+	switch mode {
+	case PricingLast, PricingPassive, PricingAggressive:
+		return true
+	}
+	return false
+}
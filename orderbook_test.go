@@ -0,0 +1,225 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// 중간가 10000, 스프레드 20bp, 양쪽 깊이 6 (1 + 2 + 3)
+func testOrderbook() Orderbook {
+	return Orderbook{
+		Market: "KRW-BTC",
+		OrderbookUnits: []OrderbookUnit{
+			{AskPrice: 10010, AskSize: 1, BidPrice: 9990, BidSize: 1},
+			{AskPrice: 10020, AskSize: 2, BidPrice: 9980, BidSize: 2},
+			{AskPrice: 10040, AskSize: 3, BidPrice: 9960, BidSize: 3},
+		},
+	}
+}
+
+func TestEstimateFill(t *testing.T) {
+	tests := []struct {
+		name         string
+		book         Orderbook
+		side         string
+		volume       float64
+		notional     float64
+		wantVolume   float64
+		wantExpected float64
+		wantWorst    float64
+		wantSlippage float64
+		wantComplete bool
+	}{
+		{
+			name: "buy within best level", book: testOrderbook(), side: "bid", volume: 0.5,
+			wantVolume: 0.5, wantExpected: 10010, wantWorst: 10010, wantSlippage: 10, wantComplete: true,
+		},
+		{
+			name: "buy walks two levels", book: testOrderbook(), side: "bid", volume: 2,
+			wantVolume: 2, wantExpected: 10015, wantWorst: 10020, wantSlippage: 15, wantComplete: true,
+		},
+		{
+			name: "sell walks two levels", book: testOrderbook(), side: "ask", volume: 1.5,
+			wantVolume: 1.5, wantExpected: (9990 + 0.5*9980) / 1.5, wantWorst: 9980,
+			wantSlippage: (10000 - (9990+0.5*9980)/1.5) / 10000 * 10000, wantComplete: true,
+		},
+		{
+			name: "buy by notional", book: testOrderbook(), side: "bid", notional: 10010 + 10020,
+			wantVolume: 2, wantExpected: 10015, wantWorst: 10020, wantSlippage: 15, wantComplete: true,
+		},
+		{
+			name: "buy beyond depth", book: testOrderbook(), side: "bid", volume: 10,
+			wantVolume: 6, wantExpected: (10010 + 2*10020 + 3*10040) / 6.0, wantWorst: 10040,
+			wantSlippage: ((10010+2*10020+3*10040)/6.0 - 10000) / 10000 * 10000, wantComplete: false,
+		},
+		{
+			name: "empty levels are skipped",
+			book: Orderbook{OrderbookUnits: []OrderbookUnit{
+				{AskPrice: 10010, AskSize: 0, BidPrice: 9990, BidSize: 1},
+				{AskPrice: 10020, AskSize: 1, BidPrice: 9980, BidSize: 1},
+			}},
+			side: "bid", volume: 1,
+			wantVolume: 1, wantExpected: 10020, wantWorst: 10020, wantSlippage: 20, wantComplete: true,
+		},
+		{
+			name: "empty book", book: Orderbook{Market: "KRW-BTC"}, side: "bid", volume: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.book.estimateFill(tt.side, tt.volume, tt.notional)
+			if !approxEqual(got.Volume, tt.wantVolume) {
+				t.Errorf("volume %f, want %f", got.Volume, tt.wantVolume)
+			}
+			if !approxEqual(got.ExpectedPrice, tt.wantExpected) {
+				t.Errorf("expected price %f, want %f", got.ExpectedPrice, tt.wantExpected)
+			}
+			if got.WorstPrice != tt.wantWorst {
+				t.Errorf("worst price %f, want %f", got.WorstPrice, tt.wantWorst)
+			}
+			if !approxEqual(got.SlippageBps, tt.wantSlippage) {
+				t.Errorf("slippage %f bps, want %f", got.SlippageBps, tt.wantSlippage)
+			}
+			if got.Complete != tt.wantComplete {
+				t.Errorf("complete %v, want %v", got.Complete, tt.wantComplete)
+			}
+		})
+	}
+
+	empty := Orderbook{}.estimateFill("bid", 1, 0)
+	if empty.Mid != 0 || empty.SpreadBps != -1 {
+		t.Errorf("empty book: mid %f spread %f, want 0 and -1", empty.Mid, empty.SpreadBps)
+	}
+	if full := testOrderbook().estimateFill("bid", 1, 0); full.Mid != 10000 || !approxEqual(full.SpreadBps, 20) {
+		t.Errorf("mid %f spread %f, want 10000 and 20", full.Mid, full.SpreadBps)
+	}
+}
+
+func TestOrderPricerApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		maxSlippage  float64
+		book         Orderbook
+		signal       TradeSignal
+		wantPrice    float64
+		wantVolume   float64
+		wantSlippage float64
+		wantErr      string
+	}{
+		{
+			name: "passive buy rests on best bid", mode: PricingPassive, maxSlippage: 30, book: testOrderbook(),
+			signal:    TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 1, Notional: 9990 * 2},
+			wantPrice: 9990, wantVolume: 2, wantSlippage: -10,
+		},
+		{
+			name: "passive sell rests on best ask", mode: PricingPassive, maxSlippage: 30, book: testOrderbook(),
+			signal:    TradeSignal{Type: "sell", OrderType: OrderTypeLimit, Price: 10005, Volume: 1.5},
+			wantPrice: 10010, wantVolume: 1.5, wantSlippage: -10,
+		},
+		{
+			name: "aggressive buy crosses to the worst level", mode: PricingAggressive, maxSlippage: 30, book: testOrderbook(),
+			signal:    TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 2},
+			wantPrice: 10020, wantVolume: 2, wantSlippage: 15,
+		},
+		{
+			name: "last keeps the signal price", mode: PricingLast, maxSlippage: 30, book: testOrderbook(),
+			signal:    TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10025, Volume: 1},
+			wantPrice: 10025, wantVolume: 1, wantSlippage: 25,
+		},
+		{
+			name: "last price beyond the limit", mode: PricingLast, maxSlippage: 20, book: testOrderbook(),
+			signal:  TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10025, Volume: 1},
+			wantErr: "exceeds limit",
+		},
+		{
+			name: "aggressive beyond depth", mode: PricingAggressive, maxSlippage: 30, book: testOrderbook(),
+			signal:  TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 7},
+			wantErr: "insufficient",
+		},
+		{
+			name: "market buy by notional", mode: PricingPassive, maxSlippage: 30, book: testOrderbook(),
+			signal:    TradeSignal{Type: "buy", OrderType: OrderTypeMarket, Price: 10005, Notional: 10010 + 10020},
+			wantPrice: 10005, wantSlippage: 15,
+		},
+		{
+			name: "market sell beyond depth", mode: PricingPassive, maxSlippage: 30, book: testOrderbook(),
+			signal:  TradeSignal{Type: "sell", OrderType: OrderTypeMarket, Price: 10005, Volume: 7},
+			wantErr: "insufficient",
+		},
+		{
+			name: "zero limit disables the check", mode: PricingAggressive, maxSlippage: 0, book: testOrderbook(),
+			signal:    TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 6},
+			wantPrice: 10040, wantVolume: 6, wantSlippage: ((10010+2*10020+3*10040)/6.0 - 10000) / 10000 * 10000,
+		},
+		{
+			name: "empty book", mode: PricingPassive, maxSlippage: 30, book: Orderbook{Market: "KRW-BTC"},
+			signal:  TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 1},
+			wantErr: "no best bid/ask",
+		},
+		{
+			name: "one-sided book", mode: PricingPassive, maxSlippage: 30,
+			book:    Orderbook{Market: "KRW-BTC", OrderbookUnits: []OrderbookUnit{{AskPrice: 10010, AskSize: 1}}},
+			signal:  TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 1},
+			wantErr: "no best bid/ask",
+		},
+		{
+			name: "hold signal", mode: PricingPassive, maxSlippage: 30, book: testOrderbook(),
+			signal:  TradeSignal{Type: "hold", Price: 10005},
+			wantErr: "invalid trade signal type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pricer := &OrderPricer{Mode: tt.mode, MaxSlippageBps: tt.maxSlippage}
+			signal, estimate, err := pricer.apply(tt.book, tt.signal)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply failed: %v", err)
+			}
+			if signal.Price != tt.wantPrice {
+				t.Errorf("price %f, want %f", signal.Price, tt.wantPrice)
+			}
+			if !approxEqual(signal.Volume, tt.wantVolume) {
+				t.Errorf("volume %f, want %f", signal.Volume, tt.wantVolume)
+			}
+			if !approxEqual(estimate.SlippageBps, tt.wantSlippage) {
+				t.Errorf("slippage %f bps, want %f", estimate.SlippageBps, tt.wantSlippage)
+			}
+		})
+	}
+}
+
+func TestOrderPricerSlippageBoundary(t *testing.T) {
+	book := testOrderbook()
+	signal := TradeSignal{Type: "buy", OrderType: OrderTypeLimit, Price: 10005, Volume: 2}
+	slippage := book.estimateFill("bid", 2, 0).SlippageBps
+
+	// 한도와 같으면 허용
+	pricer := &OrderPricer{Mode: PricingAggressive, MaxSlippageBps: slippage}
+	if _, _, err := pricer.apply(book, signal); err != nil {
+		t.Fatalf("slippage equal to the limit should be accepted: %v", err)
+	}
+
+	// 한도를 조금이라도 넘으면 거부
+	pricer.MaxSlippageBps = math.Nextafter(slippage, 0)
+	if _, _, err := pricer.apply(book, signal); err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Fatalf("slippage just above the limit should be rejected, got %v", err)
+	}
+}
+
+func TestNewTradingBotRejectsUnknownOrderPricing(t *testing.T) {
+	t.Setenv("TRADING_MARKET", "KRW-BTC")
+	t.Setenv("ORDER_PRICING", "agressive")
+	if _, err := NewTradingBot(Config{AccessKey: "ak", SecretKey: "sk"}); err == nil {
+		t.Fatalf("expected an error for an unknown ORDER_PRICING")
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 거래 방식
+const (
+	StrategyModeSignal    = "signal"    // 기술적 분석 신호로 매수/매도
+	StrategyModeRebalance = "rebalance" // 목표 비중 유지 (리밸런싱)
+)
+
+// 리밸런싱 실행 사유
+const (
+	RebalanceReasonDrift    = "drift"    // 비중 이탈이 허용 범위 초과
+	RebalanceReasonSchedule = "schedule" // 정기 리밸런싱 주기 도래
+	RebalanceReasonManual   = "manual"   // 운영자 요청
+)
+
+// AllocationTarget 구조체 - 자산별 목표 비중
+type AllocationTarget struct {
+	Asset  string  `json:"asset"`  // 마켓 코드 (예: KRW-BTC) 또는 KRW
+	Weight float64 `json:"weight"` // 목표 비중 (%)
+}
+
+// Allocation 구조체 - 자산별 현재 비중과 목표 대비 이탈
+type Allocation struct {
+	Asset   string  `json:"asset"`
+	Price   float64 `json:"price,omitempty"` // 현재가 (KRW는 생략)
+	Volume  float64 `json:"volume"`          // 보유 수량 (주문 중 묶인 수량 포함)
+	Value   float64 `json:"value"`           // KRW 평가 금액
+	Target  float64 `json:"target"`          // 목표 비중 (%)
+	Current float64 `json:"current"`         // 현재 비중 (%)
+	Drift   float64 `json:"drift"`           // 현재 비중 - 목표 비중 (%p)
+}
+
+// RebalanceOrder 구조체 - 리밸런싱 주문 한 건
+type RebalanceOrder struct {
+	Market    string  `json:"market"`
+	Side      string  `json:"side"`     // buy, sell
+	Notional  float64 `json:"notional"` // 주문 금액 (KRW)
+	Volume    float64 `json:"volume,omitempty"`
+	OrderUUID string  `json:"order_uuid,omitempty"`
+	Skipped   string  `json:"skipped,omitempty"` // 주문하지 않은 이유
+	Error     string  `json:"error,omitempty"`
+}
+
+// RebalancePlan 구조체 - 리밸런싱 판단과 주문 계획/결과
+type RebalancePlan struct {
+	Time        time.Time        `json:"time"`
+	Total       float64          `json:"total"` // 목표 자산 평가 금액 합계 (KRW)
+	Allocations []Allocation     `json:"allocations"`
+	MaxDrift    float64          `json:"max_drift"` // 가장 큰 비중 이탈 (%p)
+	Reason      string           `json:"reason,omitempty"`
+	Orders      []RebalanceOrder `json:"orders"`
+	Executed    bool             `json:"executed"`
+}
+
+// Rebalancer 구조체 - 목표 비중 유지 설정과 마지막 실행 결과
+type Rebalancer struct {
+	Mode     string             // 거래 방식 (signal, rebalance)
+	Targets  []AllocationTarget // 목표 비중 (합계 100%, KRW 비중은 나머지)
+	Band     float64            // 허용 비중 이탈 (%p)
+	Interval time.Duration      // 정기 리밸런싱 주기 (0이면 비중 이탈 시에만)
+
+	mu   sync.Mutex
+	last *RebalancePlan // 마지막으로 실행한 리밸런싱
+}
+
+// 환경 변수로 리밸런싱 설정 - 거래 방식이나 목표 비중이 잘못되면 시작하지 않음
+func newRebalancerFromEnv() (*Rebalancer, error) {
+	r := &Rebalancer{
+		Mode:     getEnvOrDefault("STRATEGY_MODE", StrategyModeSignal),
+		Band:     getEnvFloat("REBALANCE_BAND_PERCENT", 5.0),
+		Interval: time.Duration(getEnvInt("REBALANCE_INTERVAL_HOURS", 0)) * time.Hour,
+	}
+	if r.Mode != StrategyModeSignal && r.Mode != StrategyModeRebalance {
+		return nil, fmt.Errorf("invalid STRATEGY_MODE: %q (expected %s or %s)", r.Mode, StrategyModeSignal, StrategyModeRebalance)
+	}
+
+	targets, err := parseAllocationTargets(os.Getenv("REBALANCE_TARGETS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REBALANCE_TARGETS: %v", err)
+	}
+	if r.enabled() && len(targets) == 0 {
+		return nil, fmt.Errorf("STRATEGY_MODE=%s requires REBALANCE_TARGETS", StrategyModeRebalance)
+	}
+	r.Targets = targets
+	return r, nil
+}
+
+// 목표 비중 파싱 (예: "KRW-BTC:50,KRW-ETH:30,KRW:20")
+// KRW 비중을 지정하지 않으면 100%에서 나머지를 KRW로 유지한다.
+func parseAllocationTargets(value string) ([]AllocationTarget, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var targets []AllocationTarget
+	seen := make(map[string]bool)
+	total := 0.0
+	for _, item := range strings.Split(value, ",") {
+		asset, weightText, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("expected ASSET:WEIGHT, got %q", item)
+		}
+		asset = strings.ToUpper(strings.TrimSpace(asset))
+		weight, err := strconv.ParseFloat(strings.TrimSpace(weightText), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %s", asset, weightText)
+		}
+		if asset != "KRW" {
+			if quote, _ := splitMarket(asset); quote != "KRW" {
+				return nil, fmt.Errorf("only KRW markets can be rebalanced: %s", asset)
+			}
+		}
+		if seen[asset] {
+			return nil, fmt.Errorf("duplicate asset: %s", asset)
+		}
+		seen[asset] = true
+		targets = append(targets, AllocationTarget{Asset: asset, Weight: weight})
+		total += weight
+	}
+
+	if total > 100+1e-9 {
+		return nil, fmt.Errorf("weights add up to %.2f%%, more than 100%%", total)
+	}
+	if !seen["KRW"] {
+		targets = append(targets, AllocationTarget{Asset: "KRW", Weight: 100 - total})
+	} else if math.Abs(total-100) > 1e-9 {
+		return nil, fmt.Errorf("weights add up to %.2f%%, expected 100%%", total)
+	}
+	return targets, nil
+}
+
+// 리밸런싱 방식 사용 여부
+func (r *Rebalancer) enabled() bool {
+	return r.Mode == StrategyModeRebalance
+}
+
+// 목표 비중에 포함된 마켓 목록 (KRW 제외)
+func (r *Rebalancer) markets() []string {
+	markets := make([]string, 0, len(r.Targets))
+	for _, target := range r.Targets {
+		if target.Asset != "KRW" {
+			markets = append(markets, target.Asset)
+		}
+	}
+	return markets
+}
+
+// 마지막 리밸런싱 결과
+func (r *Rebalancer) lastPlan() *RebalancePlan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.last
+}
+
+// 현재 잔고와 시세로 비중을 계산하고 리밸런싱 필요 여부와 주문 계획 작성
+// 목표 비중에 없는 통화는 평가 금액에서 제외하고 주문하지 않는다.
+func (r *Rebalancer) plan(ctx context.Context, bot *TradingBot, now time.Time) (*RebalancePlan, error) {
+	if len(r.Targets) == 0 {
+		return nil, fmt.Errorf("no rebalance targets configured (REBALANCE_TARGETS)")
+	}
+
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balance: %v", err)
+	}
+	prices := make(map[string]float64)
+	if markets := r.markets(); len(markets) > 0 {
+		tickers, err := bot.fetchTickers(ctx, markets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tickers: %v", err)
+		}
+		for _, ticker := range tickers {
+			prices[ticker.Market] = ticker.TradePrice
+		}
+	}
+
+	plan := &RebalancePlan{Time: now, Orders: []RebalanceOrder{}}
+	for _, target := range r.Targets {
+		allocation := Allocation{Asset: target.Asset, Target: target.Weight}
+		currency := "KRW"
+		if target.Asset != "KRW" {
+			_, currency = splitMarket(target.Asset)
+			allocation.Price = prices[target.Asset]
+			if allocation.Price <= 0 {
+				return nil, fmt.Errorf("no price for %s", target.Asset)
+			}
+		}
+		available, locked, err := accountBalance(accounts, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s balance: %v", currency, err)
+		}
+		allocation.Volume = available + locked
+		allocation.Value = allocation.Volume
+		if currency != "KRW" {
+			allocation.Value *= allocation.Price
+		}
+		plan.Total += allocation.Value
+		plan.Allocations = append(plan.Allocations, allocation)
+	}
+	if plan.Total <= 0 {
+		return nil, fmt.Errorf("no holdings to rebalance")
+	}
+
+	for i := range plan.Allocations {
+		allocation := &plan.Allocations[i]
+		allocation.Current = allocation.Value / plan.Total * 100
+		allocation.Drift = allocation.Current - allocation.Target
+		plan.MaxDrift = math.Max(plan.MaxDrift, math.Abs(allocation.Drift))
+	}
+
+	// 리밸런싱 사유 판단 (비중 이탈 우선)
+	r.mu.Lock()
+	last := r.last
+	r.mu.Unlock()
+	switch {
+	case plan.MaxDrift >= r.Band:
+		plan.Reason = RebalanceReasonDrift
+	case r.Interval > 0 && (last == nil || now.Sub(last.Time) >= r.Interval):
+		plan.Reason = RebalanceReasonSchedule
+	}
+
+	// 목표 금액과의 차이로 주문 계획 (매도 먼저, 매수 나중)
+	minOrder := bot.riskManager.Sizer.MinOrder
+	for _, allocation := range plan.Allocations {
+		if allocation.Asset == "KRW" {
+			continue
+		}
+		diff := allocation.Target/100*plan.Total - allocation.Value
+		order := RebalanceOrder{Market: allocation.Asset, Side: "buy", Notional: diff}
+		if diff < 0 {
+			order.Side = "sell"
+			order.Notional = -diff
+			order.Volume = order.Notional / allocation.Price
+		}
+		if order.Notional < minOrder {
+			order.Skipped = "below minimum order size"
+		}
+		plan.Orders = append(plan.Orders, order)
+	}
+	sort.SliceStable(plan.Orders, func(i, j int) bool {
+		return plan.Orders[i].Side == "sell" && plan.Orders[j].Side == "buy"
+	})
+
+	return plan, nil
+}
+
+// 주문 계획 실행 - 매도 후 KRW 잔고를 다시 조회해 매수 금액을 조정
+func (r *Rebalancer) execute(ctx context.Context, bot *TradingBot, plan *RebalancePlan, bucket time.Duration) error {
+	sizer := bot.riskManager.Sizer
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch balance: %v", err)
+	}
+
+	sold := false
+	for i := range plan.Orders {
+		order := &plan.Orders[i]
+		if order.Side != "sell" || order.Skipped != "" {
+			continue
+		}
+		_, base := splitMarket(order.Market)
+		available, _, err := accountBalance(accounts, base)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s balance: %v", base, err)
+		}
+		// 미체결 주문에 묶인 수량은 매도하지 않음
+		if order.Volume > available {
+			order.Notional *= available / order.Volume
+			order.Volume = available
+		}
+		if order.Notional < sizer.MinOrder {
+			order.Skipped = "available balance below minimum order size"
+			continue
+		}
+		r.submit(ctx, bot, order, TradeSignal{
+			Type:       "sell",
+			Volume:     order.Volume,
+			OrderType:  OrderTypeMarket,
+			Identifier: rebalanceIdentifier(plan, order.Market, order.Side, bucket),
+		})
+		sold = sold || order.OrderUUID != ""
+	}
+
+	// 매도 대금을 반영한 KRW 잔고로 매수 (부족하면 비율대로 축소)
+	if sold {
+		accounts, err = bot.getBalance(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch balance: %v", err)
+		}
+	}
+	cash, _, err := accountBalance(accounts, "KRW")
+	if err != nil {
+		return fmt.Errorf("failed to parse KRW balance: %v", err)
+	}
+	budget := cash / (1 + sizer.FeeRate)
+	wanted := 0.0
+	for _, order := range plan.Orders {
+		if order.Side == "buy" && order.Skipped == "" {
+			wanted += order.Notional
+		}
+	}
+	scale := 1.0
+	if wanted > budget {
+		scale = budget / wanted
+	}
+
+	for i := range plan.Orders {
+		order := &plan.Orders[i]
+		if order.Side != "buy" || order.Skipped != "" {
+			continue
+		}
+		if flag, ok := bot.watcher.isFlagged(order.Market); ok {
+			order.Skipped = fmt.Sprintf("flagged market (warning: %t, caution: %s)", flag.Warning, flag.Caution)
+			continue
+		}
+		order.Notional *= scale
+		if order.Notional < sizer.MinOrder {
+			order.Skipped = "insufficient KRW balance"
+			continue
+		}
+		r.submit(ctx, bot, order, TradeSignal{
+			Type:       "buy",
+			Notional:   order.Notional,
+			OrderType:  OrderTypePrice,
+			Identifier: rebalanceIdentifier(plan, order.Market, order.Side, bucket),
+		})
+	}
+
+	plan.Executed = true
+	r.mu.Lock()
+	r.last = plan
+	r.mu.Unlock()
+	return nil
+}
+
+// 리밸런싱 주문 식별자 - 자동 실행은 사유별로 주기 단위 중복을 막고, 운영자 요청은 요청마다 새로 만든다.
+func rebalanceIdentifier(plan *RebalancePlan, market, side string, bucket time.Duration) string {
+	key := "rebalance-" + side + "|" + plan.Reason
+	if plan.Reason == RebalanceReasonManual {
+		key += "|" + strconv.FormatInt(plan.Time.UnixNano(), 10)
+	}
+	return signalIdentifier(market, key, plan.Time, bucket)
+}
+
+// 리밸런싱 주문 제출 (실패는 주문 결과에 기록하고 나머지 주문은 계속 진행)
+func (r *Rebalancer) submit(ctx context.Context, bot *TradingBot, order *RebalanceOrder, signal TradeSignal) {
+	if ctx.Err() != nil {
+		order.Skipped = "trading stopped"
+		return
+	}
+	orderReq, err := newOrderRequest(signal, order.Market)
+	if err != nil {
+		order.Error = err.Error()
+		return
+	}
+	placed, err := bot.submitOrder(ctx, orderReq)
+	if err != nil {
+		order.Error = err.Error()
+		bot.logger.Error("Rebalance %s order on %s failed: %v", order.Side, order.Market, err)
+		return
+	}
+	order.OrderUUID = placed.UUID
+	bot.logger.Info("Rebalance %s order on %s: %.0f KRW (order: %s)", order.Side, order.Market, order.Notional, placed.UUID)
+}
+
+// 거래 주기마다 호출 - 비중 이탈이 허용 범위를 넘거나 정기 주기가 되면 리밸런싱
+func (r *Rebalancer) tick(ctx context.Context, bot *TradingBot, bucket time.Duration) {
+	plan, err := r.plan(ctx, bot, time.Now())
+	if err != nil {
+		bot.logger.Error("Rebalance check failed: %v", err)
+		return
+	}
+	if plan.Reason == "" {
+		bot.logger.Debug("Allocation within band (max drift %.2f%%p), no rebalance", plan.MaxDrift)
+		return
+	}
+
+	bot.logger.Info("Rebalancing (%s, max drift %.2f%%p, total %.0f KRW)", plan.Reason, plan.MaxDrift, plan.Total)
+	if err := r.execute(ctx, bot, plan, bucket); err != nil {
+		bot.logger.Error("Rebalance failed: %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"trading-bot/upbitmock"
+)
+
+func TestNewTradingBotRejectsInvalidStrategyConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"unknown strategy mode", map[string]string{"STRATEGY_MODE": "rebalanse"}},
+		{"invalid targets", map[string]string{"STRATEGY_MODE": StrategyModeRebalance, "REBALANCE_TARGETS": "KRW-BTC:80,KRW-ETH:40"}},
+		{"invalid targets in signal mode", map[string]string{"REBALANCE_TARGETS": "KRW-BTC"}},
+		{"rebalance without targets", map[string]string{"STRATEGY_MODE": StrategyModeRebalance}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRADING_MARKET", "KRW-BTC")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if _, err := NewTradingBot(Config{AccessKey: "ak", SecretKey: "sk"}); err == nil {
+				t.Fatalf("expected an error for %v", tt.env)
+			}
+		})
+	}
+}
+
+func TestParseAllocationTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []AllocationTarget
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: nil},
+		{name: "KRW gets the remainder", value: "KRW-BTC:50, krw-eth:30", want: []AllocationTarget{{"KRW-BTC", 50}, {"KRW-ETH", 30}, {"KRW", 20}}},
+		{name: "explicit KRW", value: "KRW-BTC:60,KRW:40", want: []AllocationTarget{{"KRW-BTC", 60}, {"KRW", 40}}},
+		{name: "fully invested", value: "KRW-BTC:100", want: []AllocationTarget{{"KRW-BTC", 100}, {"KRW", 0}}},
+		{name: "missing weight", value: "KRW-BTC", wantErr: true},
+		{name: "negative weight", value: "KRW-BTC:-10", wantErr: true},
+		{name: "non-numeric weight", value: "KRW-BTC:half", wantErr: true},
+		{name: "non-KRW market", value: "BTC-ETH:50", wantErr: true},
+		{name: "duplicate asset", value: "KRW-BTC:20,KRW-BTC:30", wantErr: true},
+		{name: "more than 100%", value: "KRW-BTC:70,KRW-ETH:40", wantErr: true},
+		{name: "explicit KRW not adding up to 100%", value: "KRW-BTC:50,KRW:30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAllocationTargets(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAllocationTargets(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAllocationTargets(%q) failed: %v", tt.value, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("targets = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Asset != tt.want[i].Asset || !approxEqual(got[i].Weight, tt.want[i].Weight) {
+					t.Fatalf("targets = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// BTC/ETH 가격이 100,000 KRW인 리밸런싱 봇
+func newRebalanceTestBot(t *testing.T, targets string) (*TradingBot, *upbitmock.Server) {
+	t.Helper()
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.AddMarket(upbitmock.Market{Market: "KRW-ETH"})
+	mock.SetPricePath("KRW-BTC", 100000)
+	mock.SetPricePath("KRW-ETH", 100000)
+
+	bot := newMockBot(t, mock, map[string]string{
+		"TRADING_MARKET":         "",
+		"STRATEGY_MODE":          StrategyModeRebalance,
+		"REBALANCE_TARGETS":      targets,
+		"REBALANCE_BAND_PERCENT": "5",
+	})
+	return bot, mock
+}
+
+func findRebalanceOrder(t *testing.T, plan *RebalancePlan, market string) RebalanceOrder {
+	t.Helper()
+	for _, order := range plan.Orders {
+		if order.Market == market {
+			return order
+		}
+	}
+	t.Fatalf("no order for %s in %+v", market, plan.Orders)
+	return RebalanceOrder{}
+}
+
+func TestRebalancePlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    string
+		balances   map[string]float64 // KRW 금액, 코인 수량 (가격 100,000 KRW)
+		wantReason string
+		wantDrift  float64
+		wantOrders []RebalanceOrder // 매도 먼저
+	}{
+		{
+			name:       "within band",
+			targets:    "KRW-BTC:50,KRW:50",
+			balances:   map[string]float64{"KRW": 530000, "BTC": 4.7},
+			wantReason: "",
+			wantDrift:  3,
+			wantOrders: []RebalanceOrder{{Market: "KRW-BTC", Side: "buy", Notional: 30000}},
+		},
+		{
+			name:       "drift beyond band",
+			targets:    "KRW-BTC:50,KRW:50",
+			balances:   map[string]float64{"KRW": 400000, "BTC": 6},
+			wantReason: RebalanceReasonDrift,
+			wantDrift:  10,
+			wantOrders: []RebalanceOrder{{Market: "KRW-BTC", Side: "sell", Notional: 100000, Volume: 1}},
+		},
+		{
+			// 목표 순서와 관계없이 매도를 먼저 실행
+			name:       "sells before buys",
+			targets:    "KRW-ETH:50,KRW-BTC:50",
+			balances:   map[string]float64{"KRW": 0, "BTC": 8, "ETH": 2},
+			wantReason: RebalanceReasonDrift,
+			wantDrift:  30,
+			wantOrders: []RebalanceOrder{
+				{Market: "KRW-BTC", Side: "sell", Notional: 300000, Volume: 3},
+				{Market: "KRW-ETH", Side: "buy", Notional: 300000},
+			},
+		},
+		{
+			name:       "differences below the minimum order are skipped",
+			targets:    "KRW-BTC:50,KRW-ETH:30,KRW:20",
+			balances:   map[string]float64{"KRW": 200000, "BTC": 5.03, "ETH": 2.97},
+			wantReason: "",
+			wantDrift:  0.3,
+			wantOrders: []RebalanceOrder{
+				{Market: "KRW-BTC", Side: "sell", Notional: 3000, Volume: 0.03, Skipped: "below minimum order size"},
+				{Market: "KRW-ETH", Side: "buy", Notional: 3000, Skipped: "below minimum order size"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, mock := newRebalanceTestBot(t, tt.targets)
+			for currency, amount := range tt.balances {
+				mock.SetBalance(currency, amount, 100000)
+			}
+
+			plan, err := bot.rebalancer.plan(context.Background(), bot, time.Now())
+			if err != nil {
+				t.Fatalf("plan failed: %v", err)
+			}
+			if plan.Reason != tt.wantReason || math.Abs(plan.MaxDrift-tt.wantDrift) > 1e-6 {
+				t.Fatalf("reason = %q, max drift = %f, want %q and %f", plan.Reason, plan.MaxDrift, tt.wantReason, tt.wantDrift)
+			}
+			if len(plan.Orders) != len(tt.wantOrders) {
+				t.Fatalf("orders = %+v, want %+v", plan.Orders, tt.wantOrders)
+			}
+			for i, want := range tt.wantOrders {
+				got := plan.Orders[i]
+				if got.Market != want.Market || got.Side != want.Side || got.Skipped != want.Skipped ||
+					math.Abs(got.Notional-want.Notional) > 1e-6 || math.Abs(got.Volume-want.Volume) > 1e-9 {
+					t.Fatalf("order %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRebalanceExecuteScalesBuysToCash(t *testing.T) {
+	bot, mock := newRebalanceTestBot(t, "KRW-BTC:50,KRW-ETH:50")
+	mock.SetBalance("KRW", 200000, 0)
+
+	// KRW 잔고(200,000)보다 큰 매수 계획은 비율대로 축소, 최소 주문 금액 미만이 되면 건너뜀
+	plan := &RebalancePlan{
+		Time:   time.Now(),
+		Reason: RebalanceReasonDrift,
+		Orders: []RebalanceOrder{
+			{Market: "KRW-BTC", Side: "buy", Notional: 300000},
+			{Market: "KRW-ETH", Side: "buy", Notional: 100000},
+		},
+	}
+	if err := bot.rebalancer.execute(context.Background(), bot, plan, time.Minute); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+
+	budget := 200000 / (1 + bot.riskManager.Sizer.FeeRate)
+	btc := findRebalanceOrder(t, plan, "KRW-BTC")
+	eth := findRebalanceOrder(t, plan, "KRW-ETH")
+	if btc.OrderUUID == "" || eth.OrderUUID == "" {
+		t.Fatalf("orders = %+v, want both buys placed", plan.Orders)
+	}
+	if !approxEqual(btc.Notional, budget*0.75) || !approxEqual(eth.Notional, budget*0.25) {
+		t.Fatalf("notionals = %f, %f, want %f and %f", btc.Notional, eth.Notional, budget*0.75, budget*0.25)
+	}
+
+	// 매수 금액이 최소 주문 금액 아래로 줄어들면 주문하지 않음
+	mock.SetBalance("KRW", 10000, 0)
+	plan = &RebalancePlan{
+		Time:   time.Now().Add(time.Hour),
+		Reason: RebalanceReasonDrift,
+		Orders: []RebalanceOrder{
+			{Market: "KRW-BTC", Side: "buy", Notional: 60000},
+			{Market: "KRW-ETH", Side: "buy", Notional: 20000},
+		},
+	}
+	if err := bot.rebalancer.execute(context.Background(), bot, plan, time.Minute); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if btc := findRebalanceOrder(t, plan, "KRW-BTC"); btc.OrderUUID == "" {
+		t.Fatalf("BTC order = %+v, want it placed", btc)
+	}
+	if eth := findRebalanceOrder(t, plan, "KRW-ETH"); eth.OrderUUID != "" || eth.Skipped != "insufficient KRW balance" {
+		t.Fatalf("ETH order = %+v, want it skipped for insufficient KRW", eth)
+	}
+}
+
+func TestRebalanceSellsBeforeBuying(t *testing.T) {
+	bot, mock := newRebalanceTestBot(t, "KRW-ETH:50,KRW-BTC:50")
+	mock.SetBalance("BTC", 8, 100000)
+	mock.SetBalance("ETH", 2, 100000)
+
+	ctx := context.Background()
+	plan, err := bot.rebalancer.plan(ctx, bot, time.Now())
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+	// KRW가 없으므로 매도 대금으로만 매수 가능
+	if err := bot.rebalancer.execute(ctx, bot, plan, time.Minute); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	for _, order := range plan.Orders {
+		if order.OrderUUID == "" {
+			t.Fatalf("order %+v was not placed", order)
+		}
+	}
+	eth := findRebalanceOrder(t, plan, "KRW-ETH")
+	if eth.Notional < 290000 {
+		t.Fatalf("ETH buy = %f KRW, want it funded by the BTC sale", eth.Notional)
+	}
+}
+
+// 자동 리밸런싱과 같은 주기에 운영자가 요청해도 주문이 나감
+func TestManualRebalanceAfterAutomaticTick(t *testing.T) {
+	bot, mock := newRebalanceTestBot(t, "KRW-BTC:50,KRW:50")
+	mock.SetBalance("KRW", 400000, 0)
+	mock.SetBalance("BTC", 6, 100000)
+
+	ctx := context.Background()
+	bucket := time.Hour
+	bot.rebalancer.tick(ctx, bot, bucket)
+	last := bot.rebalancer.lastPlan()
+	if last == nil || last.Reason != RebalanceReasonDrift || findRebalanceOrder(t, last, "KRW-BTC").OrderUUID == "" {
+		t.Fatalf("automatic rebalance = %+v, want a drift sell", last)
+	}
+
+	// 같은 주기 안에서 다시 비중이 틀어진 뒤 운영자 요청
+	mock.SetBalance("BTC", 6, 100000)
+	plan, err := bot.rebalancer.plan(ctx, bot, time.Now())
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+	plan.Reason = RebalanceReasonManual
+	if err := bot.rebalancer.execute(ctx, bot, plan, bucket); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	order := findRebalanceOrder(t, plan, "KRW-BTC")
+	if order.Side != "sell" || order.OrderUUID == "" || order.Error != "" {
+		t.Fatalf("manual rebalance order = %+v, want a new sell", order)
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Position 구조체 - 통화별 보유 현황
+type Position struct {
+	Currency    string  `json:"currency"`
+	Balance     float64 `json:"balance"` // 주문 가능 수량
+	Locked      float64 `json:"locked"`  // 미체결 주문에 묶인 수량
+	AvgBuyPrice float64 `json:"avg_buy_price"`
+}
+
+// PositionBook 구조체 - 거래소 기준으로 재구성한 보유 현황과 미체결 주문
+type PositionBook struct {
+	Positions  map[string]Position `json:"positions"`
+	OpenOrders []Order             `json:"open_orders"`
+}
+
+// ReconciliationReport 구조체 - 시작 시 대조 결과
+type ReconciliationReport struct {
+	Time          time.Time `json:"time"`
+	OpenOrders    int       `json:"open_orders"`
+	RecentOrders  int       `json:"recent_orders"`
+	Discrepancies []string  `json:"discrepancies"`
+}
+
+// 계좌 목록으로부터 보유 현황 재구성
+func newPositionBook(accounts []Account, openOrders []Order) (*PositionBook, error) {
+	book := &PositionBook{
+		Positions:  make(map[string]Position),
+		OpenOrders: openOrders,
+	}
+
+	for _, account := range accounts {
+		balance, locked, err := accountBalance([]Account{account}, account.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s balance: %v", account.Currency, err)
+		}
+		avgBuyPrice, _ := strconv.ParseFloat(account.AvgBuyPrice, 64)
+		book.Positions[account.Currency] = Position{
+			Currency:    account.Currency,
+			Balance:     balance,
+			Locked:      locked,
+			AvgBuyPrice: avgBuyPrice,
+		}
+	}
+
+	return book, nil
+}
+
+// 저널과 대조할 최근 완료/취소 주문 기간
+const reconcileLookback = 24 * time.Hour
+
+// 시작 시 거래소 잔고, 미체결 주문, 최근 체결 내역을 불러와 로컬 저널과 대조
+func (bot *TradingBot) reconcile(ctx context.Context) (*ReconciliationReport, error) {
+	// 1. 결과를 알 수 없는 주문 먼저 정리
+	if err := bot.recoverPendingOrders(ctx); err != nil {
+		return nil, err
+	}
+
+	// 2. 거래소 상태 조회
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+	openOrders, err := bot.getAllOrders(ctx, "wait")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch open orders: %v", err)
+	}
+	since := time.Now().Add(-reconcileLookback)
+	doneOrders, err := bot.getOrdersSince(ctx, "done", since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent fills: %v", err)
+	}
+	cancelledOrders, err := bot.getOrdersSince(ctx, "cancel", since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent cancels: %v", err)
+	}
+
+	book, err := newPositionBook(accounts, openOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconciliationReport{
+		Time:          time.Now(),
+		OpenOrders:    len(openOrders),
+		RecentOrders:  len(doneOrders) + len(cancelledOrders),
+		Discrepancies: []string{},
+	}
+
+	// 3. 거래소 주문과 저널 대조
+	known := make(map[string]bool)
+	for _, orders := range [][]Order{openOrders, doneOrders, cancelledOrders} {
+		for _, order := range orders {
+			known[order.UUID] = true
+		}
+	}
+
+	for _, order := range openOrders {
+		if order.Identifier == "" {
+			report.Discrepancies = append(report.Discrepancies,
+				fmt.Sprintf("open order %s on %s was not placed by the bot", order.UUID, order.Market))
+			continue
+		}
+		if _, ok := bot.journal.lookup(order.Identifier); !ok {
+			report.Discrepancies = append(report.Discrepancies,
+				fmt.Sprintf("open order %s (%s) is missing from the journal", order.UUID, order.Identifier))
+		}
+	}
+
+	for _, entry := range bot.journal.entries() {
+		switch entry.Status {
+		case JournalIntent:
+			report.Discrepancies = append(report.Discrepancies,
+				fmt.Sprintf("order %s is still unresolved in the journal", entry.Identifier))
+		case JournalSubmitted, JournalRecovered:
+			if entry.UUID != "" && !known[entry.UUID] && entry.Time.After(since) {
+				report.Discrepancies = append(report.Discrepancies,
+					fmt.Sprintf("journal order %s (%s) not found in recent exchange orders", entry.Identifier, entry.UUID))
+			}
+		}
+	}
+
+	if removed := bot.journal.compact(time.Now().Add(-journalRetention), openOrders); removed > 0 {
+		bot.logger.Info("Compacted %d finished journal entries", removed)
+	}
+
+	// 4. 묶인 잔고와 미체결 주문 대조
+	lockedByOrders := make(map[string]bool)
+	for _, order := range openOrders {
+		quote, base := splitMarket(order.Market)
+		if order.Side == "bid" {
+			lockedByOrders[quote] = true
+		} else {
+			lockedByOrders[base] = true
+		}
+	}
+	for currency, position := range book.Positions {
+		if position.Locked > 0 && !lockedByOrders[currency] {
+			report.Discrepancies = append(report.Discrepancies,
+				fmt.Sprintf("%s has %f locked without a matching open order", currency, position.Locked))
+		}
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		bot.logger.Error("Reconciliation discrepancy: %s", discrepancy)
+	}
+	bot.logger.Info("Reconciliation finished: %d accounts, %d open orders, %d recent orders, %d discrepancies",
+		len(accounts), report.OpenOrders, report.RecentOrders, len(report.Discrepancies))
+
+	bot.mu.Lock()
+	bot.positions = book
+	bot.reconciliation = report
+	bot.mu.Unlock()
+
+	return report, nil
+}
+
+// 잔고로 보유 현황 갱신 (미체결 주문 목록은 마지막 조회 결과 유지)
+func (bot *TradingBot) updatePositions(accounts []Account) error {
+	bot.mu.RLock()
+	var openOrders []Order
+	if bot.positions != nil {
+		openOrders = bot.positions.OpenOrders
+	}
+	bot.mu.RUnlock()
+
+	book, err := newPositionBook(accounts, openOrders)
+	if err != nil {
+		return err
+	}
+
+	bot.mu.Lock()
+	bot.positions = book
+	bot.mu.Unlock()
+
+	// 미체결 주문 목록을 얻은 김에 오래된 저널 기록 정리
+	if bot.journal != nil {
+		if removed := bot.journal.compact(time.Now().Add(-journalRetention), openOrders); removed > 0 {
+			bot.logger.Debug("Compacted %d finished journal entries", removed)
+		}
+	}
+	return nil
+}
+
+// 거래소 잔고와 미체결 주문으로 보유 현황을 다시 구성
+func (bot *TradingBot) refreshPositions(ctx context.Context) error {
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch accounts: %v", err)
+	}
+	openOrders, err := bot.getAllOrders(ctx, "wait")
+	if err != nil {
+		return fmt.Errorf("failed to fetch open orders: %v", err)
+	}
+
+	book, err := newPositionBook(accounts, openOrders)
+	if err != nil {
+		return err
+	}
+
+	bot.mu.Lock()
+	bot.positions = book
+	bot.mu.Unlock()
+	return nil
+}
+
+// 보유 중인 코인의 KRW 마켓 목록 (거래소 잔고 기준)
+// 잔고 조회에 실패하면 마지막 보유 현황을 사용한다.
+func (bot *TradingBot) heldMarkets(ctx context.Context) []string {
+	accounts, err := bot.getBalance(ctx)
+	if err == nil {
+		err = bot.updatePositions(accounts)
+	}
+	if err != nil {
+		bot.logger.Error("Failed to refresh balances, using last known positions: %v", err)
+	}
+
+	bot.mu.RLock()
+	defer bot.mu.RUnlock()
+
+	markets := make([]string, 0)
+	if bot.positions == nil {
+		return markets
+	}
+	for currency, position := range bot.positions.Positions {
+		if currency == "KRW" || position.Balance+position.Locked <= 0 {
+			continue
+		}
+		markets = append(markets, "KRW-"+currency)
+	}
+	sort.Strings(markets)
+	return markets
+}
+
+// 주기적 보유 현황 갱신 - 시작 이후 열린 포지션도 스크리너/감시/청산 대상에 포함되도록 함
+func (bot *TradingBot) runPositionRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := bot.refreshPositions(ctx); err != nil && ctx.Err() == nil {
+				bot.logger.Error("Position refresh failed: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 주문 목록 한 페이지 크기 (업비트 최대값)
+const ordersPageSize = 100
+
+// 상태별 주문 목록 한 페이지 조회 (최신순)
+func (bot *TradingBot) getOrdersPage(ctx context.Context, state string, page int) ([]Order, error) {
+	values := url.Values{}
+	values.Set("state", state)
+	values.Set("limit", strconv.Itoa(ordersPageSize))
+	values.Set("page", strconv.Itoa(page))
+	values.Set("order_by", "desc")
+
+	var orders []Order
+	if err := bot.doPrivate(ctx, http.MethodGet, "/v1/orders", values, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// 상태별 전체 주문 목록 조회 (wait: 미체결, done: 체결 완료, cancel: 취소)
+// 페이지 크기보다 적게 돌아올 때까지 다음 페이지를 조회한다 (최신순).
+func (bot *TradingBot) getAllOrders(ctx context.Context, state string) ([]Order, error) {
+	var orders []Order
+	for page := 1; ; page++ {
+		batch, err := bot.getOrdersPage(ctx, state, page)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, batch...)
+		if len(batch) < ordersPageSize {
+			return orders, nil
+		}
+	}
+}
+
+// since 이후에 생성된 주문이 포함될 때까지 페이지 조회 (최신순, 마지막 페이지에는 이전 주문이 섞일 수 있음)
+func (bot *TradingBot) getOrdersSince(ctx context.Context, state string, since time.Time) ([]Order, error) {
+	var orders []Order
+	for page := 1; ; page++ {
+		batch, err := bot.getOrdersPage(ctx, state, page)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, batch...)
+		if len(batch) < ordersPageSize {
+			return orders, nil
+		}
+		oldest, err := time.Parse(time.RFC3339, batch[len(batch)-1].CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_at for order %s: %v", batch[len(batch)-1].UUID, err)
+		}
+		if oldest.Before(since) {
+			return orders, nil
+		}
+	}
+}
@@ -0,0 +1,708 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 신호 규칙 언어
+//
+//	rule       := or
+//	or         := and ("or" and)*
+//	and        := not ("and" not)*
+//	not        := "not" not | comparison
+//	comparison := sum (("<" | "<=" | ">" | ">=" | "==" | "!=" | "crosses_above" | "crosses_below") sum)?
+//	sum        := product (("+" | "-") product)*
+//	product    := unary (("*" | "/") unary)*
+//	unary      := "-" unary | atom
+//	atom       := number | "close" | "price" | indicator "(" args ")" | "atleast" "(" n "," rule ("," rule)* ")" | "(" rule ")"
+//
+// 예: sma(10) > sma(20) and rsi(14) < 30 and close < bb_lower(20, 2)
+//     atleast(2, rsi(14) < 30, close < bb_lower(20, 2), sma(5) crosses_above sma(20))
+
+// 규칙에서 사용할 수 있는 지표
+type ruleIndicator struct {
+	args     int                                                  // 인자 개수
+	lookback func(args []float64) int                             // 계산에 필요한 가격 개수
+	value    func(t *TechnicalIndicators, args []float64) float64 // 지표 값
+}
+
+var ruleIndicators = map[string]ruleIndicator{
+	"sma": {
+		args:     1,
+		lookback: func(args []float64) int { return int(args[0]) },
+		value:    func(t *TechnicalIndicators, args []float64) float64 { return t.calculateMA(int(args[0])) },
+	},
+	"rsi": {
+		args:     1,
+		lookback: func(args []float64) int { return int(args[0]) + 1 },
+		value:    func(t *TechnicalIndicators, args []float64) float64 { return t.calculateRSI(int(args[0])) },
+	},
+	"bb_upper": {
+		args:     2,
+		lookback: func(args []float64) int { return int(args[0]) },
+		value: func(t *TechnicalIndicators, args []float64) float64 {
+			_, upper, _ := t.calculateBollingerBands(int(args[0]), args[1])
+			return upper
+		},
+	},
+	"bb_lower": {
+		args:     2,
+		lookback: func(args []float64) int { return int(args[0]) },
+		value: func(t *TechnicalIndicators, args []float64) float64 {
+			_, _, lower := t.calculateBollingerBands(int(args[0]), args[1])
+			return lower
+		},
+	},
+	"bb_middle": {
+		args:     1,
+		lookback: func(args []float64) int { return int(args[0]) },
+		value:    func(t *TechnicalIndicators, args []float64) float64 { return t.calculateMA(int(args[0])) },
+	},
+	"atr": {
+		args:     1,
+		lookback: func(args []float64) int { return int(args[0]) + 1 },
+		value:    func(t *TechnicalIndicators, args []float64) float64 { return t.calculateATR(int(args[0])) },
+	},
+}
+
+// 숫자 값 표현식 - shift만큼 최근 가격을 제외한 시점의 값을 계산
+type valueExpr interface {
+	value(t *TechnicalIndicators, shift int) float64
+	lookback() int
+	String() string
+}
+
+// 조건 표현식 - 판정 결과와 함께 비교 조건별 결과를 record로 전달
+type condExpr interface {
+	eval(t *TechnicalIndicators, record func(SignalCondition)) bool
+	lookback() int
+	String() string
+}
+
+type numberExpr struct{ v float64 }
+
+func (e numberExpr) value(t *TechnicalIndicators, shift int) float64 {
+	return e.v
+}
+
+func (e numberExpr) lookback() int {
+	return 0
+}
+
+func (e numberExpr) String() string {
+	return formatRuleNumber(e.v)
+}
+
+type priceExpr struct{ name string }
+
+func (e priceExpr) value(t *TechnicalIndicators, shift int) float64 {
+	if shift >= len(t.Prices) {
+		return math.NaN()
+	}
+	return t.Prices[len(t.Prices)-1-shift]
+}
+
+func (e priceExpr) lookback() int {
+	return 1
+}
+
+func (e priceExpr) String() string {
+	return e.name
+}
+
+type indicatorExpr struct {
+	name      string
+	args      []float64
+	indicator ruleIndicator
+}
+
+func (e indicatorExpr) value(t *TechnicalIndicators, shift int) float64 {
+	// 가격이 부족하면 지표 함수의 0 대신 NaN (조건을 충족하지 않음)
+	if len(t.Prices)-shift < e.lookback() {
+		return math.NaN()
+	}
+	if shift > 0 {
+		t = &TechnicalIndicators{Prices: t.Prices[:len(t.Prices)-shift]}
+	}
+	return e.indicator.value(t, e.args)
+}
+
+func (e indicatorExpr) lookback() int {
+	return e.indicator.lookback(e.args)
+}
+
+func (e indicatorExpr) String() string {
+	args := make([]string, len(e.args))
+	for i, arg := range e.args {
+		args[i] = formatRuleNumber(arg)
+	}
+	return e.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+type arithExpr struct {
+	op          string
+	left, right valueExpr
+}
+
+func (e arithExpr) value(t *TechnicalIndicators, shift int) float64 {
+	l, r := e.left.value(t, shift), e.right.value(t, shift)
+	switch e.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	default:
+		if r == 0 {
+			return math.NaN()
+		}
+		return l / r
+	}
+}
+
+func (e arithExpr) lookback() int {
+	return max(e.left.lookback(), e.right.lookback())
+}
+
+func (e arithExpr) String() string {
+	return "(" + e.left.String() + " " + e.op + " " + e.right.String() + ")"
+}
+
+type negExpr struct{ x valueExpr }
+
+func (e negExpr) value(t *TechnicalIndicators, shift int) float64 {
+	return -e.x.value(t, shift)
+}
+
+func (e negExpr) lookback() int {
+	return e.x.lookback()
+}
+
+func (e negExpr) String() string {
+	return "-" + e.x.String()
+}
+
+type compareExpr struct {
+	op          string
+	left, right valueExpr
+}
+
+func (e compareExpr) eval(t *TechnicalIndicators, record func(SignalCondition)) bool {
+	l, r := e.left.value(t, 0), e.right.value(t, 0)
+	passed := false
+	switch e.op {
+	case "<":
+		passed = l < r
+	case "<=":
+		passed = l <= r
+	case ">":
+		passed = l > r
+	case ">=":
+		passed = l >= r
+	case "==":
+		passed = l == r
+	case "!=":
+		passed = l != r
+	}
+	return recordCondition(record, e.String(), l, r, passed)
+}
+
+func (e compareExpr) lookback() int {
+	return max(e.left.lookback(), e.right.lookback())
+}
+
+func (e compareExpr) String() string {
+	return e.left.String() + " " + e.op + " " + e.right.String()
+}
+
+// 교차 - 직전 가격 시점에는 교차 전이고 현재 교차한 경우
+type crossExpr struct {
+	above       bool
+	left, right valueExpr
+}
+
+func (e crossExpr) eval(t *TechnicalIndicators, record func(SignalCondition)) bool {
+	l, r := e.left.value(t, 0), e.right.value(t, 0)
+	prevL, prevR := e.left.value(t, 1), e.right.value(t, 1)
+	passed := l > r && prevL <= prevR
+	if !e.above {
+		passed = l < r && prevL >= prevR
+	}
+	if math.IsNaN(prevL) || math.IsNaN(prevR) {
+		passed = false
+	}
+	return recordCondition(record, e.String(), l, r, passed)
+}
+
+// 비교 결과 기록 - 계산할 수 없는 값(0으로 나누기, 가격 부족)이 있으면 충족하지 않은 것으로 보고,
+// 판단 근거를 JSON으로 남길 수 있도록 유한하지 않은 값은 0으로 기록한다.
+func recordCondition(record func(SignalCondition), name string, value, threshold float64, passed bool) bool {
+	if math.IsNaN(value) || math.IsNaN(threshold) {
+		passed = false
+	}
+	finite := func(v float64) float64 {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0
+		}
+		return v
+	}
+	record(SignalCondition{Name: name, Value: finite(value), Threshold: finite(threshold), Passed: passed})
+	return passed
+}
+
+func (e crossExpr) lookback() int {
+	return max(e.left.lookback(), e.right.lookback()) + 1
+}
+
+func (e crossExpr) String() string {
+	op := "crosses_above"
+	if !e.above {
+		op = "crosses_below"
+	}
+	return e.left.String() + " " + op + " " + e.right.String()
+}
+
+// and/or/atleast - 판단 근거를 모두 남기기 위해 단락 평가하지 않음
+type logicExpr struct {
+	op    string // and, or, atleast
+	n     int    // atleast: 최소 충족 조건 수
+	items []condExpr
+}
+
+func (e logicExpr) eval(t *TechnicalIndicators, record func(SignalCondition)) bool {
+	passed := 0
+	for _, item := range e.items {
+		if item.eval(t, record) {
+			passed++
+		}
+	}
+	switch e.op {
+	case "and":
+		return passed == len(e.items)
+	case "or":
+		return passed > 0
+	default:
+		return passed >= e.n
+	}
+}
+
+func (e logicExpr) lookback() int {
+	lookback := 0
+	for _, item := range e.items {
+		lookback = max(lookback, item.lookback())
+	}
+	return lookback
+}
+
+func (e logicExpr) String() string {
+	items := make([]string, len(e.items))
+	for i, item := range e.items {
+		items[i] = item.String()
+	}
+	if e.op == "atleast" {
+		return fmt.Sprintf("atleast(%d, %s)", e.n, strings.Join(items, ", "))
+	}
+	return "(" + strings.Join(items, " "+e.op+" ") + ")"
+}
+
+type notExpr struct{ x condExpr }
+
+func (e notExpr) eval(t *TechnicalIndicators, record func(SignalCondition)) bool {
+	return !e.x.eval(t, record)
+}
+
+func (e notExpr) lookback() int {
+	return e.x.lookback()
+}
+
+func (e notExpr) String() string {
+	return "not (" + e.x.String() + ")"
+}
+
+// SignalRule 구조체 - 컴파일된 신호 규칙
+type SignalRule struct {
+	Source string
+	expr   condExpr
+}
+
+// 규칙 문자열 컴파일
+func compileRule(source string) (*SignalRule, error) {
+	tokens, err := tokenizeRule(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &ruleParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != ruleTokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	expr, ok := node.(condExpr)
+	if !ok {
+		return nil, fmt.Errorf("rule must be a condition, got value %s", node)
+	}
+	return &SignalRule{Source: source, expr: expr}, nil
+}
+
+// 규칙 판정 - 비교 조건별 결과에 side(buy, sell)를 붙여 반환
+func (r *SignalRule) evaluate(t *TechnicalIndicators, side string) (bool, []SignalCondition) {
+	var conditions []SignalCondition
+	passed := r.expr.eval(t, func(condition SignalCondition) {
+		condition.Side = side
+		conditions = append(conditions, condition)
+	})
+	return passed, conditions
+}
+
+// 규칙 판정에 필요한 최소 가격 개수
+func (r *SignalRule) minDataPoints() int {
+	return r.expr.lookback()
+}
+
+// 상태 API에는 규칙 문자열로 표시
+func (r *SignalRule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Source)
+}
+
+// 환경 변수의 규칙 컴파일 (없으면 기본 규칙)
+// 규칙이 잘못되었거나 보관하는 분석 이력(maxDataPoints)보다 긴 기간이 필요하면 오류를 반환한다.
+func newSignalRuleFromEnv(key, defaultSource string, maxDataPoints int) (*SignalRule, error) {
+	source := os.Getenv(key)
+	if source == "" {
+		source = defaultSource
+	}
+
+	rule, err := compileRule(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	if points := rule.minDataPoints(); points > maxDataPoints {
+		return nil, fmt.Errorf("%s needs %d data points but only %d are available", key, points, maxDataPoints)
+	}
+	return rule, nil
+}
+
+func formatRuleNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// 규칙 토큰 종류
+const (
+	ruleTokenEOF = iota
+	ruleTokenNumber
+	ruleTokenIdent
+	ruleTokenSymbol
+)
+
+type ruleToken struct {
+	kind int
+	text string
+	num  float64
+	pos  int
+}
+
+// 규칙 문자열을 토큰으로 분리 (식별자는 소문자로 통일)
+func tokenizeRule(source string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start)
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenNumber, text: text, num: num, pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenIdent, text: strings.ToLower(string(runes[start:i])), pos: start})
+		default:
+			start := i
+			text := string(c)
+			if i+1 < len(runes) && strings.Contains("<>=!", text) && runes[i+1] == '=' {
+				text += "="
+			}
+			if !containsString([]string{"(", ")", ",", "+", "-", "*", "/", "<", "<=", ">", ">=", "==", "!="}, text) {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, start)
+			}
+			i += len([]rune(text))
+			tokens = append(tokens, ruleToken{kind: ruleTokenSymbol, text: text, pos: start})
+		}
+	}
+	return append(tokens, ruleToken{kind: ruleTokenEOF, text: "end of rule", pos: len(runes)}), nil
+}
+
+// 재귀 하강 파서 - 각 함수는 valueExpr 또는 condExpr를 반환
+type ruleParser struct {
+	tokens []ruleToken
+	pos    int
+}
+
+func (p *ruleParser) peek() ruleToken {
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) next() ruleToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != ruleTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// 다음 토큰이 기호/키워드 text이면 소비
+func (p *ruleParser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == ruleTokenSymbol || tok.kind == ruleTokenIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *ruleParser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at position %d, got %q", text, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *ruleParser) parseOr() (interface{}, error) {
+	return p.parseLogic("or", p.parseAnd)
+}
+
+func (p *ruleParser) parseAnd() (interface{}, error) {
+	return p.parseLogic("and", p.parseNot)
+}
+
+func (p *ruleParser) parseLogic(op string, operand func() (interface{}, error)) (interface{}, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().text != op {
+		return first, nil
+	}
+
+	items := []condExpr{}
+	node := first
+	for {
+		cond, ok := node.(condExpr)
+		if !ok {
+			return nil, fmt.Errorf("%q needs conditions, got value %s", op, node)
+		}
+		items = append(items, cond)
+		if !p.accept(op) {
+			break
+		}
+		if node, err = operand(); err != nil {
+			return nil, err
+		}
+	}
+	return logicExpr{op: op, items: items}, nil
+}
+
+func (p *ruleParser) parseNot() (interface{}, error) {
+	if !p.accept("not") {
+		return p.parseComparison()
+	}
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	cond, ok := node.(condExpr)
+	if !ok {
+		return nil, fmt.Errorf("\"not\" needs a condition, got value %s", node)
+	}
+	return notExpr{x: cond}, nil
+}
+
+func (p *ruleParser) parseComparison() (interface{}, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek().text
+	switch op {
+	case "<", "<=", ">", ">=", "==", "!=", "crosses_above", "crosses_below":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	l, lok := left.(valueExpr)
+	r, rok := right.(valueExpr)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%q compares values, not conditions", op)
+	}
+
+	switch op {
+	case "crosses_above":
+		return crossExpr{above: true, left: l, right: r}, nil
+	case "crosses_below":
+		return crossExpr{above: false, left: l, right: r}, nil
+	}
+	return compareExpr{op: op, left: l, right: r}, nil
+}
+
+func (p *ruleParser) parseSum() (interface{}, error) {
+	return p.parseArith([]string{"+", "-"}, p.parseProduct)
+}
+
+func (p *ruleParser) parseProduct() (interface{}, error) {
+	return p.parseArith([]string{"*", "/"}, p.parseUnary)
+}
+
+func (p *ruleParser) parseArith(ops []string, operand func() (interface{}, error)) (interface{}, error) {
+	node, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == ruleTokenSymbol && containsString(ops, p.peek().text) {
+		op := p.next().text
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l, lok := node.(valueExpr)
+		r, rok := right.(valueExpr)
+		if !lok || !rok {
+			return nil, fmt.Errorf("%q needs values, not conditions", op)
+		}
+		node = arithExpr{op: op, left: l, right: r}
+	}
+	return node, nil
+}
+
+func (p *ruleParser) parseUnary() (interface{}, error) {
+	if !p.accept("-") {
+		return p.parseAtom()
+	}
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	value, ok := node.(valueExpr)
+	if !ok {
+		return nil, fmt.Errorf("\"-\" needs a value, got condition %s", node)
+	}
+	return negExpr{x: value}, nil
+}
+
+func (p *ruleParser) parseAtom() (interface{}, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == ruleTokenNumber:
+		return numberExpr{v: tok.num}, nil
+	case tok.kind == ruleTokenSymbol && tok.text == "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tok.kind == ruleTokenIdent && (tok.text == "close" || tok.text == "price"):
+		return priceExpr{name: tok.text}, nil
+	case tok.kind == ruleTokenIdent && tok.text == "atleast":
+		return p.parseAtLeast()
+	case tok.kind == ruleTokenIdent:
+		indicator, ok := ruleIndicators[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown indicator %q at position %d", tok.text, tok.pos)
+		}
+		args, err := p.parseArgs(tok)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != indicator.args {
+			return nil, fmt.Errorf("%s takes %d argument(s), got %d", tok.text, indicator.args, len(args))
+		}
+		// 첫 번째 인자는 기간 (양의 정수)
+		if args[0] < 1 || args[0] != math.Trunc(args[0]) {
+			return nil, fmt.Errorf("%s period must be a positive integer, got %s", tok.text, formatRuleNumber(args[0]))
+		}
+		return indicatorExpr{name: tok.text, args: args, indicator: indicator}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+// 지표 인자 (숫자만 허용)
+func (p *ruleParser) parseArgs(name ruleToken) ([]float64, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []float64
+	for {
+		tok := p.next()
+		if tok.kind != ruleTokenNumber {
+			return nil, fmt.Errorf("%s arguments must be numbers, got %q at position %d", name.text, tok.text, tok.pos)
+		}
+		args = append(args, tok.num)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// atleast(n, 조건, 조건, ...) - M개 조건 중 n개 이상 충족
+func (p *ruleParser) parseAtLeast() (interface{}, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	tok := p.next()
+	if tok.kind != ruleTokenNumber || tok.num < 1 || tok.num != math.Trunc(tok.num) {
+		return nil, fmt.Errorf("atleast count must be a positive integer, got %q at position %d", tok.text, tok.pos)
+	}
+
+	expr := logicExpr{op: "atleast", n: int(tok.num)}
+	for p.accept(",") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		cond, ok := node.(condExpr)
+		if !ok {
+			return nil, fmt.Errorf("atleast needs conditions, got value %s", node)
+		}
+		expr.items = append(expr.items, cond)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if expr.n > len(expr.items) {
+		return nil, fmt.Errorf("atleast(%d, ...) has only %d condition(s)", expr.n, len(expr.items))
+	}
+	return expr, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSignalRuleFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		max     int
+		wantErr string
+		want    string // 기대 규칙 문자열 (오류가 없을 때)
+	}{
+		{name: "unset uses default", source: "", max: maxPriceSamples, want: "close < 100"},
+		{name: "valid rule", source: "sma(10) > sma(20)", max: maxPriceSamples, want: "sma(10) > sma(20)"},
+		{name: "syntax error", source: "sma(10) >", max: maxPriceSamples, wantErr: "invalid BUY_RULE"},
+		{name: "unknown indicator", source: "ema(10) > 1", max: maxPriceSamples, wantErr: "invalid BUY_RULE"},
+		{name: "lookback longer than price samples", source: "sma(150) > 1", max: maxPriceSamples, wantErr: "needs 150 data points"},
+		{name: "lookback within candle history", source: "sma(150) > 1", max: maxCandleCount - 1, want: "sma(150) > 1"},
+		{name: "cross needs one more point", source: "close crosses_above sma(199)", max: maxCandleCount - 1, wantErr: "needs 200 data points"},
+		{name: "rsi needs period plus one", source: "rsi(100) < 30", max: maxPriceSamples, wantErr: "needs 101 data points"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BUY_RULE", tt.source)
+			rule, err := newSignalRuleFromEnv("BUY_RULE", "close < 100", tt.max)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rule.Source != tt.want {
+				t.Fatalf("rule = %q, want %q", rule.Source, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTradingBotRejectsInvalidRule(t *testing.T) {
+	t.Setenv("TRADING_MARKET", "KRW-BTC")
+	t.Setenv("SELL_RULE", "sma(500) < close")
+	if _, err := NewTradingBot(Config{AccessKey: "ak", SecretKey: "sk"}); err == nil {
+		t.Fatalf("expected an error for a rule longer than the available history")
+	}
+}
+
+func TestSignalRuleEvaluate(t *testing.T) {
+	rising := []float64{10, 10, 10, 10, 12} // close 12, sma(5) 10.4, sma(4) 10.5
+
+	tests := []struct {
+		name           string
+		rule           string
+		prices         []float64
+		want           bool
+		wantConditions int
+	}{
+		// and이 or보다 먼저 결합: close > 11 or (close < 5 and close > 100)
+		{name: "and binds tighter than or", rule: "close > 11 or close < 5 and close > 100", prices: rising, want: true, wantConditions: 3},
+		{name: "parentheses override precedence", rule: "(close > 11 or close < 5) and close > 100", prices: rising, want: false, wantConditions: 3},
+		// not은 바로 뒤 조건에만 적용: (not close > 11) or close > 5
+		{name: "not binds to the next condition", rule: "not close > 11 or close > 5", prices: rising, want: true, wantConditions: 2},
+		{name: "double not", rule: "not not close > 11", prices: rising, want: true, wantConditions: 1},
+
+		{name: "crosses_above on two bars", rule: "close crosses_above 11", prices: []float64{10, 12}, want: true, wantConditions: 1},
+		{name: "already above is not a cross", rule: "close crosses_above 11", prices: []float64{12, 12}, want: false, wantConditions: 1},
+		{name: "touching then above is a cross", rule: "close crosses_above 11", prices: []float64{11, 12}, want: true, wantConditions: 1},
+		{name: "crosses_below on two bars", rule: "close crosses_below 11", prices: []float64{12, 10}, want: true, wantConditions: 1},
+		{name: "crosses_below needs a move down", rule: "close crosses_below 11", prices: []float64{10, 10}, want: false, wantConditions: 1},
+		{name: "cross against an indicator", rule: "close crosses_above sma(4)", prices: rising, want: true, wantConditions: 1},
+
+		{name: "atleast met", rule: "atleast(2, close > 11, close > 100, sma(5) < 11)", prices: rising, want: true, wantConditions: 3},
+		{name: "atleast not met", rule: "atleast(3, close > 11, close > 100, sma(5) < 11)", prices: rising, want: false, wantConditions: 3},
+		{name: "atleast with nested logic", rule: "atleast(1, close > 100 and close > 11, close < 5 or close > 11)", prices: rising, want: true, wantConditions: 4},
+
+		{name: "subtraction", rule: "close - sma(5) > 1.5", prices: rising, want: true, wantConditions: 1},
+		{name: "multiplication before addition", rule: "close + 2 * 3 == 18", prices: rising, want: true, wantConditions: 1},
+		{name: "left-associative division", rule: "close / 2 / 3 == 2", prices: rising, want: true, wantConditions: 1},
+		{name: "unary minus", rule: "-close < -11", prices: rising, want: true, wantConditions: 1},
+
+		{name: "division by zero fails", rule: "close / (close - 12) > 0", prices: rising, want: false, wantConditions: 1},
+		{name: "division by zero fails not-equal", rule: "close / 0 != 1", prices: rising, want: false, wantConditions: 1},
+		{name: "division by zero fails less-than", rule: "close / 0 < 1", prices: rising, want: false, wantConditions: 1},
+
+		// 가격이 부족하면 지표는 0이 아니라 NaN이므로 충족하지 않음
+		{name: "indicator on a short series", rule: "sma(5) < 100", prices: []float64{10, 12}, want: false, wantConditions: 1},
+		{name: "rsi on a short series", rule: "rsi(14) < 101", prices: rising, want: false, wantConditions: 1},
+		{name: "cross on a single price", rule: "close crosses_above 11", prices: []float64{12}, want: false, wantConditions: 1},
+		{name: "cross without the previous indicator value", rule: "close crosses_above sma(5)", prices: rising, want: false, wantConditions: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := compileRule(tt.rule)
+			if err != nil {
+				t.Fatalf("compileRule(%q) failed: %v", tt.rule, err)
+			}
+			passed, conditions := rule.evaluate(&TechnicalIndicators{Prices: tt.prices}, "buy")
+			if passed != tt.want {
+				t.Fatalf("%s = %t, want %t (conditions: %+v)", tt.rule, passed, tt.want, conditions)
+			}
+			if len(conditions) != tt.wantConditions {
+				t.Fatalf("conditions = %+v, want %d", conditions, tt.wantConditions)
+			}
+			for _, condition := range conditions {
+				if condition.Side != "buy" {
+					t.Fatalf("condition side = %q, want buy", condition.Side)
+				}
+			}
+			// 계산할 수 없는 값이 있어도 판단 근거를 기록할 수 있어야 함
+			if _, err := json.Marshal(conditions); err != nil {
+				t.Fatalf("conditions cannot be encoded: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// 신뢰도 계산 방식
+const (
+	ConfidenceRaw    = "raw"    // 지표 비율의 가중 합 (기존 방식)
+	ConfidenceZScore = "zscore" // 구성 요소별 과거 분포 대비 z-score의 가중 합을 정규분포 CDF로 변환
+)
+
+// z-score 계산에 필요한 최소 과거 값 개수 (부족하면 raw 방식으로 계산)
+const minZScoreHistory = 10
+
+// ConfidenceWeights 구조체 - 신뢰도 구성 요소 가중치
+type ConfidenceWeights struct {
+	MA   float64 `json:"ma"`
+	RSI  float64 `json:"rsi"`
+	Band float64 `json:"band"`
+}
+
+// ConfidenceScorer 구조체 - 신뢰도 계산 설정
+type ConfidenceScorer struct {
+	Mode    string            `json:"mode"`
+	Window  int               `json:"window"` // zscore: 구성 요소 분포를 계산할 과거 가격 시점 수
+	Weights ConfidenceWeights `json:"weights"`
+}
+
+// 환경 변수로 신뢰도 계산 설정 (알 수 없는 방식이면 시작하지 않음)
+func newConfidenceScorerFromEnv() (*ConfidenceScorer, error) {
+	mode := getEnvOrDefault("CONFIDENCE_MODE", ConfidenceZScore)
+	if mode != ConfidenceRaw && mode != ConfidenceZScore {
+		return nil, fmt.Errorf("invalid CONFIDENCE_MODE: %q (expected %s or %s)", mode, ConfidenceRaw, ConfidenceZScore)
+	}
+
+	return &ConfidenceScorer{
+		Mode:   mode,
+		Window: getEnvInt("CONFIDENCE_ZSCORE_WINDOW", 50),
+		Weights: ConfidenceWeights{
+			MA:   getEnvFloat("CONFIDENCE_WEIGHT_MA", 0.4),
+			RSI:  getEnvFloat("CONFIDENCE_WEIGHT_RSI", 0.3),
+			Band: getEnvFloat("CONFIDENCE_WEIGHT_BAND", 0.3),
+		},
+	}, nil
+}
+
+// z-score 계산용 과거 시점까지 포함해 필요한 가격 개수 (캔들 조회 개수에 사용)
+func (ts *TradingStrategy) historyDataPoints() int {
+	points := ts.minDataPoints()
+	if ts.Scorer != nil && ts.Scorer.Mode == ConfidenceZScore && ts.Scorer.Window > 1 {
+		points += ts.Scorer.Window - 1
+	}
+	return points
+}
+
+// 신호 방향 기준 구성 요소 값 (클수록 신호가 강함)
+// buy: 상승 추세 폭, 낮은 RSI, 하단 밴드 아래 이탈 폭 / sell: 하락 추세 폭, 높은 RSI, 상단 밴드 위 이탈 폭
+func (ts *TradingStrategy) confidenceComponents(t *TechnicalIndicators, side string) (ma, rsi, band float64) {
+	shortMA := t.calculateMA(ts.ShortMA)
+	longMA := t.calculateMA(ts.LongMA)
+	_, upperBB, lowerBB := t.calculateBollingerBands(ts.BBPeriod, ts.BBStdDev)
+	price := t.Prices[len(t.Prices)-1]
+	rsi = t.calculateRSI(ts.RSIPeriod)
+
+	if longMA <= 0 || upperBB <= 0 || lowerBB <= 0 {
+		return 0, 0, 0
+	}
+	if side == "buy" {
+		return (shortMA - longMA) / longMA, -rsi, (lowerBB - price) / lowerBB
+	}
+	return (longMA - shortMA) / longMA, rsi, (price - upperBB) / upperBB
+}
+
+// 신호 신뢰도 계산 - zscore 방식은 구성 요소별로 최근 Window개 시점의 분포 대비 현재 값의 z-score를 구해
+// 가중 합을 가중치 크기로 나눈 뒤(독립 표준정규 가정) 정규분포 CDF로 0~1 값으로 변환한다.
+func (ts *TradingStrategy) scoreConfidence(t *TechnicalIndicators, side string) ConfidenceBreakdown {
+	scorer := ts.Scorer
+	if scorer == nil {
+		scorer = &ConfidenceScorer{Mode: ConfidenceRaw, Weights: ConfidenceWeights{MA: 0.4, RSI: 0.3, Band: 0.3}}
+	}
+
+	shortMA := t.calculateMA(ts.ShortMA)
+	longMA := t.calculateMA(ts.LongMA)
+	rsi := t.calculateRSI(ts.RSIPeriod)
+	_, upperBB, lowerBB := t.calculateBollingerBands(ts.BBPeriod, ts.BBStdDev)
+	price := t.Prices[len(t.Prices)-1]
+	band := lowerBB
+	if side == "sell" {
+		band = upperBB
+	}
+	raw := calculateConfidence(shortMA, longMA, rsi, price, band, scorer.Weights)
+	if scorer.Mode != ConfidenceZScore {
+		return raw
+	}
+
+	// 과거 시점별 구성 요소 값 (shift 0이 현재)
+	lookback := max(max(ts.LongMA, ts.BBPeriod), ts.RSIPeriod+1)
+	history := min(scorer.Window, len(t.Prices)-lookback+1)
+	if history < minZScoreHistory {
+		return raw
+	}
+	maValues := make([]float64, history)
+	rsiValues := make([]float64, history)
+	bandValues := make([]float64, history)
+	for shift := 0; shift < history; shift++ {
+		view := &TechnicalIndicators{Prices: t.Prices[:len(t.Prices)-shift]}
+		maValues[shift], rsiValues[shift], bandValues[shift] = ts.confidenceComponents(view, side)
+	}
+
+	w := scorer.Weights
+	breakdown := ConfidenceBreakdown{
+		Mode:         ConfidenceZScore,
+		History:      history,
+		MAStrength:   maValues[0],
+		RSIStrength:  rsiValues[0],
+		BandStrength: bandValues[0],
+		MAZScore:     zScore(maValues),
+		RSIZScore:    zScore(rsiValues),
+		BandZScore:   zScore(bandValues),
+	}
+	breakdown.MAComponent = w.MA * breakdown.MAZScore
+	breakdown.RSIComponent = w.RSI * breakdown.RSIZScore
+	breakdown.BandComponent = w.Band * breakdown.BandZScore
+
+	norm := math.Sqrt(w.MA*w.MA + w.RSI*w.RSI + w.Band*w.Band)
+	if norm > 0 {
+		combined := (breakdown.MAComponent + breakdown.RSIComponent + breakdown.BandComponent) / norm
+		breakdown.Total = normalCDF(combined)
+	}
+	return breakdown
+}
+
+// 첫 번째 값의 z-score (표준편차가 0이면 0)
+func zScore(values []float64) float64 {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	sd := math.Sqrt(variance / float64(len(values)))
+	if sd == 0 {
+		return 0
+	}
+	return (values[0] - mean) / sd
+}
+
+// 표준정규분포 누적분포함수
+func normalCDF(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
+
+// CalibrationBucket 구조체 - 신뢰도 구간별 적중률
+type CalibrationBucket struct {
+	Min           float64 `json:"min"`
+	Max           float64 `json:"max"`
+	Count         int     `json:"count"`
+	Hits          int     `json:"hits"`
+	HitRate       float64 `json:"hit_rate"`
+	AvgConfidence float64 `json:"avg_confidence"`
+	AvgReturn     float64 `json:"avg_return"` // 신호 방향 기준 평균 수익률 (매도는 하락이 양수)
+}
+
+// CalibrationReport 구조체 - 신뢰도 보정 보고서
+type CalibrationReport struct {
+	Horizon    string              `json:"horizon"`    // 결과 판정 시간
+	Samples    int                 `json:"samples"`    // 결과가 확인된 신호 수
+	Unresolved int                 `json:"unresolved"` // 판정 시간이 지나지 않았거나 이후 가격이 없는 신호 수
+	HitRate    float64             `json:"hit_rate"`
+	Brier      float64             `json:"brier"` // (신뢰도 - 적중 여부)^2 평균, 낮을수록 잘 보정됨
+	Buckets    []CalibrationBucket `json:"buckets"`
+}
+
+// 신호 기록으로 신뢰도 보정 보고서 생성
+// 매수/매도 신호마다 horizon 이후 처음 기록된 같은 마켓의 가격과 비교해, 신호 방향으로 움직였으면 적중으로 본다.
+// 백테스트 결과도 같은 JSON Lines 형식(신호 판단 근거)으로 기록하면 그대로 사용할 수 있다.
+func calibrate(explanations []SignalExplanation, market string, horizon time.Duration, buckets int) CalibrationReport {
+	if buckets <= 0 {
+		buckets = 5
+	}
+	report := CalibrationReport{Horizon: horizon.String(), Buckets: make([]CalibrationBucket, buckets)}
+	for i := range report.Buckets {
+		report.Buckets[i].Min = float64(i) / float64(buckets)
+		report.Buckets[i].Max = float64(i+1) / float64(buckets)
+	}
+
+	// 마켓별 시간순 정렬
+	byMarket := make(map[string][]SignalExplanation)
+	for _, explanation := range explanations {
+		if market != "" && explanation.Market != market {
+			continue
+		}
+		byMarket[explanation.Market] = append(byMarket[explanation.Market], explanation)
+	}
+
+	hits := 0
+	for _, series := range byMarket {
+		sort.SliceStable(series, func(i, j int) bool { return series[i].Time.Before(series[j].Time) })
+		for i, explanation := range series {
+			if explanation.Confidence == nil || (explanation.Signal != "buy" && explanation.Signal != "sell") {
+				continue
+			}
+			if explanation.Inputs.Price <= 0 {
+				continue
+			}
+
+			// horizon 이후 처음 기록된 가격
+			exitTime := explanation.Time.Add(horizon)
+			exitPrice := 0.0
+			start := i + 1 + sort.Search(len(series)-i-1, func(j int) bool { return !series[i+1+j].Time.Before(exitTime) })
+			for _, later := range series[start:] {
+				if later.Inputs.Price > 0 {
+					exitPrice = later.Inputs.Price
+					break
+				}
+			}
+			if exitPrice <= 0 {
+				report.Unresolved++
+				continue
+			}
+
+			ret := (exitPrice - explanation.Inputs.Price) / explanation.Inputs.Price
+			if explanation.Signal == "sell" {
+				ret = -ret
+			}
+			hit := ret > 0
+
+			confidence := math.Max(0, math.Min(1, explanation.Confidence.Total))
+			index := int(confidence * float64(buckets))
+			if index >= buckets {
+				index = buckets - 1
+			}
+			bucket := &report.Buckets[index]
+			bucket.Count++
+			bucket.AvgConfidence += confidence
+			bucket.AvgReturn += ret
+
+			outcome := 0.0
+			if hit {
+				outcome = 1
+				bucket.Hits++
+				hits++
+			}
+			report.Brier += (confidence - outcome) * (confidence - outcome)
+			report.Samples++
+		}
+	}
+
+	for i := range report.Buckets {
+		bucket := &report.Buckets[i]
+		if bucket.Count > 0 {
+			bucket.HitRate = float64(bucket.Hits) / float64(bucket.Count)
+			bucket.AvgConfidence /= float64(bucket.Count)
+			bucket.AvgReturn /= float64(bucket.Count)
+		}
+	}
+	if report.Samples > 0 {
+		report.HitRate = float64(hits) / float64(report.Samples)
+		report.Brier /= float64(report.Samples)
+	}
+	return report
+}
+
+// JSON Lines 형식의 신호 판단 근거 읽기 (잘린 줄은 무시)
+func readSignalExplanations(r io.Reader) ([]SignalExplanation, error) {
+	var explanations []SignalExplanation
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var explanation SignalExplanation
+		if err := json.Unmarshal(scanner.Bytes(), &explanation); err != nil {
+			continue
+		}
+		explanations = append(explanations, explanation)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read signal explanations: %v", err)
+	}
+	return explanations, nil
+}
+
+// 신호 기록 파일 전체 읽기 (메모리에 유지하는 최근 기록보다 긴 기간 보정에 사용)
+func (l *SignalLog) readAll() ([]SignalExplanation, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signal log: %v", err)
+	}
+	defer file.Close()
+
+	return readSignalExplanations(file)
+}
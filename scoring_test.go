@@ -0,0 +1,178 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestZScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"zero variance", []float64{5, 5, 5, 5}, 0},
+		{"single value", []float64{3}, 0},
+		{"above the mean", []float64{3, 1, 2}, 1 / math.Sqrt(2.0/3)},
+		{"below the mean", []float64{1, 3, 2}, -1 / math.Sqrt(2.0/3)},
+		{"at the mean", []float64{2, 1, 3}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := zScore(tt.values); !approxEqual(got, tt.want) {
+				t.Fatalf("zScore(%v) = %f, want %f", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalCDF(t *testing.T) {
+	for _, tt := range []struct{ x, want float64 }{{0, 0.5}, {1.959964, 0.975}, {-1.959964, 0.025}} {
+		if got := normalCDF(tt.x); math.Abs(got-tt.want) > 1e-6 {
+			t.Fatalf("normalCDF(%f) = %f, want %f", tt.x, got, tt.want)
+		}
+	}
+}
+
+func newScoringStrategy(mode string, window int) *TradingStrategy {
+	return &TradingStrategy{
+		ShortMA:   10,
+		LongMA:    20,
+		RSIPeriod: 14,
+		BBPeriod:  20,
+		BBStdDev:  2.0,
+		Scorer: &ConfidenceScorer{
+			Mode:    mode,
+			Window:  window,
+			Weights: ConfidenceWeights{MA: 0.4, RSI: 0.3, Band: 0.3},
+		},
+	}
+}
+
+func TestScoreConfidenceZScore(t *testing.T) {
+	// 변동이 없으면 모든 구성 요소의 z-score가 0이므로 신뢰도 0.5
+	t.Run("zero variance", func(t *testing.T) {
+		ts := newScoringStrategy(ConfidenceZScore, 30)
+		breakdown := ts.scoreConfidence(&TechnicalIndicators{Prices: flatPrices(100000, 60)}, "buy")
+		if breakdown.Mode != ConfidenceZScore || breakdown.History != 30 {
+			t.Fatalf("breakdown = %+v, want zscore over 30 points", breakdown)
+		}
+		if breakdown.MAZScore != 0 || breakdown.RSIZScore != 0 || breakdown.BandZScore != 0 || !approxEqual(breakdown.Total, 0.5) {
+			t.Fatalf("breakdown = %+v, want zero z-scores and total 0.5", breakdown)
+		}
+	})
+
+	// 과거 시점이 minZScoreHistory보다 적으면 raw 방식
+	t.Run("short history falls back to raw", func(t *testing.T) {
+		ts := newScoringStrategy(ConfidenceZScore, 50)
+		prices := flatPrices(100000, 20+minZScoreHistory-2) // 장기 지표 20개 + 과거 시점 9개
+		breakdown := ts.scoreConfidence(&TechnicalIndicators{Prices: prices}, "buy")
+		if breakdown.Mode == ConfidenceZScore {
+			t.Fatalf("breakdown = %+v, want the raw fallback", breakdown)
+		}
+	})
+
+	// 마지막에 급락하면 매수 방향 밴드 이탈은 과거보다 크고 매도 방향은 작음
+	t.Run("direction of a sharp drop", func(t *testing.T) {
+		ts := newScoringStrategy(ConfidenceZScore, 30)
+		prices := flatPrices(100000, 59)
+		for i := range prices {
+			prices[i] += float64(i%3) * 100
+		}
+		prices = append(prices, 90000)
+
+		buy := ts.scoreConfidence(&TechnicalIndicators{Prices: prices}, "buy")
+		sell := ts.scoreConfidence(&TechnicalIndicators{Prices: prices}, "sell")
+		if buy.BandZScore <= 0 || sell.BandZScore >= 0 {
+			t.Fatalf("band z-scores = %f (buy), %f (sell), want positive and negative", buy.BandZScore, sell.BandZScore)
+		}
+		if buy.Total <= 0.5 || buy.Total >= 1 {
+			t.Fatalf("buy confidence = %f, want above 0.5", buy.Total)
+		}
+		combined := (buy.MAComponent + buy.RSIComponent + buy.BandComponent) / math.Sqrt(0.4*0.4+0.3*0.3+0.3*0.3)
+		if !approxEqual(buy.Total, normalCDF(combined)) {
+			t.Fatalf("total = %f, want CDF of the normalized weighted sum %f", buy.Total, normalCDF(combined))
+		}
+	})
+
+	t.Run("raw mode", func(t *testing.T) {
+		ts := newScoringStrategy(ConfidenceRaw, 30)
+		breakdown := ts.scoreConfidence(&TechnicalIndicators{Prices: flatPrices(100000, 60)}, "buy")
+		if breakdown.Mode == ConfidenceZScore || breakdown.History != 0 {
+			t.Fatalf("breakdown = %+v, want raw", breakdown)
+		}
+	})
+}
+
+func TestNewTradingBotRejectsUnknownConfidenceMode(t *testing.T) {
+	t.Setenv("TRADING_MARKET", "KRW-BTC")
+	t.Setenv("CONFIDENCE_MODE", "z-score")
+	if _, err := NewTradingBot(Config{AccessKey: "ak", SecretKey: "sk"}); err == nil {
+		t.Fatalf("expected an error for an unknown CONFIDENCE_MODE")
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	signal := func(minute int, market, side string, price float64, confidence float64) SignalExplanation {
+		explanation := SignalExplanation{
+			Time:   base.Add(time.Duration(minute) * time.Minute),
+			Market: market,
+			Signal: side,
+			Inputs: SignalInputs{Price: price},
+		}
+		if side != "hold" {
+			explanation.Confidence = &ConfidenceBreakdown{Total: confidence}
+		}
+		return explanation
+	}
+
+	explanations := []SignalExplanation{
+		signal(0, "KRW-BTC", "buy", 100, 0.9),  // 2분 뒤 110: 적중
+		signal(1, "KRW-BTC", "hold", 105, 0),   // 가격만 사용
+		signal(2, "KRW-BTC", "sell", 110, 0.2), // 2분 뒤 120: 실패
+		signal(3, "KRW-BTC", "buy", 100, 1.0),  // 2분 뒤 130: 적중 (마지막 구간)
+		signal(4, "KRW-BTC", "hold", 120, 0),
+		signal(5, "KRW-BTC", "buy", 130, 0.0), // 이후 가격 없음
+		signal(0, "KRW-ETH", "buy", 100, 0.5), // 다른 마켓
+		signal(3, "KRW-ETH", "hold", 90, 0),
+	}
+
+	report := calibrate(explanations, "KRW-BTC", 2*time.Minute, 5)
+	if report.Samples != 3 || report.Unresolved != 1 {
+		t.Fatalf("samples = %d, unresolved = %d, want 3 and 1", report.Samples, report.Unresolved)
+	}
+	if !approxEqual(report.HitRate, 2.0/3) {
+		t.Fatalf("hit rate = %f, want 2/3", report.HitRate)
+	}
+	// ((0.9-1)^2 + (0.2-0)^2 + (1-1)^2) / 3
+	if !approxEqual(report.Brier, 0.05/3) {
+		t.Fatalf("brier = %f, want %f", report.Brier, 0.05/3)
+	}
+
+	// 구간 경계: 0.2는 [0.2, 0.4), 1.0은 마지막 구간
+	if len(report.Buckets) != 5 || !approxEqual(report.Buckets[1].Min, 0.2) || !approxEqual(report.Buckets[4].Max, 1) {
+		t.Fatalf("buckets = %+v", report.Buckets)
+	}
+	low := report.Buckets[1]
+	if low.Count != 1 || low.Hits != 0 || !approxEqual(low.AvgReturn, -10.0/110) {
+		t.Fatalf("0.2 bucket = %+v, want one missed sell", low)
+	}
+	high := report.Buckets[4]
+	if high.Count != 2 || high.Hits != 2 || !approxEqual(high.HitRate, 1) || !approxEqual(high.AvgConfidence, 0.95) {
+		t.Fatalf("top bucket = %+v, want two hits averaging 0.95", high)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if report.Buckets[i].Count != 0 {
+			t.Fatalf("bucket %d = %+v, want empty", i, report.Buckets[i])
+		}
+	}
+
+	// 마켓을 지정하지 않으면 모든 마켓 (ETH 매수는 90으로 하락해 실패)
+	all := calibrate(explanations, "", 2*time.Minute, 0)
+	if all.Samples != 4 || len(all.Buckets) != 5 || all.Buckets[2].Count != 1 || all.Buckets[2].Hits != 0 {
+		t.Fatalf("all markets = %+v, want the ETH miss in the middle bucket", all)
+	}
+}
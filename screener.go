@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 한 번의 시세 조회에 포함할 최대 마켓 수
+const quotationBatchSize = 100
+
+// Ticker 구조체 - 업비트 현재가 응답
+type Ticker struct {
+	Market            string  `json:"market"`
+	TradePrice        float64 `json:"trade_price"`
+	HighPrice         float64 `json:"high_price"`
+	LowPrice          float64 `json:"low_price"`
+	PrevClosingPrice  float64 `json:"prev_closing_price"`
+	SignedChangeRate  float64 `json:"signed_change_rate"`
+	AccTradePrice24h  float64 `json:"acc_trade_price_24h"`
+	AccTradeVolume24h float64 `json:"acc_trade_volume_24h"`
+}
+
+// MarketScore 구조체 - 스크리너 순위 항목
+type MarketScore struct {
+	Rank             int     `json:"rank"`
+	Market           string  `json:"market"`
+	KoreanName       string  `json:"korean_name"`
+	EnglishName      string  `json:"english_name"`
+	TradePrice       float64 `json:"trade_price"`
+	AccTradePrice24h float64 `json:"acc_trade_price_24h"` // 24시간 누적 거래대금 (KRW)
+	Volatility       float64 `json:"volatility"`          // (고가 - 저가) / 전일 종가
+	SpreadBps        float64 `json:"spread_bps"`          // 최우선 호가 스프레드 (bp)
+	Score            float64 `json:"score"`
+}
+
+// ScreenerResult 구조체 - 스크리닝 결과
+type ScreenerResult struct {
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Candidates int           `json:"candidates"` // 경고/주의 종목을 제외한 KRW 마켓 수
+	Markets    []MarketScore `json:"markets"`    // 거래대금/스프레드 조건을 통과한 마켓 (점수순)
+	Selected   []string      `json:"selected"`   // 자동 선택된 거래 대상 마켓
+}
+
+// MarketScreener 구조체 - KRW 마켓을 주기적으로 평가해 순위 산출
+type MarketScreener struct {
+	Interval     time.Duration // 갱신 주기 (0이면 요청 시에만 갱신)
+	TopN         int           // 자동 선택할 상위 마켓 수
+	MinVolume    float64       // 최소 24시간 거래대금 (KRW)
+	MaxSpreadBps float64       // 최대 허용 스프레드 (bp)
+	AutoSelect   bool          // 상위 마켓을 거래 대상으로 자동 반영
+
+	// 점수 가중치 (각 지표의 순위 백분위에 곱함)
+	VolumeWeight     float64
+	VolatilityWeight float64
+	SpreadWeight     float64
+
+	mu     sync.RWMutex
+	result *ScreenerResult
+}
+
+// 환경 변수로 스크리너 설정
+func newMarketScreenerFromEnv() *MarketScreener {
+	return &MarketScreener{
+		Interval:         time.Duration(getEnvInt("SCREENER_INTERVAL_MINUTES", 15)) * time.Minute,
+		TopN:             getEnvInt("SCREENER_TOP_N", 3),
+		MinVolume:        getEnvFloat("SCREENER_MIN_VOLUME_KRW", 1000000000),
+		MaxSpreadBps:     getEnvFloat("SCREENER_MAX_SPREAD_BPS", 20),
+		AutoSelect:       os.Getenv("SCREENER_AUTO_SELECT") == "true",
+		VolumeWeight:     getEnvFloat("SCREENER_WEIGHT_VOLUME", 0.5),
+		VolatilityWeight: getEnvFloat("SCREENER_WEIGHT_VOLATILITY", 0.3),
+		SpreadWeight:     getEnvFloat("SCREENER_WEIGHT_SPREAD", 0.2),
+	}
+}
+
+// 마지막 스크리닝 결과
+func (s *MarketScreener) latest() *ScreenerResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.result
+}
+
+// 주기적 스크리닝 - ctx가 취소될 때까지 실행
+func (s *MarketScreener) run(ctx context.Context, bot *TradingBot) {
+	if s.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.refresh(ctx, bot); err != nil && ctx.Err() == nil {
+			bot.logger.Error("Market screener failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 전체 KRW 마켓 평가 후 결과 저장 (AutoSelect이면 거래 대상 마켓 갱신)
+func (s *MarketScreener) refresh(ctx context.Context, bot *TradingBot) (*ScreenerResult, error) {
+	// 1. 경고/주의 종목을 제외한 KRW 마켓
+	markets, err := bot.fetchMarkets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch markets: %v", err)
+	}
+	details := make(map[string]Market)
+	listed := make(map[string]bool)
+	codes := make([]string, 0, len(markets))
+	for _, market := range markets {
+		if strings.HasPrefix(market.Market, "KRW-") {
+			listed[market.Market] = true
+		}
+		if !strings.HasPrefix(market.Market, "KRW-") || !isMarketSafe(market) {
+			continue
+		}
+		details[market.Market] = market
+		codes = append(codes, market.Market)
+	}
+
+	// 2. 거래대금과 변동성
+	tickers, err := bot.fetchTickers(ctx, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tickers: %v", err)
+	}
+	liquid := make([]string, 0, len(tickers))
+	byMarket := make(map[string]Ticker, len(tickers))
+	for _, ticker := range tickers {
+		if ticker.AccTradePrice24h < s.MinVolume {
+			continue
+		}
+		byMarket[ticker.Market] = ticker
+		liquid = append(liquid, ticker.Market)
+	}
+
+	// 3. 스프레드
+	orderbooks, err := bot.fetchOrderbooks(ctx, liquid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch orderbooks: %v", err)
+	}
+	scores := make([]MarketScore, 0, len(orderbooks))
+	for _, orderbook := range orderbooks {
+		spread := orderbook.spreadBps()
+		if spread < 0 || spread > s.MaxSpreadBps {
+			continue
+		}
+		ticker := byMarket[orderbook.Market]
+		volatility := 0.0
+		if ticker.PrevClosingPrice > 0 {
+			volatility = (ticker.HighPrice - ticker.LowPrice) / ticker.PrevClosingPrice
+		}
+		detail := details[orderbook.Market]
+		scores = append(scores, MarketScore{
+			Market:           orderbook.Market,
+			KoreanName:       detail.KoreanName,
+			EnglishName:      detail.EnglishName,
+			TradePrice:       ticker.TradePrice,
+			AccTradePrice24h: ticker.AccTradePrice24h,
+			Volatility:       volatility,
+			SpreadBps:        spread,
+		})
+	}
+
+	// 4. 순위 산출
+	s.score(scores)
+
+	result := &ScreenerResult{
+		UpdatedAt:  time.Now(),
+		Candidates: len(codes),
+		Markets:    scores,
+	}
+	if s.AutoSelect {
+		result.Selected = s.selectMarkets(ctx, bot, scores, listed)
+	}
+
+	s.mu.Lock()
+	s.result = result
+	s.mu.Unlock()
+
+	bot.logger.Info("Market screener ranked %d of %d KRW markets", len(scores), len(codes))
+	return result, nil
+}
+
+// 지표별 순위 백분위에 가중치를 곱해 점수 계산 (거래대금/변동성은 클수록, 스프레드는 작을수록 유리)
+func (s *MarketScreener) score(scores []MarketScore) {
+	if len(scores) == 0 {
+		return
+	}
+
+	percentile := func(less func(a, b MarketScore) bool) map[string]float64 {
+		ordered := append([]MarketScore(nil), scores...)
+		sort.SliceStable(ordered, func(i, j int) bool { return less(ordered[i], ordered[j]) })
+		ranks := make(map[string]float64, len(ordered))
+		for start := 0; start < len(ordered); {
+			// 값이 같은 마켓은 같은 백분위 (평균 순위)
+			end := start + 1
+			for end < len(ordered) && !less(ordered[start], ordered[end]) {
+				end++
+			}
+			rank := 1.0
+			if len(ordered) > 1 {
+				rank = float64(start+end-1) / 2 / float64(len(ordered)-1)
+			}
+			for _, score := range ordered[start:end] {
+				ranks[score.Market] = rank
+			}
+			start = end
+		}
+		return ranks
+	}
+	volume := percentile(func(a, b MarketScore) bool { return a.AccTradePrice24h < b.AccTradePrice24h })
+	volatility := percentile(func(a, b MarketScore) bool { return a.Volatility < b.Volatility })
+	spread := percentile(func(a, b MarketScore) bool { return a.SpreadBps > b.SpreadBps })
+
+	for i := range scores {
+		market := scores[i].Market
+		scores[i].Score = s.VolumeWeight*volume[market] +
+			s.VolatilityWeight*volatility[market] +
+			s.SpreadWeight*spread[market]
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+}
+
+// 상위 마켓을 거래 대상으로 반영
+// 순위에서 빠졌더라도 보유 중인 마켓(거래소 잔고 기준)은 청산할 수 있도록, 리밸런싱 목표 마켓은 비중을 유지할 수 있도록 유지한다.
+func (s *MarketScreener) selectMarkets(ctx context.Context, bot *TradingBot, scores []MarketScore, listed map[string]bool) []string {
+	selected := make([]string, 0, s.TopN)
+	for _, score := range scores {
+		if len(selected) >= s.TopN {
+			break
+		}
+		selected = append(selected, score.Market)
+	}
+
+	kept := bot.heldMarkets(ctx)
+	if bot.rebalancer.enabled() {
+		kept = append(kept, bot.rebalancer.markets()...)
+	}
+	for _, market := range kept {
+		if listed[market] && !containsString(selected, market) {
+			selected = append(selected, market)
+		}
+	}
+
+	if len(selected) == 0 {
+		bot.logger.Info("Market screener found no eligible markets, keeping current markets")
+		return bot.activeMarkets()
+	}
+
+	bot.setActiveMarkets(selected)
+	bot.logger.Info("Active trading markets updated by screener: %s", strings.Join(selected, ","))
+	return selected
+}
+
+// 현재가 일괄 조회
+func (bot *TradingBot) fetchTickers(ctx context.Context, markets []string) ([]Ticker, error) {
+	tickers := make([]Ticker, 0, len(markets))
+	for start := 0; start < len(markets); start += quotationBatchSize {
+		end := start + quotationBatchSize
+		if end > len(markets) {
+			end = len(markets)
+		}
+
+		var batch []Ticker
+		if err := bot.getQuotation(ctx, "/v1/ticker", markets[start:end], &batch); err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, batch...)
+	}
+	return tickers, nil
+}
+
+// 마켓 목록 기준 시세 API 조회
+func (bot *TradingBot) getQuotation(ctx context.Context, path string, markets []string, out interface{}) error {
+	if len(markets) == 0 {
+		return nil
+	}
+
+	values := url.Values{}
+	values.Set("markets", strings.Join(markets, ","))
+	return bot.getPublic(ctx, path, values, out)
+}
+
+// 시세 API GET 요청 (인증 불필요)
+func (bot *TradingBot) getPublic(ctx context.Context, path string, values url.Values, out interface{}) error {
+	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL") + path + "?" + values.Encode()
+
+	client := &http.Client{Timeout: upbitRequestTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", apiUrl, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API returned non-200 status: %d, body: %s",
+			resp.StatusCode, string(bodyBytes))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// 문자열 포함 여부
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
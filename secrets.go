@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 키 저장소 파일 형식 버전 및 키 유도 방식
+const (
+	keystoreVersion  = 1
+	keystoreKDFNone  = "none"          // 키 파일의 32바이트 키를 그대로 사용
+	keystoreKDFPBKDF = "pbkdf2-sha256" // 패스프레이즈에서 키 유도
+)
+
+// UpbitCredentials 구조체 - 업비트 API 키
+type UpbitCredentials struct {
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// SecretsProvider 인터페이스 - 업비트 API 키 공급자
+type SecretsProvider interface {
+	Name() string
+	Load() (*UpbitCredentials, error)
+}
+
+// 환경 변수 공급자 (기존 .env 방식)
+type envSecretsProvider struct{}
+
+func (p *envSecretsProvider) Name() string { return "env" }
+
+func (p *envSecretsProvider) Load() (*UpbitCredentials, error) {
+	return &UpbitCredentials{
+		AccessKey: os.Getenv("UPBIT_OPEN_API_ACCESS_KEY"),
+		SecretKey: os.Getenv("UPBIT_OPEN_API_SECRET_KEY"),
+	}, nil
+}
+
+// 파일 마운트 공급자 (Docker/Kubernetes secrets)
+type fileSecretsProvider struct {
+	AccessKeyFile string
+	SecretKeyFile string
+}
+
+func (p *fileSecretsProvider) Name() string { return "file" }
+
+func (p *fileSecretsProvider) Load() (*UpbitCredentials, error) {
+	accessKey, err := readSecretFile(p.AccessKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := readSecretFile(p.SecretKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &UpbitCredentials{AccessKey: accessKey, SecretKey: secretKey}, nil
+}
+
+// 암호화 키 저장소 공급자 (AES-256-GCM)
+type keystoreSecretsProvider struct {
+	Path       string
+	Passphrase string // 패스프레이즈 (KeyFile이 없을 때 사용)
+	KeyFile    string // 32바이트 키 파일 (raw, hex, base64)
+}
+
+func (p *keystoreSecretsProvider) Name() string { return "keystore" }
+
+func (p *keystoreSecretsProvider) Load() (*UpbitCredentials, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	var ks keystoreFile
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore: %v", err)
+	}
+	if ks.Version != keystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version: %d", ks.Version)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(ks.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore salt: %v", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(ks.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore nonce: %v", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ks.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore ciphertext: %v", err)
+	}
+
+	key, err := p.key(ks.KDF, salt, ks.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(ks.KDF))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore: wrong passphrase or key")
+	}
+
+	var creds UpbitCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore contents: %v", err)
+	}
+	return &creds, nil
+}
+
+// 업비트 API 키를 암호화해 키 저장소 파일로 저장
+func (p *keystoreSecretsProvider) Save(creds UpbitCredentials) error {
+	ks := keystoreFile{Version: keystoreVersion, KDF: keystoreKDFNone}
+	salt := make([]byte, 16)
+	if p.KeyFile == "" {
+		ks.KDF = keystoreKDFPBKDF
+		ks.Iterations = passwordHashIterations
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %v", err)
+		}
+	}
+
+	key, err := p.key(ks.KDF, salt, ks.Iterations)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	ks.Salt = base64.StdEncoding.EncodeToString(salt)
+	ks.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	ks.Ciphertext = base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, []byte(ks.KDF)))
+
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %v", err)
+	}
+	return os.WriteFile(p.Path, data, 0600)
+}
+
+// 키 유도 방식에 따라 AES-256 키 생성
+func (p *keystoreSecretsProvider) key(kdf string, salt []byte, iterations int) ([]byte, error) {
+	switch kdf {
+	case keystoreKDFNone:
+		if p.KeyFile == "" {
+			return nil, fmt.Errorf("keystore requires KEYSTORE_KEY_FILE")
+		}
+		return readKeyFile(p.KeyFile)
+	case keystoreKDFPBKDF:
+		if p.Passphrase == "" {
+			return nil, fmt.Errorf("keystore requires a passphrase")
+		}
+		if iterations <= 0 {
+			return nil, fmt.Errorf("invalid keystore iterations: %d", iterations)
+		}
+		return pbkdf2.Key([]byte(p.Passphrase), salt, iterations, 32, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupported keystore kdf: %s", kdf)
+	}
+}
+
+// 키 저장소 파일 구조
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// 키 파일 읽기 - 32바이트 raw, 64자 hex, base64 인코딩 지원
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %v", err)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key file must contain a 32-byte key (raw, hex or base64)")
+}
+
+// 마운트된 시크릿 파일 읽기 (끝의 개행 제거)
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %v", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// 환경 설정으로 공급자 선택
+// SECRETS_PROVIDER가 없으면 KEYSTORE_PATH, UPBIT_*_KEY_FILE, 환경 변수 순으로 결정한다.
+func newSecretsProviderFromEnv() (SecretsProvider, error) {
+	name := os.Getenv("SECRETS_PROVIDER")
+	if name == "" {
+		switch {
+		case os.Getenv("KEYSTORE_PATH") != "":
+			name = "keystore"
+		case os.Getenv("UPBIT_OPEN_API_SECRET_KEY_FILE") != "":
+			name = "file"
+		default:
+			name = "env"
+		}
+	}
+
+	switch name {
+	case "env":
+		return &envSecretsProvider{}, nil
+	case "file":
+		return &fileSecretsProvider{
+			AccessKeyFile: getEnvOrDefault("UPBIT_OPEN_API_ACCESS_KEY_FILE", "/run/secrets/upbit_access_key"),
+			SecretKeyFile: getEnvOrDefault("UPBIT_OPEN_API_SECRET_KEY_FILE", "/run/secrets/upbit_secret_key"),
+		}, nil
+	case "keystore":
+		provider := &keystoreSecretsProvider{
+			Path:       getEnvOrDefault("KEYSTORE_PATH", "/app/secrets/keystore.json"),
+			Passphrase: os.Getenv("KEYSTORE_PASSPHRASE"),
+			KeyFile:    os.Getenv("KEYSTORE_KEY_FILE"),
+		}
+		if file := os.Getenv("KEYSTORE_PASSPHRASE_FILE"); file != "" {
+			passphrase, err := readSecretFile(file)
+			if err != nil {
+				return nil, err
+			}
+			provider.Passphrase = passphrase
+		}
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets provider: %s", name)
+	}
+}
+
+// 로드 후 비밀 값이 담긴 환경 변수 제거 (이후에는 메모리에만 보관)
+func clearSecretEnv() {
+	for _, key := range []string{
+		"UPBIT_OPEN_API_ACCESS_KEY",
+		"UPBIT_OPEN_API_SECRET_KEY",
+		"KEYSTORE_PASSPHRASE",
+		"API_JWT_SECRET",
+	} {
+		os.Unsetenv(key)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testCredentials = UpbitCredentials{AccessKey: "access-key", SecretKey: "secret-key"}
+
+// 32바이트 키를 지정한 형식으로 키 파일에 저장
+func writeTestKeyFile(t *testing.T, encode func([]byte) []byte) string {
+	t.Helper()
+	key := bytes.Repeat([]byte{0x42}, 32)
+	path := filepath.Join(t.TempDir(), "keystore.key")
+	if err := os.WriteFile(path, encode(key), 0600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+	return path
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider func(t *testing.T) *keystoreSecretsProvider
+		wantKDF  string
+	}{
+		{
+			name: "passphrase",
+			provider: func(t *testing.T) *keystoreSecretsProvider {
+				return &keystoreSecretsProvider{Passphrase: "correct horse battery staple"}
+			},
+			wantKDF: keystoreKDFPBKDF,
+		},
+		{
+			name: "raw key file",
+			provider: func(t *testing.T) *keystoreSecretsProvider {
+				return &keystoreSecretsProvider{KeyFile: writeTestKeyFile(t, func(key []byte) []byte { return key })}
+			},
+			wantKDF: keystoreKDFNone,
+		},
+		{
+			name: "hex key file",
+			provider: func(t *testing.T) *keystoreSecretsProvider {
+				return &keystoreSecretsProvider{KeyFile: writeTestKeyFile(t, func(key []byte) []byte {
+					return []byte(hex.EncodeToString(key) + "\n")
+				})}
+			},
+			wantKDF: keystoreKDFNone,
+		},
+		{
+			name: "base64 key file",
+			provider: func(t *testing.T) *keystoreSecretsProvider {
+				return &keystoreSecretsProvider{KeyFile: writeTestKeyFile(t, func(key []byte) []byte {
+					return []byte(base64.StdEncoding.EncodeToString(key))
+				})}
+			},
+			wantKDF: keystoreKDFNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := tt.provider(t)
+			provider.Path = filepath.Join(t.TempDir(), "secrets", "keystore.json")
+			if err := provider.Save(testCredentials); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			info, err := os.Stat(provider.Path)
+			if err != nil {
+				t.Fatalf("keystore not written: %v", err)
+			}
+			if mode := info.Mode().Perm(); mode != 0600 {
+				t.Errorf("keystore mode %o, want 600", mode)
+			}
+			data, _ := os.ReadFile(provider.Path)
+			if bytes.Contains(data, []byte(testCredentials.SecretKey)) || bytes.Contains(data, []byte(testCredentials.AccessKey)) {
+				t.Fatalf("keystore contains plaintext credentials: %s", data)
+			}
+			var ks keystoreFile
+			if err := json.Unmarshal(data, &ks); err != nil || ks.KDF != tt.wantKDF {
+				t.Fatalf("keystore kdf = %q (%v), want %q", ks.KDF, err, tt.wantKDF)
+			}
+
+			creds, err := provider.Load()
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if *creds != testCredentials {
+				t.Errorf("loaded %+v, want %+v", *creds, testCredentials)
+			}
+		})
+	}
+}
+
+func TestKeystoreRejectsWrongKey(t *testing.T) {
+	dir := t.TempDir()
+
+	saved := &keystoreSecretsProvider{Path: filepath.Join(dir, "passphrase.json"), Passphrase: "correct horse battery staple"}
+	if err := saved.Save(testCredentials); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	wrong := &keystoreSecretsProvider{Path: saved.Path, Passphrase: "correct horse battery stapler"}
+	if creds, err := wrong.Load(); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Fatalf("expected wrong passphrase to fail, got %+v, %v", creds, err)
+	}
+	missing := &keystoreSecretsProvider{Path: saved.Path}
+	if _, err := missing.Load(); err == nil {
+		t.Fatalf("expected a missing passphrase to fail")
+	}
+
+	keyed := &keystoreSecretsProvider{Path: filepath.Join(dir, "keyfile.json"), KeyFile: writeTestKeyFile(t, func(key []byte) []byte { return key })}
+	if err := keyed.Save(testCredentials); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	otherKey := writeTestKeyFile(t, func(key []byte) []byte { return bytes.Repeat([]byte{0x24}, 32) })
+	if _, err := (&keystoreSecretsProvider{Path: keyed.Path, KeyFile: otherKey}).Load(); err == nil {
+		t.Fatalf("expected a different key file to fail")
+	}
+	shortKey := writeTestKeyFile(t, func(key []byte) []byte { return key[:16] })
+	if _, err := (&keystoreSecretsProvider{Path: keyed.Path, KeyFile: shortKey}).Load(); err == nil {
+		t.Fatalf("expected a 16-byte key file to be rejected")
+	}
+}
+
+func TestKeystoreRejectsTampering(t *testing.T) {
+	keyFile := writeTestKeyFile(t, func(key []byte) []byte { return key })
+	original := &keystoreSecretsProvider{Path: filepath.Join(t.TempDir(), "keystore.json"), KeyFile: keyFile}
+	if err := original.Save(testCredentials); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(original.Path)
+	if err != nil {
+		t.Fatalf("failed to read keystore: %v", err)
+	}
+
+	flip := func(encoded string, index int) string {
+		raw, _ := base64.StdEncoding.DecodeString(encoded)
+		raw[index] ^= 0x01
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+	tests := []struct {
+		name   string
+		tamper func(ks *keystoreFile)
+	}{
+		{"flipped ciphertext byte", func(ks *keystoreFile) { ks.Ciphertext = flip(ks.Ciphertext, 0) }},
+		{"flipped tag byte", func(ks *keystoreFile) {
+			raw, _ := base64.StdEncoding.DecodeString(ks.Ciphertext)
+			ks.Ciphertext = flip(ks.Ciphertext, len(raw)-1)
+		}},
+		{"truncated ciphertext", func(ks *keystoreFile) {
+			raw, _ := base64.StdEncoding.DecodeString(ks.Ciphertext)
+			ks.Ciphertext = base64.StdEncoding.EncodeToString(raw[:len(raw)-1])
+		}},
+		{"flipped nonce byte", func(ks *keystoreFile) { ks.Nonce = flip(ks.Nonce, 0) }},
+		{"unsupported version", func(ks *keystoreFile) { ks.Version = keystoreVersion + 1 }},
+		{"unsupported kdf", func(ks *keystoreFile) { ks.KDF = "scrypt" }},
+		{"invalid ciphertext encoding", func(ks *keystoreFile) { ks.Ciphertext = "not base64!" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ks keystoreFile
+			if err := json.Unmarshal(data, &ks); err != nil {
+				t.Fatalf("failed to parse keystore: %v", err)
+			}
+			tt.tamper(&ks)
+			tampered, _ := json.Marshal(ks)
+
+			provider := &keystoreSecretsProvider{Path: filepath.Join(t.TempDir(), "keystore.json"), KeyFile: keyFile}
+			if err := os.WriteFile(provider.Path, tampered, 0600); err != nil {
+				t.Fatalf("failed to write keystore: %v", err)
+			}
+			if creds, err := provider.Load(); err == nil {
+				t.Fatalf("expected tampered keystore to fail, loaded %+v", creds)
+			}
+		})
+	}
+
+	// 변조하지 않은 원본은 그대로 열림
+	if _, err := original.Load(); err != nil {
+		t.Fatalf("original keystore should still load: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 종료 시 미체결 주문 처리 정책
+const (
+	ShutdownCancelNone = "none" // 미체결 주문 유지
+	ShutdownCancelBot  = "bot"  // 봇이 제출한(저널에 있는) 미체결 주문만 취소
+	ShutdownCancelAll  = "all"  // 모든 미체결 주문 취소
+)
+
+// 봇 종료 - 거래 루프 중지 및 진행 중인 틱 완료 대기, 미체결 주문 정리, 저널/로그 flush
+// 제한 시간이 지나도 저널/기록 파일은 항상 flush 후 닫는다.
+func (bot *TradingBot) Shutdown(ctx context.Context, cancelPolicy string) error {
+	bot.logger.Info("Shutting down trading bot (cancel policy: %s)", cancelPolicy)
+
+	// 이후의 거래 시작/리밸런싱 요청 거부
+	bot.mu.Lock()
+	bot.stopping = true
+	bot.mu.Unlock()
+
+	// 1. 거래 루프와 주기 작업 중지 후 모든 백그라운드 고루틴 종료 대기
+	var errs []string
+	bot.StopTrading()
+	bot.mu.Lock()
+	if bot.stopBackground != nil {
+		bot.stopBackground()
+	}
+	bot.mu.Unlock()
+	if err := waitGroupContext(ctx, &bot.background); err != nil {
+		errs = append(errs, fmt.Sprintf("timed out waiting for background tasks: %v", err))
+	}
+
+	// 진행 중인 분할 실행이 자식 주문을 정리할 때까지 대기
+	if err := bot.executor.wait(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("timed out waiting for executions: %v", err))
+	}
+
+	// 2. 미체결 주문 취소 (제한 시간이 지났으면 건너뜀)
+	if cancelPolicy == ShutdownCancelBot || cancelPolicy == ShutdownCancelAll {
+		if ctx.Err() != nil {
+			errs = append(errs, "skipped cancelling open orders: shutdown timed out")
+		} else if err := bot.cancelOpenOrders(ctx, cancelPolicy == ShutdownCancelAll); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// 3. 저널과 로그 flush
+	// 청산/리밸런싱이 기록 중이면 끝날 때까지 기다리고, 닫은 뒤에는 tickMu를 풀지 않아 더 이상 주문하지 않음
+	if !lockContext(ctx, &bot.tickMu) {
+		errs = append(errs, "timed out waiting for the running tick: closing logs anyway")
+	}
+	if bot.journal != nil {
+		if err := bot.journal.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to close journal: %v", err))
+		}
+	}
+	if bot.signals != nil {
+		if err := bot.signals.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to close signal log: %v", err))
+		}
+	}
+	if bot.ledger != nil {
+		if err := bot.ledger.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to close fill ledger: %v", err))
+		}
+	}
+	bot.logger.Info("Trading bot shut down")
+	if err := bot.logger.Close(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to close log file: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown completed with errors: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// 미체결 주문 취소 (all이 false이면 저널에 기록된 봇 주문만)
+func (bot *TradingBot) cancelOpenOrders(ctx context.Context, all bool) error {
+	orders, err := bot.getAllOrders(ctx, "wait")
+	if err != nil {
+		return fmt.Errorf("failed to fetch open orders: %v", err)
+	}
+
+	failed := 0
+	for _, order := range orders {
+		if !all {
+			if order.Identifier == "" || bot.journal == nil {
+				continue
+			}
+			if _, ok := bot.journal.lookup(order.Identifier); !ok {
+				continue
+			}
+		}
+
+		if err := bot.cancelOrder(ctx, order.UUID); err != nil {
+			bot.logger.Error("Failed to cancel order %s: %v", order.UUID, err)
+			failed++
+			continue
+		}
+		bot.logger.Info("Cancelled open order %s (%s %s)", order.UUID, order.Market, order.Side)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to cancel %d open orders", failed)
+	}
+	return nil
+}
+
+// WaitGroup 대기 (제한 시간이 지나면 ctx 오류 반환)
+func waitGroupContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 잠금 획득 (제한 시간이 지나면 false - 이후에 획득된 잠금은 풀리지 않음)
+func lockContext(ctx context.Context, mu *sync.Mutex) bool {
+	locked := make(chan struct{})
+	go func() {
+		mu.Lock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignalInputs 구조체 - 신호 계산에 사용한 지표 값
+type SignalInputs struct {
+	Price    float64 `json:"price"`
+	ShortMA  float64 `json:"short_ma"`
+	LongMA   float64 `json:"long_ma"`
+	RSI      float64 `json:"rsi"`
+	BBMiddle float64 `json:"bb_middle"`
+	BBUpper  float64 `json:"bb_upper"`
+	BBLower  float64 `json:"bb_lower"`
+	Samples  int     `json:"samples"` // 분석에 사용한 가격 개수
+}
+
+// SignalCondition 구조체 - 매수/매도 조건 하나의 판정 결과
+type SignalCondition struct {
+	Side      string  `json:"side"`      // buy, sell
+	Name      string  `json:"name"`      // 규칙의 비교 조건 (예: rsi(14) < 30)
+	Value     float64 `json:"value"`     // 비교한 값
+	Threshold float64 `json:"threshold"` // 비교 기준
+	Passed    bool    `json:"passed"`
+}
+
+// ConfidenceBreakdown 구조체 - 신뢰도 구성 요소
+// raw 방식은 지표 비율에, zscore 방식은 구성 요소별 z-score에 가중치를 적용한다.
+type ConfidenceBreakdown struct {
+	Mode          string  `json:"mode"`              // 계산 방식 (raw, zscore)
+	History       int     `json:"history,omitempty"` // zscore: 분포 계산에 사용한 과거 시점 수
+	MAStrength    float64 `json:"ma_strength"`       // raw: |단기MA - 장기MA| / 장기MA, zscore: 신호 방향 추세 폭
+	RSIStrength   float64 `json:"rsi_strength"`      // raw: 과매도/과매수 구간 이탈 정도, zscore: 신호 방향 RSI
+	BandStrength  float64 `json:"band_strength"`     // raw: |가격 - 밴드| / 밴드, zscore: 신호 방향 밴드 이탈 폭
+	MAZScore      float64 `json:"ma_zscore,omitempty"`
+	RSIZScore     float64 `json:"rsi_zscore,omitempty"`
+	BandZScore    float64 `json:"band_zscore,omitempty"`
+	MAComponent   float64 `json:"ma_component"`   // 가중치 적용 값
+	RSIComponent  float64 `json:"rsi_component"`  // 가중치 적용 값
+	BandComponent float64 `json:"band_component"` // 가중치 적용 값
+	Total         float64 `json:"total"`          // 최종 신뢰도 (0~1)
+}
+
+// SignalExplanation 구조체 - 신호 판단 근거 기록
+type SignalExplanation struct {
+	Time       time.Time            `json:"time"`
+	Market     string               `json:"market"`
+	Source     string               `json:"source"` // 분석 데이터 (tick 또는 캔들 시간대)
+	Signal     string               `json:"signal"` // 최종 신호 (buy, sell, hold)
+	Inputs     SignalInputs         `json:"inputs"`
+	Conditions []SignalCondition    `json:"conditions"`
+	Confidence *ConfidenceBreakdown `json:"confidence,omitempty"` // 매수/매도 신호일 때만
+	Note       string               `json:"note,omitempty"`       // 분석 이후 신호가 바뀐 이유 (상위 시간대 미확인 등)
+}
+
+// SignalLog 구조체 - 신호 판단 근거를 JSON Lines 파일에 기록하고 최근 기록을 메모리에 유지
+type SignalLog struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	recent  []SignalExplanation
+	maxSize int
+}
+
+// 신호 기록 파일을 열고 최근 기록을 복원
+func openSignalLog(path string, maxSize int) (*SignalLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create signal log directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signal log: %v", err)
+	}
+
+	signalLog := &SignalLog{path: path, file: file, maxSize: maxSize}
+
+	// 기록 도중 종료되어 잘린 마지막 줄은 무시
+	explanations, err := readSignalExplanations(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	for _, explanation := range explanations {
+		signalLog.remember(explanation)
+	}
+
+	return signalLog, nil
+}
+
+// 최근 기록에 추가 (maxSize 초과 시 오래된 기록 제거, mu를 잡은 상태에서 호출)
+func (l *SignalLog) remember(explanation SignalExplanation) {
+	l.recent = append(l.recent, explanation)
+	if l.maxSize > 0 && len(l.recent) > l.maxSize {
+		l.recent = append([]SignalExplanation(nil), l.recent[len(l.recent)-l.maxSize:]...)
+	}
+}
+
+// 신호 판단 근거 기록
+func (l *SignalLog) record(explanation SignalExplanation) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	line, err := json.Marshal(explanation)
+	if err != nil {
+		return fmt.Errorf("failed to encode signal explanation: %v", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write signal explanation: %v", err)
+	}
+
+	l.remember(explanation)
+	return nil
+}
+
+// 조건에 맞는 최근 기록 조회 (최신순)
+func (l *SignalLog) query(market, signal string, limit int) []SignalExplanation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]SignalExplanation, 0)
+	for i := len(l.recent) - 1; i >= 0; i-- {
+		explanation := l.recent[i]
+		if market != "" && explanation.Market != market {
+			continue
+		}
+		if signal != "" && explanation.Signal != signal {
+			continue
+		}
+		result = append(result, explanation)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// 신호 기록 파일 닫기
+func (l *SignalLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// 신호 판단 근거 저장 (실패해도 거래 흐름은 계속 진행)
+func (bot *TradingBot) recordSignal(market string, explanation *SignalExplanation) {
+	if explanation == nil {
+		return
+	}
+	explanation.Time = time.Now()
+	explanation.Market = market
+	bot.logger.Debug("Signal explanation for %s: %s (%s)", explanation.Market, explanation.Signal, explanation.Note)
+	if bot.signals == nil {
+		return
+	}
+	if err := bot.signals.record(*explanation); err != nil {
+		bot.logger.Error("Failed to record signal explanation: %v", err)
+	}
+}
+
+// GET /api/signals 쿼리 파라미터 파싱 (market, signal, limit)
+func parseSignalQuery(c *gin.Context) (market, signal string, limit int, err error) {
+	market = strings.ToUpper(c.Query("market"))
+	signal = c.Query("signal")
+	limit = 100
+
+	switch signal {
+	case "", "buy", "sell", "hold":
+	default:
+		return "", "", 0, fmt.Errorf("invalid signal: %s", signal)
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return "", "", 0, fmt.Errorf("invalid limit: %s", value)
+		}
+	}
+	return market, signal, limit, nil
+}
+
+// GET /api/signals/calibration 쿼리 파라미터 파싱 (market, horizon_minutes, buckets)
+func parseCalibrationQuery(c *gin.Context) (market string, horizon time.Duration, buckets int, err error) {
+	market = strings.ToUpper(c.Query("market"))
+	minutes := getEnvInt("CALIBRATION_HORIZON_MINUTES", 60)
+	buckets = 5
+
+	if value := c.Query("horizon_minutes"); value != "" {
+		minutes, err = strconv.Atoi(value)
+		if err != nil || minutes <= 0 {
+			return "", 0, 0, fmt.Errorf("invalid horizon_minutes: %s", value)
+		}
+	}
+	if value := c.Query("buckets"); value != "" {
+		buckets, err = strconv.Atoi(value)
+		if err != nil || buckets <= 0 || buckets > 100 {
+			return "", 0, 0, fmt.Errorf("invalid buckets: %s", value)
+		}
+	}
+	return market, time.Duration(minutes) * time.Minute, buckets, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"math"
+	"os"
+)
+
+// 포지션 크기 산정 방식
+const (
+	SizingFixedFraction = "fixed_fraction" // 계좌 잔고의 고정 비율
+	SizingFixedNotional = "fixed_notional" // 주문당 고정 KRW 금액
+	SizingVolatility    = "volatility"     // ATR 기반 변동성 목표
+	SizingKelly         = "kelly"          // 켈리 비율
+)
+
+// 매도 신호 청산 정책
+const (
+	ExitPolicyFull    = "full"    // 보유 수량 전체 매도
+	ExitPolicyPartial = "partial" // 보유 수량의 일부만 매도
+)
+
+// 업비트 KRW 마켓 최소 주문 금액 및 기본 수수료율
+const (
+	minOrderKRW    = 5000.0
+	defaultFeeRate = 0.0005
+)
+
+// PositionSize 구조체 - KRW 명목 금액과 코인 수량을 구분해서 표현
+type PositionSize struct {
+	Notional float64 // KRW 명목 금액
+	Volume   float64 // 코인 수량
+}
+
+// PositionSizer 구조체 - 산정 방식별 파라미터
+type PositionSizer struct {
+	Mode              string
+	Fraction          float64 // fixed_fraction: 잔고 대비 비율 (0.02 = 2%)
+	Notional          float64 // fixed_notional: 주문당 KRW 금액
+	RiskPerTrade      float64 // volatility: 거래당 허용 손실 비율 (잔고 대비)
+	ATRPeriod         int     // volatility: ATR 계산 기간
+	ATRMultiple       float64 // volatility: 손절 거리 = ATR * 배수
+	KellyWinRate      float64 // kelly: 승률 (0~1)
+	KellyPayoff       float64 // kelly: 평균 이익 / 평균 손실
+	KellyFraction     float64 // kelly: 켈리 비율 축소 계수 (0.5 = half-kelly)
+	ScaleByConfidence bool    // 신호 신뢰도로 명목 금액 조정 여부
+	FeeRate           float64 // 매수 수수료율
+	MinOrder          float64 // 최소 주문 금액 (KRW)
+}
+
+// 환경 변수로부터 PositionSizer 생성
+func newPositionSizerFromEnv() *PositionSizer {
+	return &PositionSizer{
+		Mode:              getEnvOrDefault("SIZING_MODE", SizingFixedFraction),
+		Fraction:          getEnvFloat("SIZING_FRACTION", 0.02),
+		Notional:          getEnvFloat("SIZING_NOTIONAL_KRW", 10000),
+		RiskPerTrade:      getEnvFloat("SIZING_RISK_PER_TRADE", 0.01),
+		ATRPeriod:         getEnvInt("SIZING_ATR_PERIOD", 14),
+		ATRMultiple:       getEnvFloat("SIZING_ATR_MULTIPLE", 2.0),
+		KellyWinRate:      getEnvFloat("SIZING_KELLY_WIN_RATE", 0.5),
+		KellyPayoff:       getEnvFloat("SIZING_KELLY_PAYOFF", 1.5),
+		KellyFraction:     getEnvFloat("SIZING_KELLY_FRACTION", 0.5),
+		ScaleByConfidence: os.Getenv("SIZING_SCALE_BY_CONFIDENCE") != "false",
+		FeeRate:           defaultFeeRate,
+		MinOrder:          minOrderKRW,
+	}
+}
+
+// 산정 방식에 따른 KRW 명목 금액 계산 (상한 적용 전)
+func (ps *PositionSizer) targetNotional(confidence, balance, currentPrice, atr float64) float64 {
+	var notional float64
+
+	switch ps.Mode {
+	case SizingFixedNotional:
+		notional = ps.Notional
+	case SizingVolatility:
+		// 손절 거리(ATR * 배수)에서 손실이 잔고의 RiskPerTrade가 되도록 수량 결정
+		stopDistance := atr * ps.ATRMultiple
+		if stopDistance <= 0 {
+			return 0
+		}
+		volume := (balance * ps.RiskPerTrade) / stopDistance
+		notional = volume * currentPrice
+	case SizingKelly:
+		if ps.KellyPayoff <= 0 {
+			return 0
+		}
+		kelly := ps.KellyWinRate - (1-ps.KellyWinRate)/ps.KellyPayoff
+		if kelly <= 0 {
+			return 0
+		}
+		notional = balance * kelly * ps.KellyFraction
+	default:
+		notional = balance * ps.Fraction
+	}
+
+	if ps.ScaleByConfidence {
+		notional *= confidence
+	}
+
+	return notional
+}
+
+// 청산 수량 계산 - 주문 가능 보유 수량과 청산 정책으로 매도 수량 결정
+func (rm *RiskManager) calculateExitSize(available float64, currentPrice float64) PositionSize {
+	if available <= 0 || currentPrice <= 0 {
+		return PositionSize{}
+	}
+
+	minOrder := minOrderKRW
+	if rm.Sizer != nil {
+		minOrder = rm.Sizer.MinOrder
+	}
+
+	volume := available
+	if rm.ExitPolicy == ExitPolicyPartial && rm.PartialExitRatio > 0 && rm.PartialExitRatio < 1 {
+		volume = available * rm.PartialExitRatio
+		// 부분 매도 후 잔량이 최소 주문 금액 미만이면 전량 매도
+		if (available-volume)*currentPrice < minOrder {
+			volume = available
+		}
+	}
+
+	// 매도 금액이 최소 주문 금액 미만이면 전량으로 다시 시도
+	if volume*currentPrice < minOrder {
+		volume = available
+	}
+	if volume*currentPrice < minOrder {
+		return PositionSize{}
+	}
+
+	return PositionSize{
+		Notional: volume * currentPrice,
+		Volume:   volume,
+	}
+}
+
+// 종가 변화량 기반 ATR 계산 (고가/저가 데이터가 없으므로 |P(t) - P(t-1)| 평균 사용)
+func (t *TechnicalIndicators) calculateATR(period int) float64 {
+	if period <= 0 || len(t.Prices) < period+1 {
+		return 0
+	}
+
+	sum := 0.0
+	for i := len(t.Prices) - period; i < len(t.Prices); i++ {
+		sum += math.Abs(t.Prices[i] - t.Prices[i-1])
+	}
+	return sum / float64(period)
+}
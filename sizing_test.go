@@ -0,0 +1,172 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func TestCalculatePositionSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		sizer      PositionSizer
+		risk       RiskManager
+		confidence float64
+		balance    float64
+		price      float64
+		atr        float64
+		want       float64 // 기대 명목 금액 (0이면 주문 없음)
+	}{
+		{
+			name:    "fixed notional",
+			sizer:   PositionSizer{Mode: SizingFixedNotional, Notional: 10000},
+			balance: 1000000, price: 100000,
+			want: 10000,
+		},
+		{
+			name:    "fixed fraction of balance",
+			sizer:   PositionSizer{Mode: SizingFixedFraction, Fraction: 0.02},
+			balance: 1000000, price: 100000,
+			want: 20000,
+		},
+		{
+			name:    "unknown mode falls back to fixed fraction",
+			sizer:   PositionSizer{Mode: "", Fraction: 0.03},
+			balance: 1000000, price: 100000,
+			want: 30000,
+		},
+		{
+			name:    "volatility risk sizing",
+			sizer:   PositionSizer{Mode: SizingVolatility, RiskPerTrade: 0.01, ATRMultiple: 2},
+			balance: 1000000, price: 100000, atr: 1000,
+			// 허용 손실 10,000 / 손절 거리 2,000 = 5개
+			want: 500000,
+		},
+		{
+			name:    "volatility without atr",
+			sizer:   PositionSizer{Mode: SizingVolatility, RiskPerTrade: 0.01, ATRMultiple: 2},
+			balance: 1000000, price: 100000, atr: 0,
+			want: 0,
+		},
+		{
+			name:    "half kelly",
+			sizer:   PositionSizer{Mode: SizingKelly, KellyWinRate: 0.5, KellyPayoff: 1.5, KellyFraction: 0.5},
+			balance: 1000000, price: 100000,
+			want: 1000000 * (0.5 - 0.5/1.5) * 0.5,
+		},
+		{
+			name:    "negative kelly edge",
+			sizer:   PositionSizer{Mode: SizingKelly, KellyWinRate: 0.3, KellyPayoff: 1, KellyFraction: 1},
+			balance: 1000000, price: 100000,
+			want: 0,
+		},
+		{
+			name:       "scaled by confidence",
+			sizer:      PositionSizer{Mode: SizingFixedFraction, Fraction: 0.02, ScaleByConfidence: true},
+			confidence: 0.5,
+			balance:    1000000, price: 100000,
+			want: 10000,
+		},
+		{
+			name:    "below minimum order is dropped",
+			sizer:   PositionSizer{Mode: SizingFixedFraction, Fraction: 0.002},
+			balance: 1000000, price: 100000,
+			want: 0,
+		},
+		{
+			name:    "capped by balance including fee",
+			sizer:   PositionSizer{Mode: SizingFixedNotional, Notional: 100000},
+			balance: 50000, price: 100000,
+			want: 50000 / 1.0005,
+		},
+		{
+			name:    "balance cap below minimum order",
+			sizer:   PositionSizer{Mode: SizingFixedNotional, Notional: 100000},
+			balance: 4000, price: 100000,
+			want: 0,
+		},
+		{
+			name:    "capped by max position size",
+			sizer:   PositionSizer{Mode: SizingFixedFraction, Fraction: 0.5},
+			risk:    RiskManager{MaxPositionSize: 100000},
+			balance: 1000000, price: 100000,
+			want: 100000,
+		},
+		{
+			name:    "capped by stop loss risk",
+			sizer:   PositionSizer{Mode: SizingFixedFraction, Fraction: 0.5},
+			risk:    RiskManager{StopLoss: 2, MaxRiskPerTrade: 0.002},
+			balance: 1000000, price: 100000,
+			// 허용 손실 2,000 / 손절 2% = 100,000
+			want: 100000,
+		},
+		{
+			name:    "no price",
+			sizer:   PositionSizer{Mode: SizingFixedNotional, Notional: 10000},
+			balance: 1000000, price: 0,
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizer := tt.sizer
+			sizer.FeeRate = defaultFeeRate
+			sizer.MinOrder = minOrderKRW
+			rm := tt.risk
+			rm.Sizer = &sizer
+
+			size := rm.calculatePositionSize(TradeSignal{Type: "buy", Confidence: tt.confidence}, tt.balance, tt.price, tt.atr)
+			if !approxEqual(size.Notional, tt.want) {
+				t.Fatalf("notional = %f, want %f", size.Notional, tt.want)
+			}
+			if tt.want == 0 {
+				if size.Volume != 0 {
+					t.Fatalf("volume = %f, want 0", size.Volume)
+				}
+				return
+			}
+			if !approxEqual(size.Volume, tt.want/tt.price) {
+				t.Fatalf("volume = %f, want %f", size.Volume, tt.want/tt.price)
+			}
+		})
+	}
+}
+
+func TestCalculateExitSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		ratio     float64
+		available float64
+		price     float64
+		want      float64 // 기대 매도 수량
+	}{
+		{name: "full exit", policy: ExitPolicyFull, available: 2, price: 10000, want: 2},
+		{name: "partial exit", policy: ExitPolicyPartial, ratio: 0.5, available: 2, price: 10000, want: 1},
+		{name: "partial remainder below minimum sells all", policy: ExitPolicyPartial, ratio: 0.5, available: 0.8, price: 10000, want: 0.8},
+		{name: "invalid ratio sells all", policy: ExitPolicyPartial, ratio: 1.5, available: 2, price: 10000, want: 2},
+		{name: "position below minimum order", policy: ExitPolicyFull, available: 0.4, price: 10000, want: 0},
+		{name: "nothing available", policy: ExitPolicyFull, available: 0, price: 10000, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := &RiskManager{
+				Sizer:            &PositionSizer{FeeRate: defaultFeeRate, MinOrder: minOrderKRW},
+				ExitPolicy:       tt.policy,
+				PartialExitRatio: tt.ratio,
+			}
+			size := rm.calculateExitSize(tt.available, tt.price)
+			if !approxEqual(size.Volume, tt.want) {
+				t.Fatalf("volume = %f, want %f", size.Volume, tt.want)
+			}
+			if !approxEqual(size.Notional, tt.want*tt.price) {
+				t.Fatalf("notional = %f, want %f", size.Notional, tt.want*tt.price)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// 추적 손절 방식
+const (
+	TrailingStopNone    = "none"
+	TrailingStopPercent = "percent" // 진입 후 최고가 대비 일정 비율
+	TrailingStopATR     = "atr"     // 진입 후 최고가 - ATR 배수
+)
+
+// 청산 사유
+const (
+	ExitReasonStopLoss     = "stop_loss"
+	ExitReasonTrailingStop = "trailing_stop"
+	ExitReasonBreakEven    = "break_even"
+	ExitReasonTakeProfit   = "take_profit"
+	ExitReasonTimeExit     = "time_exit"
+)
+
+// StopRules 구조체 - 보유 포지션 청산 규칙
+type StopRules struct {
+	Enabled             bool
+	TrailingMode        string        // 추적 손절 방식 (none, percent, atr)
+	TrailingPercent     float64       // percent: 최고가 대비 하락 비율 (%)
+	TrailingATRMultiple float64       // atr: 최고가에서 뺄 ATR 배수
+	BreakEvenTrigger    float64       // 최고 수익률이 이 값(%) 이상이면 손절가를 진입가로 이동 (0이면 사용 안 함)
+	BreakEvenOffset     float64       // 본전 손절가에 더할 비율 (%, 수수료 보전)
+	MaxHolding          time.Duration // 최대 보유 시간 (0이면 사용 안 함)
+	OrderType           string        // 청산 주문 유형
+	StatePath           string        // 손절 상태(최고가, 최초 확인 시각) 저장 파일 (비어 있으면 저장 안 함)
+}
+
+// 환경 변수로 청산 규칙 설정 (알 수 없는 추적 손절 방식이면 사용 안 함)
+func newStopRulesFromEnv() *StopRules {
+	trailingMode := getEnvOrDefault("TRAILING_STOP_MODE", TrailingStopNone)
+	if !validTrailingMode(trailingMode) {
+		log.Printf("Warning: unknown TRAILING_STOP_MODE %q, trailing stop disabled", trailingMode)
+		trailingMode = TrailingStopNone
+	}
+
+	return &StopRules{
+		Enabled:             os.Getenv("STOPS_ENABLED") == "true",
+		TrailingMode:        trailingMode,
+		TrailingPercent:     getEnvFloat("TRAILING_STOP_PERCENT", 3.0),
+		TrailingATRMultiple: getEnvFloat("TRAILING_STOP_ATR_MULTIPLE", 3.0),
+		BreakEvenTrigger:    getEnvFloat("BREAK_EVEN_TRIGGER_PERCENT", 0),
+		BreakEvenOffset:     getEnvFloat("BREAK_EVEN_OFFSET_PERCENT", 0.1),
+		MaxHolding:          time.Duration(getEnvInt("MAX_HOLDING_MINUTES", 0)) * time.Minute,
+		OrderType:           getEnvOrDefault("STOP_ORDER_TYPE", OrderTypeMarket),
+		StatePath:           getEnvOrDefault("STOP_STATE_PATH", "/app/logs/stops.json"),
+	}
+}
+
+// 저장된 손절 상태 읽기 (파일이 없으면 빈 상태)
+func loadPositionStops(path string) (map[string]*PositionStop, error) {
+	stops := make(map[string]*PositionStop)
+	if path == "" {
+		return stops, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stops, nil
+	}
+	if err != nil {
+		return stops, fmt.Errorf("failed to read stop state: %v", err)
+	}
+	if err := json.Unmarshal(data, &stops); err != nil {
+		return make(map[string]*PositionStop), fmt.Errorf("failed to parse stop state: %v", err)
+	}
+	return stops, nil
+}
+
+// 손절 상태 저장 - 임시 파일에 쓴 뒤 교체해 중간에 종료되어도 이전 상태를 유지
+func savePositionStops(path string, stops map[string]PositionStop) error {
+	data, err := json.MarshalIndent(stops, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stop state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create stop state directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write stop state: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace stop state: %v", err)
+	}
+	return nil
+}
+
+// 현재 손절 상태를 파일에 저장 (실패해도 거래는 계속 진행)
+func (bot *TradingBot) persistStops() {
+	rules := bot.riskManager.Stops
+	if rules == nil || rules.StatePath == "" {
+		return
+	}
+
+	bot.mu.RLock()
+	stops := bot.positionStopsLocked()
+	bot.mu.RUnlock()
+
+	if err := savePositionStops(rules.StatePath, stops); err != nil {
+		bot.logger.Error("Failed to save position stops: %v", err)
+	}
+}
+
+// PositionStop 구조체 - 포지션별 손절 상태
+type PositionStop struct {
+	Market       string    `json:"market"`
+	EntryPrice   float64   `json:"entry_price"`
+	EntryTime    time.Time `json:"entry_time"`    // 봇이 포지션을 처음 확인한 시각
+	HighestPrice float64   `json:"highest_price"` // 진입 후 최고가
+	StopPrice    float64   `json:"stop_price"`    // 현재 손절가 (올라가기만 함)
+	StopReason   string    `json:"stop_reason"`   // 현재 손절가를 정한 규칙
+	BreakEven    bool      `json:"break_even"`    // 본전 손절 적용 여부
+}
+
+// 가격 갱신 후 손절가를 올리고, 청산 조건을 만족하면 사유를 반환
+func (rm *RiskManager) evaluateStop(stop *PositionStop, price, atr float64, now time.Time) string {
+	rules := rm.Stops
+	stop.HighestPrice = math.Max(stop.HighestPrice, price)
+
+	raise := func(candidate float64, reason string) {
+		if candidate > stop.StopPrice {
+			stop.StopPrice = candidate
+			stop.StopReason = reason
+		}
+	}
+
+	// 1. 고정 손절
+	if rm.StopLoss > 0 {
+		raise(stop.EntryPrice*(1-rm.StopLoss/100), ExitReasonStopLoss)
+	}
+
+	// 2. 추적 손절 - 최고가를 따라 올라감
+	switch rules.TrailingMode {
+	case TrailingStopPercent:
+		if rules.TrailingPercent > 0 {
+			raise(stop.HighestPrice*(1-rules.TrailingPercent/100), ExitReasonTrailingStop)
+		}
+	case TrailingStopATR:
+		if atr > 0 && rules.TrailingATRMultiple > 0 {
+			raise(stop.HighestPrice-atr*rules.TrailingATRMultiple, ExitReasonTrailingStop)
+		}
+	}
+
+	// 3. 본전 손절 - 최고 수익률이 기준을 넘으면 손절가를 진입가 위로
+	if rules.BreakEvenTrigger > 0 && stop.EntryPrice > 0 {
+		peakProfit := (stop.HighestPrice - stop.EntryPrice) / stop.EntryPrice * 100
+		if peakProfit >= rules.BreakEvenTrigger {
+			stop.BreakEven = true
+			raise(stop.EntryPrice*(1+rules.BreakEvenOffset/100), ExitReasonBreakEven)
+		}
+	}
+
+	if stop.StopPrice > 0 && price <= stop.StopPrice {
+		return stop.StopReason
+	}
+
+	// 4. 익절
+	if rm.TakeProfit > 0 && stop.EntryPrice > 0 {
+		if (price-stop.EntryPrice)/stop.EntryPrice*100 >= rm.TakeProfit {
+			return ExitReasonTakeProfit
+		}
+	}
+
+	// 5. 시간 청산
+	if rules.MaxHolding > 0 && now.Sub(stop.EntryTime) >= rules.MaxHolding {
+		return ExitReasonTimeExit
+	}
+
+	return ""
+}
+
+// 보유 포지션 청산 조건 확인 - 청산 주문을 냈으면 true (이번 주기의 신호 분석은 건너뜀)
+func (bot *TradingBot) checkStops(ctx context.Context, market string, accounts []Account, price, atr float64) bool {
+	rules := bot.riskManager.Stops
+	if rules == nil || !rules.Enabled {
+		return false
+	}
+
+	_, base := splitMarket(market)
+	available, locked, err := accountBalance(accounts, base)
+	if err != nil {
+		bot.logger.Error("Error parsing %s balance: %v", base, err)
+		return false
+	}
+
+	// 최소 주문 금액 미만의 잔량은 포지션으로 보지 않음
+	if (available+locked)*price < minOrderKRW {
+		bot.mu.Lock()
+		_, tracked := bot.stops[market]
+		delete(bot.stops, market)
+		bot.mu.Unlock()
+		if tracked {
+			bot.persistStops()
+		}
+		return false
+	}
+
+	entryPrice := price
+	for _, account := range accounts {
+		if account.Currency == base {
+			if avg, err := strconv.ParseFloat(account.AvgBuyPrice, 64); err == nil && avg > 0 {
+				entryPrice = avg
+			}
+		}
+	}
+
+	now := time.Now()
+	bot.mu.Lock()
+	stop, ok := bot.stops[market]
+	var previous PositionStop
+	if ok {
+		previous = *stop
+	}
+	// 평균 매수가가 바뀌면(추가 매수 등) 손절 상태를 다시 계산 (최초 확인 시각은 유지)
+	if !ok || stop.EntryPrice != entryPrice {
+		stop = &PositionStop{
+			Market:       market,
+			EntryPrice:   entryPrice,
+			EntryTime:    now,
+			HighestPrice: math.Max(entryPrice, price),
+		}
+		if ok {
+			stop.EntryTime = bot.stops[market].EntryTime
+		}
+		bot.stops[market] = stop
+	}
+	reason := bot.riskManager.evaluateStop(stop, price, atr, now)
+	snapshot := *stop
+	bot.mu.Unlock()
+
+	// 최고가/손절가가 바뀌면 저장해 재시작 후에도 이어서 추적
+	if snapshot != previous {
+		bot.persistStops()
+	}
+
+	bot.logger.Debug("Position stop for %s: %+v", market, snapshot)
+	if reason == "" {
+		return false
+	}
+	if available*price < minOrderKRW {
+		bot.logger.Info("%s exit triggered for %s but the position is locked in open orders", reason, market)
+		return false
+	}
+
+	bot.mu.RLock()
+	interval := bot.interval
+	bot.mu.RUnlock()
+
+	// 청산은 슬리피지 한도를 적용하지 않음
+	signal := TradeSignal{
+		Type:       "sell",
+		Price:      price,
+		Volume:     available,
+		OrderType:  rules.OrderType,
+		Identifier: signalIdentifier(market, reason, now, interval),
+	}
+	orderReq, err := newOrderRequest(signal, market)
+	if err != nil {
+		bot.logger.Error("Invalid %s exit order for %s: %v", reason, market, err)
+		return false
+	}
+	order, err := bot.submitOrder(ctx, orderReq)
+	if err != nil {
+		bot.logger.Error("Failed to submit %s exit for %s: %v", reason, market, err)
+		return true
+	}
+
+	bot.logger.Info("%s exit for %s at %f (entry: %f, stop: %f): order %s",
+		reason, market, price, snapshot.EntryPrice, snapshot.StopPrice, order.UUID)
+	bot.notify(ctx, "position_exit", market, "%s exit at %f (entry %f, stop %f)",
+		reason, price, snapshot.EntryPrice, snapshot.StopPrice)
+	return true
+}
+
+// 포지션별 손절 상태 복사본 (bot.mu를 잡은 상태에서 호출)
+func (bot *TradingBot) positionStopsLocked() map[string]PositionStop {
+	stops := make(map[string]PositionStop, len(bot.stops))
+	for market, stop := range bot.stops {
+		stops[market] = *stop
+	}
+	return stops
+}
+
+// 추적 손절 방식 검증
+func validTrailingMode(mode string) bool {
+	switch mode {
+	case TrailingStopNone, TrailingStopPercent, TrailingStopATR:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"trading-bot/upbitmock"
+)
+
+func TestStopsDisabledByDefault(t *testing.T) {
+	t.Setenv("STOPS_ENABLED", "")
+	if rules := newStopRulesFromEnv(); rules.Enabled {
+		t.Fatalf("stops should be opt-in")
+	}
+}
+
+func TestTrailingStopSurvivesRestart(t *testing.T) {
+	mock := upbitmock.NewServer("ak", "sk")
+	mock.AddMarket(upbitmock.Market{Market: "KRW-BTC"})
+	mock.SetPricePath("KRW-BTC", 113000)
+	mock.SetBalance("KRW", 1000000, 0)
+	mock.SetBalance("BTC", 1, 100000)
+
+	env := map[string]string{
+		"STOPS_ENABLED":         "true",
+		"TRAILING_STOP_MODE":    TrailingStopPercent,
+		"TRAILING_STOP_PERCENT": "5",
+		"TAKE_PROFIT_PERCENT":   "100",
+		"STOP_STATE_PATH":       filepath.Join(t.TempDir(), "stops.json"),
+	}
+	ctx := context.Background()
+
+	bot := newMockBot(t, mock, env)
+	accounts, err := bot.getBalance(ctx)
+	if err != nil {
+		t.Fatalf("getBalance failed: %v", err)
+	}
+	// 최고가 120,000 → 추적 손절가 114,000
+	if bot.checkStops(ctx, "KRW-BTC", accounts, 120000, 0) {
+		t.Fatalf("stop triggered at the high")
+	}
+
+	// 재시작 후에도 최고가를 이어받아 113,000에서 청산
+	restarted := newMockBot(t, mock, env)
+	restarted.mu.RLock()
+	stop, ok := restarted.stops["KRW-BTC"]
+	restarted.mu.RUnlock()
+	if !ok || stop.HighestPrice != 120000 || !approxEqual(stop.StopPrice, 114000) {
+		t.Fatalf("restored stop = %+v, want highest 120000 and stop 114000", stop)
+	}
+	if !restarted.checkStops(ctx, "KRW-BTC", accounts, 113000, 0) {
+		t.Fatalf("trailing stop did not trigger after restart")
+	}
+	if got := submittedOrders(restarted, "ask"); got != 1 {
+		t.Fatalf("sell orders = %d, want 1", got)
+	}
+}
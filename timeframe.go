@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 업비트 분봉 API가 지원하는 단위 (분)
+var candleMinuteUnits = []int{1, 3, 5, 10, 15, 30, 60, 240}
+
+// 캔들 조회 최대 개수 (업비트 제한)
+const maxCandleCount = 200
+
+// Timeframe 구조체 - 캔들 시간 단위
+type Timeframe struct {
+	Name string        // 설정 값 (예: 5m, 1h)
+	Unit time.Duration // 캔들 하나의 길이
+	path string        // 캔들 API 경로
+}
+
+// 시간 단위 파싱 (1m, 3m, 5m, 10m, 15m, 30m, 1h(60m), 4h(240m), 1d)
+func parseTimeframe(value string) (Timeframe, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if name == "1d" {
+		return Timeframe{Name: name, Unit: 24 * time.Hour, path: "/v1/candles/days"}, nil
+	}
+
+	var minutes int
+	var err error
+	switch {
+	case strings.HasSuffix(name, "m"):
+		minutes, err = strconv.Atoi(strings.TrimSuffix(name, "m"))
+	case strings.HasSuffix(name, "h"):
+		minutes, err = strconv.Atoi(strings.TrimSuffix(name, "h"))
+		minutes *= 60
+	default:
+		return Timeframe{}, fmt.Errorf("unknown timeframe: %q", value)
+	}
+	if err != nil {
+		return Timeframe{}, fmt.Errorf("invalid timeframe %q: %v", value, err)
+	}
+
+	for _, unit := range candleMinuteUnits {
+		if unit == minutes {
+			return Timeframe{
+				Name: name,
+				Unit: time.Duration(minutes) * time.Minute,
+				path: fmt.Sprintf("/v1/candles/minutes/%d", minutes),
+			}, nil
+		}
+	}
+	return Timeframe{}, fmt.Errorf("unsupported timeframe: %q", value)
+}
+
+// TimeframeSet 구조체 - 진입 시간대와 추세 확인용 상위 시간대
+type TimeframeSet struct {
+	Entry   Timeframe   // 신호를 계산할 시간대
+	Confirm []Timeframe // 매수 신호를 확인할 상위 시간대 (모두 상승 추세여야 매수)
+}
+
+// 쉼표로 구분된 시간대 목록 파싱 - 첫 번째가 진입 시간대, 나머지는 확인 시간대
+// "tick" 또는 빈 값이면 nil (가격 샘플 기반 분석)
+func parseTimeframeSet(value string) (*TimeframeSet, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "tick") {
+		return nil, nil
+	}
+
+	set := &TimeframeSet{}
+	for i, item := range strings.Split(value, ",") {
+		tf, err := parseTimeframe(item)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			set.Entry = tf
+			continue
+		}
+		if tf.Unit < set.Entry.Unit {
+			return nil, fmt.Errorf("confirmation timeframe %s is shorter than entry timeframe %s", tf.Name, set.Entry.Name)
+		}
+		set.Confirm = append(set.Confirm, tf)
+	}
+	return set, nil
+}
+
+// 설정 문자열 (예: 5m,1h)
+func (s *TimeframeSet) String() string {
+	names := []string{s.Entry.Name}
+	for _, tf := range s.Confirm {
+		names = append(names, tf.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// Candle 구조체 - 업비트 캔들 응답
+type Candle struct {
+	Market     string  `json:"market"`
+	StartUTC   string  `json:"candle_date_time_utc"` // 캔들 시작 시각 (UTC)
+	OpenPrice  float64 `json:"opening_price"`
+	HighPrice  float64 `json:"high_price"`
+	LowPrice   float64 `json:"low_price"`
+	ClosePrice float64 `json:"trade_price"`
+	Volume     float64 `json:"candle_acc_trade_volume"`
+}
+
+// 캔들 시작 시각
+func (c Candle) start() (time.Time, error) {
+	return time.Parse("2006-01-02T15:04:05", c.StartUTC)
+}
+
+// 마감된 캔들만 과거 → 최근 순으로 조회 (진행 중인 캔들과 until 이후에 마감되는 캔들은 제외)
+func (bot *TradingBot) fetchClosedCandles(ctx context.Context, market string, tf Timeframe, count int, until time.Time) ([]Candle, []time.Time, error) {
+	values := url.Values{}
+	values.Set("market", market)
+	// 진행 중인 캔들 하나를 제외할 여유분 포함
+	values.Set("count", strconv.Itoa(min(count+1, maxCandleCount)))
+
+	var candles []Candle
+	if err := bot.getPublic(ctx, tf.path, values, &candles); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s candles for %s: %v", tf.Name, market, err)
+	}
+
+	// 업비트는 최근 캔들부터 반환
+	closed := make([]Candle, 0, len(candles))
+	closes := make([]time.Time, 0, len(candles))
+	for i := len(candles) - 1; i >= 0; i-- {
+		start, err := candles[i].start()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid candle time %q: %v", candles[i].StartUTC, err)
+		}
+		closeTime := start.Add(tf.Unit)
+		if closeTime.After(until) {
+			continue
+		}
+		closed = append(closed, candles[i])
+		closes = append(closes, closeTime)
+	}
+	if len(closed) > count {
+		closes = closes[len(closed)-count:]
+		closed = closed[len(closed)-count:]
+	}
+	return closed, closes, nil
+}
+
+// 캔들 종가로 지표 계산용 데이터 구성
+func candleIndicators(candles []Candle) *TechnicalIndicators {
+	indicators := &TechnicalIndicators{
+		Prices: make([]float64, 0, len(candles)),
+		Volume: make([]float64, 0, len(candles)),
+	}
+	for _, candle := range candles {
+		indicators.Prices = append(indicators.Prices, candle.ClosePrice)
+		indicators.Volume = append(indicators.Volume, candle.Volume)
+	}
+	return indicators
+}
+
+// MultiTimeframe 구조체 - 마켓별 분석 시간대 설정과 마지막으로 평가한 캔들
+type MultiTimeframe struct {
+	Default   *TimeframeSet            // 기본 시간대 (nil이면 가격 샘플 기반 분석)
+	PerMarket map[string]*TimeframeSet // 마켓별 설정 (값이 nil이면 가격 샘플 기반 분석)
+
+	mu        sync.Mutex
+	evaluated map[string]time.Time // 마켓별 마지막으로 평가한 진입 캔들 마감 시각
+}
+
+// 환경 변수로 시간대 설정
+// SIGNAL_TIMEFRAMES가 기본값이며, SIGNAL_TIMEFRAMES_KRW_BTC처럼 마켓별로 덮어쓸 수 있다.
+// 잘못된 설정은 전략을 바꿔 버리므로 가격 샘플 분석으로 대체하지 않고 에러를 반환한다.
+func newMultiTimeframeFromEnv() (*MultiTimeframe, error) {
+	mt := &MultiTimeframe{
+		PerMarket: make(map[string]*TimeframeSet),
+		evaluated: make(map[string]time.Time),
+	}
+
+	set, err := parseTimeframeSet(os.Getenv("SIGNAL_TIMEFRAMES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIGNAL_TIMEFRAMES: %v", err)
+	}
+	mt.Default = set
+
+	const prefix = "SIGNAL_TIMEFRAMES_"
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		market := strings.Replace(strings.TrimPrefix(key, prefix), "_", "-", 1)
+		set, err := parseTimeframeSet(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+		mt.PerMarket[market] = set
+	}
+	return mt, nil
+}
+
+// 마켓의 분석 시간대 (nil이면 가격 샘플 기반 분석)
+func (mt *MultiTimeframe) setFor(market string) *TimeframeSet {
+	if set, ok := mt.PerMarket[market]; ok {
+		return set
+	}
+	return mt.Default
+}
+
+// 아직 평가하지 않은 진입 캔들인지 확인 (같은 캔들로 신호를 반복 평가하지 않음)
+func (mt *MultiTimeframe) isNewCandle(market string, closeTime time.Time) bool {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	return closeTime.After(mt.evaluated[market])
+}
+
+// 진입 캔들 평가 완료 기록
+func (mt *MultiTimeframe) markEvaluated(market string, closeTime time.Time) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	mt.evaluated[market] = closeTime
+}
+
+// 마켓별 시간대 설정 (상태 API용)
+func (mt *MultiTimeframe) describe(markets []string) map[string]string {
+	described := make(map[string]string, len(markets))
+	for _, market := range markets {
+		if set := mt.setFor(market); set != nil {
+			described[market] = set.String()
+		} else {
+			described[market] = "tick"
+		}
+	}
+	return described
+}
+
+// 다중 시간대 분석 - 진입 시간대의 마감된 캔들로 신호를 계산하고, 매수 신호는 상위 시간대 추세로 확인
+// 새로 마감된 진입 캔들이 없으면 판단 근거 없이 hold를 반환한다. 상위 시간대는 진입 캔들 마감 시각까지 마감된 캔들만 사용한다.
+func (bot *TradingBot) analyzeTimeframes(ctx context.Context, market string, set *TimeframeSet, currentPrice float64) (TradeSignal, *SignalExplanation, error) {
+	hold := TradeSignal{Type: "hold", Price: currentPrice}
+	minDataPoints := bot.strategy.minDataPoints()
+
+	// 신뢰도 z-score 계산용 과거 캔들까지 조회
+	count := min(bot.strategy.historyDataPoints(), maxCandleCount-1)
+	candles, closes, err := bot.fetchClosedCandles(ctx, market, set.Entry, count, time.Now().UTC())
+	if err != nil {
+		return hold, nil, err
+	}
+	if len(candles) < minDataPoints {
+		bot.logger.Info("Not enough %s candles for %s. Have %d, need %d",
+			set.Entry.Name, market, len(candles), minDataPoints)
+		return hold, nil, nil
+	}
+
+	entryClose := closes[len(closes)-1]
+	if !bot.timeframes.isNewCandle(market, entryClose) {
+		bot.logger.Debug("No new closed %s candle for %s since %s", set.Entry.Name, market, entryClose.Format(time.RFC3339))
+		return hold, nil, nil
+	}
+
+	signal, explanation := bot.strategy.analyzeSignals(candleIndicators(candles))
+	explanation.Source = set.Entry.Name
+	// 주문은 현재가 기준으로 계산하고, 식별자는 진입 캔들 단위로 고정
+	signal.Price = currentPrice
+	if signal.Type != "hold" {
+		signal.Identifier = signalIdentifier(market, signal.Type, entryClose, set.Entry.Unit)
+	}
+
+	// 확인 시간대 조회에 실패하면 평가 완료로 기록하지 않고 다음 주기에 다시 시도
+	if signal.Type == "buy" {
+		for _, tf := range set.Confirm {
+			confirmed, err := bot.trendConfirmed(ctx, market, tf, entryClose)
+			if err != nil {
+				return hold, nil, err
+			}
+			if !confirmed {
+				bot.logger.Info("Buy signal on %s %s not confirmed by %s trend", market, set.Entry.Name, tf.Name)
+				signal = hold
+				explanation.Signal = signal.Type
+				explanation.Note = fmt.Sprintf("buy not confirmed by %s trend", tf.Name)
+				break
+			}
+		}
+	}
+
+	bot.timeframes.markEvaluated(market, entryClose)
+	return signal, explanation, nil
+}
+
+// 상위 시간대 상승 추세 확인 - 단기 이동평균이 장기 이동평균보다 높으면 상승
+func (bot *TradingBot) trendConfirmed(ctx context.Context, market string, tf Timeframe, until time.Time) (bool, error) {
+	candles, _, err := bot.fetchClosedCandles(ctx, market, tf, bot.strategy.LongMA, until)
+	if err != nil {
+		return false, err
+	}
+	if len(candles) < bot.strategy.LongMA {
+		bot.logger.Info("Not enough %s candles to confirm trend for %s. Have %d, need %d",
+			tf.Name, market, len(candles), bot.strategy.LongMA)
+		return false, nil
+	}
+
+	indicators := candleIndicators(candles)
+	shortMA := indicators.calculateMA(bot.strategy.ShortMA)
+	longMA := indicators.calculateMA(bot.strategy.LongMA)
+	bot.logger.Debug("%s %s trend: short MA %f, long MA %f", market, tf.Name, shortMA, longMA)
+	return shortMA > longMA, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseTimeframe(t *testing.T) {
+	tests := []struct {
+		value    string
+		wantName string
+		wantUnit time.Duration
+		wantPath string
+		wantErr  bool
+	}{
+		{value: "1m", wantName: "1m", wantUnit: time.Minute, wantPath: "/v1/candles/minutes/1"},
+		{value: " 5M ", wantName: "5m", wantUnit: 5 * time.Minute, wantPath: "/v1/candles/minutes/5"},
+		{value: "60m", wantName: "60m", wantUnit: time.Hour, wantPath: "/v1/candles/minutes/60"},
+		{value: "1h", wantName: "1h", wantUnit: time.Hour, wantPath: "/v1/candles/minutes/60"},
+		{value: "4h", wantName: "4h", wantUnit: 4 * time.Hour, wantPath: "/v1/candles/minutes/240"},
+		{value: "1d", wantName: "1d", wantUnit: 24 * time.Hour, wantPath: "/v1/candles/days"},
+		{value: "2m", wantErr: true},
+		{value: "2h", wantErr: true},
+		{value: "1w", wantErr: true},
+		{value: "m", wantErr: true},
+		{value: "xh", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			tf, err := parseTimeframe(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", tf)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTimeframe failed: %v", err)
+			}
+			if tf.Name != tt.wantName || tf.Unit != tt.wantUnit || tf.path != tt.wantPath {
+				t.Errorf("got %+v, want name %s unit %s path %s", tf, tt.wantName, tt.wantUnit, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestParseTimeframeSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string // 빈 값이면 nil (가격 샘플 분석)
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "tick", value: "TICK"},
+		{name: "entry only", value: "5m", want: "5m"},
+		{name: "entry and confirmations", value: "5m, 1h,1d", want: "5m,1h,1d"},
+		{name: "confirmation shorter than entry", value: "1h,5m", wantErr: true},
+		{name: "invalid confirmation", value: "5m,2h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := parseTimeframeSet(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", set)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTimeframeSet failed: %v", err)
+			}
+			got := ""
+			if set != nil {
+				got = set.String()
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTradingBotRejectsInvalidTimeframes(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"unsupported default", map[string]string{"SIGNAL_TIMEFRAMES": "2m"}},
+		{"confirmation shorter than entry", map[string]string{"SIGNAL_TIMEFRAMES": "1h,5m"}},
+		{"invalid per-market setting", map[string]string{"SIGNAL_TIMEFRAMES": "5m", "SIGNAL_TIMEFRAMES_KRW_BTC": "5x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRADING_MARKET", "KRW-BTC")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if _, err := NewTradingBot(Config{AccessKey: "ak", SecretKey: "sk"}); err == nil {
+				t.Fatalf("expected an error for %v", tt.env)
+			}
+		})
+	}
+}
+
+// 고정된 5분봉을 최근 순으로 반환하는 캔들 API
+func candleHandler(t *testing.T, starts []time.Time, requested *string) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/candles/minutes/5" {
+			http.NotFound(w, r)
+			return
+		}
+		*requested = r.URL.Query().Get("count")
+		candles := make([]Candle, 0, len(starts))
+		for i := len(starts) - 1; i >= 0; i-- {
+			candles = append(candles, Candle{
+				Market:     "KRW-BTC",
+				StartUTC:   starts[i].Format("2006-01-02T15:04:05"),
+				ClosePrice: float64(100 + i),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(candles)
+	})
+}
+
+func TestFetchClosedCandlesExcludesFormingCandle(t *testing.T) {
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var starts []time.Time
+	for i := 0; i < 5; i++ {
+		starts = append(starts, base.Add(time.Duration(i)*5*time.Minute)) // 00:00 ~ 00:20 시작
+	}
+	var requested string
+	bot := newMockBot(t, candleHandler(t, starts, &requested), nil)
+	tf, _ := parseTimeframe("5m")
+
+	tests := []struct {
+		name       string
+		count      int
+		until      time.Time
+		wantCloses []float64
+		wantLast   time.Time
+	}{
+		// 00:20 캔들은 00:25에 마감되므로 00:22에는 진행 중
+		{"forming candle excluded", 10, base.Add(22 * time.Minute), []float64{100, 101, 102, 103}, base.Add(20 * time.Minute)},
+		// 마감 시각과 같으면 마감된 캔들
+		{"closing exactly at until", 10, base.Add(25 * time.Minute), []float64{100, 101, 102, 103, 104}, base.Add(25 * time.Minute)},
+		// 상위 시간대 확인처럼 과거 시점까지만 사용
+		{"earlier until", 10, base.Add(12 * time.Minute), []float64{100, 101}, base.Add(10 * time.Minute)},
+		{"count keeps the most recent", 2, base.Add(22 * time.Minute), []float64{102, 103}, base.Add(20 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles, closes, err := bot.fetchClosedCandles(context.Background(), "KRW-BTC", tf, tt.count, tt.until)
+			if err != nil {
+				t.Fatalf("fetchClosedCandles failed: %v", err)
+			}
+			if len(candles) != len(tt.wantCloses) || len(closes) != len(candles) {
+				t.Fatalf("got %d candles and %d close times, want %d", len(candles), len(closes), len(tt.wantCloses))
+			}
+			for i, candle := range candles {
+				if candle.ClosePrice != tt.wantCloses[i] {
+					t.Errorf("candle %d: close %f, want %f", i, candle.ClosePrice, tt.wantCloses[i])
+				}
+				if closes[i].After(tt.until) {
+					t.Errorf("candle %d closes at %s, after %s", i, closes[i], tt.until)
+				}
+			}
+			if last := closes[len(closes)-1]; !last.Equal(tt.wantLast) {
+				t.Errorf("last close %s, want %s", last, tt.wantLast)
+			}
+		})
+	}
+
+	// 진행 중인 캔들 하나를 제외할 여유분까지 요청
+	if _, _, err := bot.fetchClosedCandles(context.Background(), "KRW-BTC", tf, 3, base.Add(22*time.Minute)); err != nil {
+		t.Fatalf("fetchClosedCandles failed: %v", err)
+	}
+	if requested != "4" {
+		t.Errorf("requested count %s, want 4", requested)
+	}
+}
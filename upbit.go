@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// 업비트 호출 제한 시간
+const (
+	upbitRequestTimeout = 10 * time.Second // 개별 HTTP 요청
+	reconcileTimeout    = 60 * time.Second // 대조 전체 (여러 요청 포함)
+)
+
+// UpbitSigner 구조체 - 업비트 private API 요청 서명
+// 모든 private 호출은 이 서명기를 통해 JWT(access_key, nonce, query_hash)를 생성한다.
+type UpbitSigner struct {
+	AccessKey string
+	SecretKey string
+}
+
+// 쿼리 문자열 생성
+// encoded는 전송용(URL 인코딩), unescaped는 query_hash 계산용 문자열이다.
+// 키는 정렬되며, 배열 파라미터는 "states[]"처럼 키에 []를 붙여 값마다 반복한다.
+func buildQueryString(values url.Values) (encoded, unescaped string, err error) {
+	encoded = values.Encode()
+	unescaped, err = url.QueryUnescape(encoded)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build query string: %v", err)
+	}
+	return encoded, unescaped, nil
+}
+
+// 쿼리 문자열의 SHA512 해시
+func hashQuery(unescaped string) string {
+	hash := sha512.Sum512([]byte(unescaped))
+	return hex.EncodeToString(hash[:])
+}
+
+// 요청 파라미터로 JWT 토큰 생성 (파라미터가 없으면 query_hash 생략)
+func (s *UpbitSigner) token(values url.Values) (string, error) {
+	claims := Claims{
+		AccessKey: s.AccessKey,
+		Nonce:     uuid.New().String(),
+	}
+
+	if len(values) > 0 {
+		_, unescaped, err := buildQueryString(values)
+		if err != nil {
+			return "", err
+		}
+		claims.QueryHash = hashQuery(unescaped)
+		claims.QueryHashAlg = "SHA512"
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.SecretKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to create JWT token: %v", err)
+	}
+	return signed, nil
+}
+
+// 서명된 private API 요청 생성 (ctx가 취소되면 진행 중인 요청도 중단됨)
+// GET/DELETE는 파라미터를 쿼리 문자열로, POST는 form 본문으로 전송하며 두 경우 모두 같은 문자열을 해시한다.
+func (s *UpbitSigner) newRequest(ctx context.Context, method, apiUrl string, values url.Values) (*http.Request, error) {
+	encoded, _, err := buildQueryString(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var req *http.Request
+	if method == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, method, apiUrl, strings.NewReader(encoded))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		if encoded != "" {
+			apiUrl += "?" + encoded
+		}
+		req, err = http.NewRequestWithContext(ctx, method, apiUrl, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	jwtToken, err := s.token(values)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwtToken)
+	req.Header.Set("Accept", "application/json")
+
+	return req, nil
+}
+
+// UpbitAPIError 구조체 - 업비트 API 오류 응답
+type UpbitAPIError struct {
+	StatusCode int
+	Name       string
+	Message    string
+}
+
+func (e *UpbitAPIError) Error() string {
+	return fmt.Sprintf("API returned error status: %d, error: %s, message: %s", e.StatusCode, e.Name, e.Message)
+}
+
+// 오류 응답 본문 파싱
+func newUpbitAPIError(resp *http.Response) *UpbitAPIError {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	var body struct {
+		Error struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	apiErr := &UpbitAPIError{StatusCode: resp.StatusCode, Message: string(bodyBytes)}
+	if err := json.Unmarshal(bodyBytes, &body); err == nil && body.Error.Name != "" {
+		apiErr.Name = body.Error.Name
+		apiErr.Message = body.Error.Message
+	}
+	return apiErr
+}
+
+// 서명된 private API 호출 후 응답을 out에 디코딩
+func (bot *TradingBot) doPrivate(ctx context.Context, method, path string, values url.Values, out interface{}) error {
+	apiUrl := os.Getenv("UPBIT_OPEN_API_SERVER_URL") + path
+
+	req, err := bot.signer.newRequest(ctx, method, apiUrl, values)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: upbitRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return newUpbitAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
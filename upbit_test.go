@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 업비트 문서의 방식(정렬된 키, 인코딩 전 문자열의 SHA512)으로 미리 계산한 값
+var queryHashVectors = []struct {
+	name      string
+	values    url.Values
+	encoded   string
+	unescaped string
+	hash      string
+}{
+	{
+		name: "order parameters are sorted by key",
+		values: url.Values{
+			"side":     {"bid"},
+			"market":   {"KRW-BTC"},
+			"volume":   {"0.01"},
+			"price":    {"100"},
+			"ord_type": {"limit"},
+		},
+		encoded:   "market=KRW-BTC&ord_type=limit&price=100&side=bid&volume=0.01",
+		unescaped: "market=KRW-BTC&ord_type=limit&price=100&side=bid&volume=0.01",
+		hash:      "1f693bb62795546d53ec95e9731e0cf9c1405bf1e042c0e7b47378d4d530b1800a85b8978cf743639a31bbf64450d2c0d9e6a425accda303b17d507362efb887",
+	},
+	{
+		name: "array parameter keeps value order",
+		values: url.Values{
+			"market":   {"KRW-BTC"},
+			"states[]": {"wait", "watch"},
+		},
+		encoded:   "market=KRW-BTC&states%5B%5D=wait&states%5B%5D=watch",
+		unescaped: "market=KRW-BTC&states[]=wait&states[]=watch",
+		hash:      "c01bbcb80094d2225c90eda65128baf7ef800471fbdeb76579856d1532cd263060e41ede9c52bfc926a0b46c4b7797a61e4327cda59d236f829cde4c875dfe77",
+	},
+	{
+		name: "uuids array",
+		values: url.Values{
+			"uuids[]": {"9ca023a5-851b-4fec-9f0a-48cd83c2eaae", "f10ea3bd-1ea6-4e31-9f6f-68cd4e2bb8d8"},
+		},
+		encoded:   "uuids%5B%5D=9ca023a5-851b-4fec-9f0a-48cd83c2eaae&uuids%5B%5D=f10ea3bd-1ea6-4e31-9f6f-68cd4e2bb8d8",
+		unescaped: "uuids[]=9ca023a5-851b-4fec-9f0a-48cd83c2eaae&uuids[]=f10ea3bd-1ea6-4e31-9f6f-68cd4e2bb8d8",
+		hash:      "ffb6ebd0709e123b218d3ff7091a99deb5e3c7a218451b94665eff0204e8756a9e83b316e9a7be016abb33071adfa3841b63d72bb0c2e0dd635ab421460c9b09",
+	},
+	{
+		name: "escaped characters are hashed unescaped",
+		values: url.Values{
+			"market":     {"KRW-BTC"},
+			"identifier": {"tb a+b/c"},
+		},
+		encoded:   "identifier=tb+a%2Bb%2Fc&market=KRW-BTC",
+		unescaped: "identifier=tb a+b/c&market=KRW-BTC",
+		hash:      "1696bc7f0d6ff9a557ceb3938fdefcc1e1a3d72a9098a981a1336fcfc86bad922d51dcfd6af05df1f593894c058d27246730f6fdc85655f1b8e075885df6cc38",
+	},
+}
+
+func TestBuildQueryStringAndHash(t *testing.T) {
+	for _, tt := range queryHashVectors {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, unescaped, err := buildQueryString(tt.values)
+			if err != nil {
+				t.Fatalf("buildQueryString failed: %v", err)
+			}
+			if encoded != tt.encoded {
+				t.Fatalf("encoded = %q, want %q", encoded, tt.encoded)
+			}
+			if unescaped != tt.unescaped {
+				t.Fatalf("unescaped = %q, want %q", unescaped, tt.unescaped)
+			}
+			if got := hashQuery(unescaped); got != tt.hash {
+				t.Fatalf("query_hash = %s, want %s", got, tt.hash)
+			}
+		})
+	}
+}
+
+// 서명 키로 토큰을 검증하고 claims 반환
+func parseSignedToken(t *testing.T, signed, secret string) *Claims {
+	t.Helper()
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		t.Fatalf("token did not verify: %v", err)
+	}
+	if token.Method != jwt.SigningMethodHS256 {
+		t.Fatalf("signing method = %s, want HS256", token.Method.Alg())
+	}
+	return claims
+}
+
+func TestSignerTokenClaims(t *testing.T) {
+	signer := &UpbitSigner{AccessKey: "test-access-key", SecretKey: "test-secret-key"}
+
+	for _, tt := range queryHashVectors {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := signer.token(tt.values)
+			if err != nil {
+				t.Fatalf("token failed: %v", err)
+			}
+			claims := parseSignedToken(t, signed, signer.SecretKey)
+			if claims.AccessKey != signer.AccessKey {
+				t.Fatalf("access_key = %q, want %q", claims.AccessKey, signer.AccessKey)
+			}
+			if claims.Nonce == "" {
+				t.Fatalf("nonce is empty")
+			}
+			if claims.QueryHash != tt.hash || claims.QueryHashAlg != "SHA512" {
+				t.Fatalf("query_hash = %s (%s), want %s (SHA512)", claims.QueryHash, claims.QueryHashAlg, tt.hash)
+			}
+		})
+	}
+
+	t.Run("no parameters omit query hash", func(t *testing.T) {
+		signed, err := signer.token(nil)
+		if err != nil {
+			t.Fatalf("token failed: %v", err)
+		}
+		payload, err := jwt.DecodeSegment(strings.Split(signed, ".")[1])
+		if err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		if strings.Contains(string(payload), "query_hash") {
+			t.Fatalf("payload %s should not contain query_hash", payload)
+		}
+		claims := parseSignedToken(t, signed, signer.SecretKey)
+		if claims.AccessKey != signer.AccessKey || claims.Nonce == "" {
+			t.Fatalf("claims = %+v", claims)
+		}
+	})
+
+	t.Run("nonce is unique per token", func(t *testing.T) {
+		first, _ := signer.token(nil)
+		second, _ := signer.token(nil)
+		if parseSignedToken(t, first, signer.SecretKey).Nonce == parseSignedToken(t, second, signer.SecretKey).Nonce {
+			t.Fatalf("nonce was reused")
+		}
+	})
+
+	t.Run("wrong secret does not verify", func(t *testing.T) {
+		signed, _ := signer.token(nil)
+		_, err := jwt.ParseWithClaims(signed, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			return []byte("other-secret"), nil
+		})
+		if err == nil {
+			t.Fatalf("token verified with the wrong secret")
+		}
+	})
+}
+
+func TestSignerNewRequest(t *testing.T) {
+	signer := &UpbitSigner{AccessKey: "test-access-key", SecretKey: "test-secret-key"}
+	ctx := context.Background()
+
+	for _, tt := range queryHashVectors {
+		t.Run(tt.name, func(t *testing.T) {
+			// GET: 쿼리 문자열로 전송
+			req, err := signer.newRequest(ctx, http.MethodGet, "https://api.upbit.com/v1/orders", tt.values)
+			if err != nil {
+				t.Fatalf("newRequest failed: %v", err)
+			}
+			if req.URL.RawQuery != tt.encoded {
+				t.Fatalf("query = %q, want %q", req.URL.RawQuery, tt.encoded)
+			}
+			claims := parseSignedToken(t, strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), signer.SecretKey)
+			if claims.QueryHash != tt.hash {
+				t.Fatalf("GET query_hash = %s, want %s", claims.QueryHash, tt.hash)
+			}
+
+			// POST: 같은 문자열을 form 본문으로 전송
+			req, err = signer.newRequest(ctx, http.MethodPost, "https://api.upbit.com/v1/orders", tt.values)
+			if err != nil {
+				t.Fatalf("newRequest failed: %v", err)
+			}
+			body, _ := io.ReadAll(req.Body)
+			if string(body) != tt.encoded || req.URL.RawQuery != "" {
+				t.Fatalf("body = %q, query = %q, want body %q", body, req.URL.RawQuery, tt.encoded)
+			}
+			if req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+				t.Fatalf("content type = %q", req.Header.Get("Content-Type"))
+			}
+			claims = parseSignedToken(t, strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), signer.SecretKey)
+			if claims.QueryHash != tt.hash {
+				t.Fatalf("POST query_hash = %s, want %s", claims.QueryHash, tt.hash)
+			}
+		})
+	}
+}
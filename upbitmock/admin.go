@@ -0,0 +1,99 @@
+package upbitmock
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// 스크립트 제어용 관리 엔드포인트
+//
+//	POST /mock/prices   {"market": "KRW-BTC", "prices": [...], "volume": 1e10}
+//	POST /mock/balances {"currency": "KRW", "balance": 1000000, "avg_buy_price": 0}
+//	POST /mock/markets  {"market": "KRW-BTC", "korean_name": "...", "english_name": "...", "market_event": {...}}
+//	POST /mock/failures {"method": "POST", "path": "/v1/orders", "status": 500, "count": 1}
+//	GET  /mock/state    현재 잔고와 주문 목록
+func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/mock/state" && r.Method == http.MethodGet {
+		s.handleState(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use POST")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/mock/prices":
+		var req struct {
+			Market string    `json:"market"`
+			Prices []float64 `json:"prices"`
+			Volume float64   `json:"volume"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Market == "" {
+			writeError(w, http.StatusBadRequest, "validation_error", "market and prices are required")
+			return
+		}
+		s.SetPricePath(req.Market, req.Prices...)
+		if req.Volume > 0 {
+			s.SetVolume(req.Market, req.Volume)
+		}
+	case "/mock/balances":
+		var req struct {
+			Currency    string  `json:"currency"`
+			Balance     float64 `json:"balance"`
+			AvgBuyPrice float64 `json:"avg_buy_price"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Currency == "" {
+			writeError(w, http.StatusBadRequest, "validation_error", "currency is required")
+			return
+		}
+		s.SetBalance(req.Currency, req.Balance, req.AvgBuyPrice)
+	case "/mock/markets":
+		var market Market
+		if err := json.NewDecoder(r.Body).Decode(&market); err != nil || market.Market == "" {
+			writeError(w, http.StatusBadRequest, "validation_error", "market is required")
+			return
+		}
+		s.AddMarket(market)
+	case "/mock/failures":
+		var failure Failure
+		if err := json.NewDecoder(r.Body).Decode(&failure); err != nil {
+			writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		s.InjectFailure(failure)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "unknown mock endpoint")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleState(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balances := make(map[string]map[string]float64, len(s.balances))
+	for currency, b := range s.balances {
+		balances[currency] = map[string]float64{
+			"balance":       b.Balance,
+			"locked":        b.Locked,
+			"avg_buy_price": b.AvgBuyPrice,
+		}
+	}
+	orders := make([]map[string]interface{}, 0, len(s.orderList))
+	for _, id := range s.orderList {
+		orders = append(orders, s.orders[id].response(true))
+	}
+	prices := make(map[string]float64, len(s.prices))
+	for market := range s.prices {
+		prices[market] = s.currentPrice(market)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"balances": balances,
+		"orders":   orders,
+		"prices":   prices,
+	})
+}
@@ -0,0 +1,166 @@
+package upbitmock
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 인증 오류 - 업비트와 같은 이름의 오류 코드로 응답
+type authError struct {
+	name    string
+	message string
+}
+
+func (e *authError) Error() string {
+	return e.name + ": " + e.message
+}
+
+// 요청의 JWT와 query_hash 검증
+// GET/DELETE는 쿼리 문자열, POST는 본문 파라미터를 unescape한 "k=v&..." 문자열을 해시한다.
+func (s *Server) authenticate(r *http.Request) error {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return &authError{"jwt_verification", "authorization header required"}
+	}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 && token.Method != jwt.SigningMethodHS512 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.secretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return &authError{"jwt_verification", "failed to verify jwt token"}
+	}
+
+	accessKey, _ := claims["access_key"].(string)
+	if accessKey != s.accessKey {
+		return &authError{"invalid_access_key", "unknown access key"}
+	}
+
+	nonce, _ := claims["nonce"].(string)
+	if nonce == "" {
+		return &authError{"jwt_verification", "nonce is required"}
+	}
+	s.mu.Lock()
+	used := s.nonces[nonce]
+	s.nonces[nonce] = true
+	s.mu.Unlock()
+	if used {
+		return &authError{"nonce_used", "nonce has already been used"}
+	}
+
+	query, err := requestQuery(r)
+	if err != nil {
+		return &authError{"invalid_query_payload", err.Error()}
+	}
+
+	queryHash, _ := claims["query_hash"].(string)
+	if query == "" {
+		if queryHash != "" {
+			return &authError{"invalid_query_payload", "query_hash given without query"}
+		}
+		return nil
+	}
+
+	if alg, _ := claims["query_hash_alg"].(string); alg != "" && alg != "SHA512" {
+		return &authError{"invalid_query_payload", "unsupported query_hash_alg"}
+	}
+	sum := sha512.Sum512([]byte(query))
+	if queryHash != hex.EncodeToString(sum[:]) {
+		return &authError{"invalid_query_payload", "query_hash mismatch"}
+	}
+
+	return nil
+}
+
+// 해시 대상 쿼리 문자열 복원 (요청 본문은 핸들러에서 다시 읽을 수 있도록 되돌려 놓음)
+func requestQuery(r *http.Request) (string, error) {
+	if r.Method != http.MethodPost {
+		return url.QueryUnescape(r.URL.RawQuery)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return jsonQuery(body)
+	}
+	return url.QueryUnescape(string(body))
+}
+
+// JSON 본문을 필드 순서대로 "k=v&..." 문자열로 변환
+func jsonQuery(body []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return "", fmt.Errorf("request body must be a JSON object")
+	}
+
+	var pairs []string
+	for decoder.More() {
+		keyTok, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		key, _ := keyTok.(string)
+
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return "", err
+		}
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				pairs = append(pairs, fmt.Sprintf("%s[]=%v", key, item))
+			}
+		default:
+			pairs = append(pairs, fmt.Sprintf("%s=%v", key, v))
+		}
+	}
+
+	return strings.Join(pairs, "&"), nil
+}
+
+// 요청 파라미터 조회 (POST는 form 또는 JSON 본문, 그 외는 쿼리 문자열)
+func requestParams(r *http.Request) (url.Values, error) {
+	if r.Method != http.MethodPost {
+		return r.URL.Query(), nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return url.ParseQuery(string(body))
+	}
+
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	for key, value := range raw {
+		values.Set(key, fmt.Sprint(value))
+	}
+	return values, nil
+}
@@ -0,0 +1,448 @@
+package upbitmock
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type trade struct {
+	Price     float64
+	Volume    float64
+	Funds     float64
+	CreatedAt time.Time
+}
+
+type order struct {
+	UUID            string
+	Side            string
+	OrdType         string
+	Market          string
+	State           string
+	Identifier      string
+	TimeInForce     string
+	Price           float64
+	Volume          float64
+	RemainingVolume float64
+	ExecutedVolume  float64
+	PaidFee         float64
+	Locked          float64
+	CreatedAt       time.Time
+	Trades          []trade
+}
+
+// 주문 응답 형식 (업비트는 숫자를 문자열로 반환)
+func (o *order) response(withTrades bool) map[string]interface{} {
+	resp := map[string]interface{}{
+		"uuid":             o.UUID,
+		"side":             o.Side,
+		"ord_type":         o.OrdType,
+		"price":            formatNumber(o.Price),
+		"state":            o.State,
+		"market":           o.Market,
+		"created_at":       o.CreatedAt.In(kst).Format(time.RFC3339),
+		"volume":           formatNumber(o.Volume),
+		"remaining_volume": formatNumber(o.RemainingVolume),
+		"executed_volume":  formatNumber(o.ExecutedVolume),
+		"paid_fee":         formatNumber(o.PaidFee),
+		"locked":           formatNumber(o.Locked),
+		"trades_count":     len(o.Trades),
+	}
+	if o.Identifier != "" {
+		resp["identifier"] = o.Identifier
+	}
+	if o.TimeInForce != "" {
+		resp["time_in_force"] = o.TimeInForce
+	}
+	if withTrades {
+		trades := make([]map[string]interface{}, 0, len(o.Trades))
+		for _, t := range o.Trades {
+			trades = append(trades, map[string]interface{}{
+				"market":     o.Market,
+				"uuid":       uuid.New().String(),
+				"price":      formatNumber(t.Price),
+				"volume":     formatNumber(t.Volume),
+				"funds":      formatNumber(t.Funds),
+				"side":       o.Side,
+				"created_at": t.CreatedAt.In(kst).Format(time.RFC3339),
+			})
+		}
+		resp["trades"] = trades
+	}
+	return resp
+}
+
+// GET /v1/accounts
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	currencies := make([]string, 0, len(s.balances))
+	for currency := range s.balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	accounts := make([]map[string]interface{}, 0, len(currencies))
+	for _, currency := range currencies {
+		b := s.balances[currency]
+		if b.Balance == 0 && b.Locked == 0 && currency != "KRW" {
+			continue
+		}
+		accounts = append(accounts, map[string]interface{}{
+			"currency":               currency,
+			"balance":                formatNumber(b.Balance),
+			"locked":                 formatNumber(b.Locked),
+			"avg_buy_price":          formatNumber(b.AvgBuyPrice),
+			"avg_buy_price_modified": false,
+			"unit_currency":          "KRW",
+		})
+	}
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+// POST /v1/orders
+func (s *Server) handlePlaceOrder(w http.ResponseWriter, r *http.Request) {
+	params, err := requestParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	o := &order{
+		UUID:        uuid.New().String(),
+		Side:        params.Get("side"),
+		OrdType:     params.Get("ord_type"),
+		Market:      params.Get("market"),
+		Identifier:  params.Get("identifier"),
+		TimeInForce: params.Get("time_in_force"),
+		State:       "wait",
+		CreatedAt:   time.Now(),
+	}
+	o.Price, _ = strconv.ParseFloat(params.Get("price"), 64)
+	o.Volume, _ = strconv.ParseFloat(params.Get("volume"), 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status, name, message := s.validateOrder(o); status != 0 {
+		writeError(w, status, name, message)
+		return
+	}
+
+	if status, name, message := s.acceptOrder(o); status != 0 {
+		writeError(w, status, name, message)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, o.response(false))
+}
+
+// 주문 파라미터 검증
+func (s *Server) validateOrder(o *order) (int, string, string) {
+	if _, ok := s.prices[o.Market]; !ok {
+		return http.StatusBadRequest, "validation_error", "market does not exist"
+	}
+	if o.Side != "bid" && o.Side != "ask" {
+		return http.StatusBadRequest, "validation_error", "side must be bid or ask"
+	}
+	if o.TimeInForce != "" && o.TimeInForce != "ioc" && o.TimeInForce != "fok" {
+		return http.StatusBadRequest, "validation_error", "invalid time_in_force"
+	}
+	if o.Identifier != "" {
+		if _, exists := s.identifiers[o.Identifier]; exists {
+			return http.StatusBadRequest, "duplicate_identifier", "identifier already used"
+		}
+	}
+
+	switch o.OrdType {
+	case "limit":
+		if o.Price <= 0 || o.Volume <= 0 {
+			return http.StatusBadRequest, "validation_error", "limit order requires price and volume"
+		}
+	case "price":
+		if o.Side != "bid" || o.Price <= 0 || o.Volume != 0 {
+			return http.StatusBadRequest, "validation_error", "price order requires bid side and price only"
+		}
+	case "market":
+		if o.Side != "ask" || o.Volume <= 0 || o.Price != 0 {
+			return http.StatusBadRequest, "validation_error", "market order requires ask side and volume only"
+		}
+	case "best":
+		if o.TimeInForce == "" {
+			return http.StatusBadRequest, "validation_error", "best order requires time_in_force"
+		}
+		if (o.Side == "bid" && (o.Price <= 0 || o.Volume != 0)) || (o.Side == "ask" && (o.Volume <= 0 || o.Price != 0)) {
+			return http.StatusBadRequest, "validation_error", "invalid best order parameters"
+		}
+	default:
+		return http.StatusBadRequest, "validation_error", "invalid ord_type"
+	}
+
+	return 0, "", ""
+}
+
+// 주문 접수 - 잔고 확인 후 즉시 체결 가능한 부분을 처리
+func (s *Server) acceptOrder(o *order) (int, string, string) {
+	price := s.currentPrice(o.Market)
+	if price <= 0 {
+		return http.StatusBadRequest, "validation_error", "no price for market"
+	}
+	quote, base := splitMarket(o.Market)
+
+	// 최소 주문 금액 및 잔고 확인
+	total := o.Price * o.Volume
+	switch o.OrdType {
+	case "price":
+		total = o.Price
+	case "market":
+		total = o.Volume * price
+	case "best":
+		if o.Side == "bid" {
+			total = o.Price
+		} else {
+			total = o.Volume * price
+		}
+	}
+	if total < minOrderKRW {
+		return http.StatusBadRequest, "under_min_total_" + o.Side, "order total is below the minimum"
+	}
+
+	if o.Side == "bid" {
+		required := total * (1 + s.FeeRate)
+		if s.balance(quote).Balance < required {
+			return http.StatusBadRequest, "insufficient_funds_bid", "insufficient KRW balance"
+		}
+	} else if s.balance(base).Balance < o.Volume {
+		return http.StatusBadRequest, "insufficient_funds_ask", "insufficient " + base + " balance"
+	}
+
+	s.orders[o.UUID] = o
+	s.orderList = append(s.orderList, o.UUID)
+	if o.Identifier != "" {
+		s.identifiers[o.Identifier] = o.UUID
+	}
+
+	switch o.OrdType {
+	case "price", "best":
+		// 시장가/최유리 주문은 현재가로 즉시 전량 체결
+		if o.Side == "bid" {
+			o.Volume = 0
+			s.fill(o, price, o.Price/price)
+		} else {
+			o.RemainingVolume = o.Volume
+			s.fill(o, price, o.Volume)
+		}
+	case "market":
+		o.RemainingVolume = o.Volume
+		s.fill(o, price, o.Volume)
+	case "limit":
+		o.RemainingVolume = o.Volume
+		if o.Side == "bid" {
+			o.Locked = o.Price * o.Volume * (1 + s.FeeRate)
+			s.balance(quote).Balance -= o.Locked
+			s.balance(quote).Locked += o.Locked
+		} else {
+			o.Locked = o.Volume
+			s.balance(base).Balance -= o.Volume
+			s.balance(base).Locked += o.Volume
+		}
+
+		if marketable(o, price) {
+			s.fill(o, o.Price, o.RemainingVolume)
+		} else if o.TimeInForce != "" {
+			// ioc/fok 지정가 주문은 즉시 체결되지 않으면 취소
+			s.cancel(o)
+		}
+	}
+
+	return 0, "", ""
+}
+
+// 지정가 주문의 체결 가능 여부
+func marketable(o *order, price float64) bool {
+	if o.Side == "bid" {
+		return price <= o.Price
+	}
+	return price >= o.Price
+}
+
+// 가격 변화 후 미체결 지정가 주문 체결
+func (s *Server) matchOrders(market string, price float64) {
+	for _, id := range s.orderList {
+		o := s.orders[id]
+		if o.Market != market || o.State != "wait" || o.OrdType != "limit" {
+			continue
+		}
+		if marketable(o, price) {
+			s.fill(o, o.Price, o.RemainingVolume)
+		}
+	}
+}
+
+// 주문 체결 처리 - 잔고와 수수료 반영
+func (s *Server) fill(o *order, price, volume float64) {
+	quote, base := splitMarket(o.Market)
+	funds := price * volume
+	fee := funds * s.FeeRate
+
+	if o.Side == "bid" {
+		cost := funds + fee
+		if o.Locked > 0 {
+			o.Locked -= cost
+			s.balance(quote).Locked -= cost
+		} else {
+			s.balance(quote).Balance -= cost
+		}
+		b := s.balance(base)
+		held := b.Balance + b.Locked
+		b.AvgBuyPrice = (b.AvgBuyPrice*held + funds) / (held + volume)
+		b.Balance += volume
+	} else {
+		if o.Locked > 0 {
+			o.Locked -= volume
+			s.balance(base).Locked -= volume
+		} else {
+			s.balance(base).Balance -= volume
+		}
+		s.balance(quote).Balance += funds - fee
+	}
+
+	o.ExecutedVolume += volume
+	o.RemainingVolume -= volume
+	if o.RemainingVolume < 1e-12 {
+		o.RemainingVolume = 0
+	}
+	o.PaidFee += fee
+	o.Trades = append(o.Trades, trade{Price: price, Volume: volume, Funds: funds, CreatedAt: time.Now()})
+
+	if o.RemainingVolume == 0 {
+		o.State = "done"
+		s.release(o)
+	}
+}
+
+// 주문 취소 - 묶인 잔고 해제
+func (s *Server) cancel(o *order) {
+	o.State = "cancel"
+	s.release(o)
+}
+
+func (s *Server) release(o *order) {
+	if o.Locked <= 0 {
+		o.Locked = 0
+		return
+	}
+	quote, base := splitMarket(o.Market)
+	currency := base
+	if o.Side == "bid" {
+		currency = quote
+	}
+	s.balance(currency).Locked -= o.Locked
+	s.balance(currency).Balance += o.Locked
+	o.Locked = 0
+}
+
+// GET /v1/order?uuid= 또는 ?identifier=
+func (s *Server) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findOrder(r)
+	if o == nil {
+		writeError(w, http.StatusNotFound, "order_not_found", "order not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, o.response(true))
+}
+
+// DELETE /v1/order?uuid= 또는 ?identifier=
+func (s *Server) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findOrder(r)
+	if o == nil {
+		writeError(w, http.StatusNotFound, "order_not_found", "order not found")
+		return
+	}
+	if o.State != "wait" {
+		writeError(w, http.StatusBadRequest, "order_not_cancellable", "order is not waiting")
+		return
+	}
+	s.cancel(o)
+	writeJSON(w, http.StatusOK, o.response(false))
+}
+
+// GET /v1/orders?state=wait|done|cancel&states[]=...&market=&limit=&order_by=
+func (s *Server) handleListOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	states := make(map[string]bool)
+	for _, state := range append(query["states[]"], query["state"]...) {
+		states[state] = true
+	}
+	if len(states) == 0 {
+		states["wait"] = true
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	skip := (page - 1) * limit
+	market := query.Get("market")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orders := make([]map[string]interface{}, 0)
+	for i := range s.orderList {
+		id := s.orderList[i]
+		if query.Get("order_by") != "asc" {
+			id = s.orderList[len(s.orderList)-1-i]
+		}
+		o := s.orders[id]
+		if !states[o.State] || (market != "" && o.Market != market) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		orders = append(orders, o.response(false))
+		if len(orders) >= limit {
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (s *Server) findOrder(r *http.Request) *order {
+	query := r.URL.Query()
+	id := query.Get("uuid")
+	if id == "" {
+		id = s.identifiers[query.Get("identifier")]
+	}
+	return s.orders[id]
+}
+
+func splitMarket(market string) (quote, base string) {
+	parts := strings.SplitN(market, "-", 2)
+	if len(parts) != 2 {
+		return "", market
+	}
+	return parts[0], parts[1]
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,231 @@
+package upbitmock
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var kst = time.FixedZone("KST", 9*60*60)
+
+// GET /v1/market/all
+func (s *Server) handleMarkets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	markets := append([]Market(nil), s.markets...)
+	s.mu.Unlock()
+
+	if r.URL.Query().Get("is_details") != "true" {
+		for i := range markets {
+			markets[i].MarketEvent = MarketEvent{}
+		}
+	}
+	writeJSON(w, http.StatusOK, markets)
+}
+
+// GET /v1/ticker?markets=KRW-BTC,KRW-ETH - 조회할 때마다 가격 경로를 한 단계 진행
+func (s *Server) handleTicker(w http.ResponseWriter, r *http.Request) {
+	markets := splitMarkets(r.URL.Query().Get("markets"))
+	if len(markets) == 0 {
+		writeError(w, http.StatusBadRequest, "validation_error", "markets is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tickers := make([]map[string]interface{}, 0, len(markets))
+	for _, market := range markets {
+		path, ok := s.prices[market]
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found_market", "market not found: "+market)
+			return
+		}
+		price := s.advance(market)
+
+		history := path.History
+		if len(history) > 24 {
+			history = history[len(history)-24:]
+		}
+		open, high, low := price, price, price
+		if len(history) > 0 {
+			open = history[0]
+		}
+		for _, p := range history {
+			high = math.Max(high, p)
+			low = math.Min(low, p)
+		}
+
+		change, changeRate := "EVEN", 0.0
+		if open > 0 {
+			changeRate = (price - open) / open
+		}
+		if changeRate > 0 {
+			change = "RISE"
+		} else if changeRate < 0 {
+			change = "FALL"
+		}
+
+		volume := path.Volume
+		tickers = append(tickers, map[string]interface{}{
+			"market":               market,
+			"trade_price":          price,
+			"opening_price":        open,
+			"high_price":           high,
+			"low_price":            low,
+			"prev_closing_price":   open,
+			"change":               change,
+			"signed_change_rate":   changeRate,
+			"acc_trade_price_24h":  volume,
+			"acc_trade_volume_24h": safeDiv(volume, price),
+			"timestamp":            time.Now().UnixMilli(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, tickers)
+}
+
+// GET /v1/orderbook?markets=KRW-BTC - 현재가 주변에 스프레드를 둔 가상 호가 생성
+func (s *Server) handleOrderbook(w http.ResponseWriter, r *http.Request) {
+	markets := splitMarkets(r.URL.Query().Get("markets"))
+	if len(markets) == 0 {
+		writeError(w, http.StatusBadRequest, "validation_error", "markets is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	books := make([]map[string]interface{}, 0, len(markets))
+	for _, market := range markets {
+		price := s.currentPrice(market)
+		if price <= 0 {
+			writeError(w, http.StatusNotFound, "not_found_market", "market not found: "+market)
+			return
+		}
+
+		step := price * s.SpreadBps / 10000
+		levelSize := safeDiv(s.pricePath(market).Volume/1000, price)
+		if levelSize <= 0 {
+			levelSize = 1
+		}
+
+		units := make([]map[string]float64, 0, 15)
+		totalAsk, totalBid := 0.0, 0.0
+		for i := 0; i < 15; i++ {
+			size := levelSize * float64(i+1)
+			units = append(units, map[string]float64{
+				"ask_price": price + step/2 + step*float64(i),
+				"bid_price": price - step/2 - step*float64(i),
+				"ask_size":  size,
+				"bid_size":  size,
+			})
+			totalAsk += size
+			totalBid += size
+		}
+
+		books = append(books, map[string]interface{}{
+			"market":          market,
+			"timestamp":       time.Now().UnixMilli(),
+			"total_ask_size":  totalAsk,
+			"total_bid_size":  totalBid,
+			"orderbook_units": units,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, books)
+}
+
+// GET /v1/candles/minutes/{unit}, /v1/candles/days - 소비된 가격을 캔들 하나씩으로 취급
+// 가장 최근 캔들은 현재 진행 중인 캔들로 반환한다.
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/v1/candles/")
+	var unit time.Duration
+	unitValue := 0
+	switch {
+	case rest == "days":
+		unit = 24 * time.Hour
+	case strings.HasPrefix(rest, "minutes/"):
+		minutes, err := strconv.Atoi(strings.TrimPrefix(rest, "minutes/"))
+		if err != nil || minutes <= 0 {
+			writeError(w, http.StatusBadRequest, "validation_error", "invalid candle unit")
+			return
+		}
+		unit = time.Duration(minutes) * time.Minute
+		unitValue = minutes
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "unknown candle type")
+		return
+	}
+
+	market := r.URL.Query().Get("market")
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count <= 0 {
+		count = 1
+	}
+	if count > 200 {
+		count = 200
+	}
+
+	s.mu.Lock()
+	path, ok := s.prices[market]
+	var history []float64
+	if ok {
+		history = append(history, path.History...)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found_market", "market not found: "+market)
+		return
+	}
+
+	now := time.Now().UTC()
+	current := now.Truncate(unit)
+	candles := make([]map[string]interface{}, 0, count)
+	for i := 0; i < count && i < len(history); i++ {
+		idx := len(history) - 1 - i
+		closePrice := history[idx]
+		openPrice := closePrice
+		if idx > 0 {
+			openPrice = history[idx-1]
+		}
+		start := current.Add(-time.Duration(i) * unit)
+
+		candle := map[string]interface{}{
+			"market":                  market,
+			"candle_date_time_utc":    start.Format("2006-01-02T15:04:05"),
+			"candle_date_time_kst":    start.In(kst).Format("2006-01-02T15:04:05"),
+			"opening_price":           openPrice,
+			"high_price":              math.Max(openPrice, closePrice),
+			"low_price":               math.Min(openPrice, closePrice),
+			"trade_price":             closePrice,
+			"timestamp":               now.UnixMilli(),
+			"candle_acc_trade_price":  0.0,
+			"candle_acc_trade_volume": 0.0,
+		}
+		if unitValue > 0 {
+			candle["unit"] = unitValue
+		}
+		candles = append(candles, candle)
+	}
+
+	writeJSON(w, http.StatusOK, candles)
+}
+
+func splitMarkets(value string) []string {
+	var markets []string
+	for _, market := range strings.Split(value, ",") {
+		if market = strings.TrimSpace(market); market != "" {
+			markets = append(markets, market)
+		}
+	}
+	return markets
+}
+
+func safeDiv(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
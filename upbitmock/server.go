@@ -0,0 +1,311 @@
+// Package upbitmock은 통합 테스트용 가짜 업비트 HTTP 서버를 제공한다.
+// 업비트와 같은 방식으로 JWT와 query_hash를 검증하고, 메모리 잔고와 주문을 관리하며,
+// 가격 경로와 장애 주입을 스크립트로 제어할 수 있다.
+package upbitmock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 기본 거래 수수료율
+const DefaultFeeRate = 0.0005
+
+// 업비트 KRW 마켓 최소 주문 금액
+const minOrderKRW = 5000.0
+
+// MarketEvent 구조체 - 마켓 경고 정보
+type MarketEvent struct {
+	Warning bool   `json:"warning"`
+	Caution string `json:"caution,omitempty"`
+}
+
+// Market 구조체 - /v1/market/all 응답 항목
+type Market struct {
+	Market      string      `json:"market"`
+	KoreanName  string      `json:"korean_name"`
+	EnglishName string      `json:"english_name"`
+	MarketEvent MarketEvent `json:"market_event"`
+}
+
+// Failure 구조체 - 요청에 주입할 장애
+type Failure struct {
+	Method  string `json:"method"`  // 비어 있으면 모든 메서드
+	Path    string `json:"path"`    // 경로 접두사 (예: /v1/orders)
+	Status  int    `json:"status"`  // 응답 상태 코드
+	Count   int    `json:"count"`   // 적용 횟수 (0 이하이면 1회)
+	Name    string `json:"name"`    // 오류 이름
+	Delay   string `json:"delay"`   // 응답 지연 (예: "2s")
+	Message string `json:"message"` // 오류 메시지
+}
+
+type balance struct {
+	Balance     float64
+	Locked      float64
+	AvgBuyPrice float64
+}
+
+type pricePath struct {
+	Prices  []float64 // 앞으로 소비할 가격
+	History []float64 // 이미 소비된 가격
+	Volume  float64   // 24시간 누적 거래 대금 (KRW)
+}
+
+// Server 구조체 - 가짜 업비트 서버 상태
+type Server struct {
+	mu sync.Mutex
+
+	accessKey string
+	secretKey string
+
+	FeeRate   float64 // 거래 수수료율
+	SpreadBps float64 // 호가 스프레드 (bp)
+
+	balances    map[string]*balance
+	markets     []Market
+	prices      map[string]*pricePath
+	orders      map[string]*order
+	orderList   []string // 주문 생성 순서
+	identifiers map[string]string
+	nonces      map[string]bool
+	failures    []*Failure
+}
+
+// 가짜 업비트 서버 생성
+func NewServer(accessKey, secretKey string) *Server {
+	return &Server{
+		accessKey:   accessKey,
+		secretKey:   secretKey,
+		FeeRate:     DefaultFeeRate,
+		SpreadBps:   5,
+		balances:    make(map[string]*balance),
+		prices:      make(map[string]*pricePath),
+		orders:      make(map[string]*order),
+		identifiers: make(map[string]string),
+		nonces:      make(map[string]bool),
+	}
+}
+
+// 통화 잔고 설정
+func (s *Server) SetBalance(currency string, amount float64, avgBuyPrice float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.balance(currency)
+	b.Balance = amount
+	b.AvgBuyPrice = avgBuyPrice
+}
+
+// 마켓 추가 (이미 있으면 정보 갱신)
+func (s *Server) AddMarket(market Market) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.markets {
+		if s.markets[i].Market == market.Market {
+			s.markets[i] = market
+			return
+		}
+	}
+	s.markets = append(s.markets, market)
+	if _, ok := s.prices[market.Market]; !ok {
+		s.prices[market.Market] = &pricePath{}
+	}
+}
+
+// 가격 경로 설정 - 시세 조회마다 한 단계씩 진행하고, 끝나면 마지막 가격을 유지
+func (s *Server) SetPricePath(market string, prices ...float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.pricePath(market)
+	path.Prices = append([]float64(nil), prices...)
+}
+
+// 24시간 누적 거래 대금 설정
+func (s *Server) SetVolume(market string, volume float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pricePath(market).Volume = volume
+}
+
+// 가격을 한 단계 진행하고 체결 가능한 지정가 주문 처리
+func (s *Server) Advance(market string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.advance(market)
+}
+
+// 현재 가격 조회 (진행하지 않음)
+func (s *Server) Price(market string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.currentPrice(market)
+}
+
+// 장애 주입
+func (s *Server) InjectFailure(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if failure.Count <= 0 {
+		failure.Count = 1
+	}
+	if failure.Status == 0 {
+		failure.Status = http.StatusInternalServerError
+	}
+	s.failures = append(s.failures, &failure)
+}
+
+// HTTP 요청 처리
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/mock/") {
+		s.handleAdmin(w, r)
+		return
+	}
+
+	if failure := s.takeFailure(r); failure != nil {
+		if delay, err := time.ParseDuration(failure.Delay); err == nil {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		name := failure.Name
+		if name == "" {
+			name = "injected_failure"
+		}
+		writeError(w, failure.Status, name, failure.Message)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/market/all" && r.Method == http.MethodGet:
+		s.handleMarkets(w, r)
+	case r.URL.Path == "/v1/ticker" && r.Method == http.MethodGet:
+		s.handleTicker(w, r)
+	case r.URL.Path == "/v1/orderbook" && r.Method == http.MethodGet:
+		s.handleOrderbook(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/candles/") && r.Method == http.MethodGet:
+		s.handleCandles(w, r)
+	case r.URL.Path == "/v1/accounts" && r.Method == http.MethodGet:
+		s.private(w, r, s.handleAccounts)
+	case r.URL.Path == "/v1/orders" && r.Method == http.MethodPost:
+		s.private(w, r, s.handlePlaceOrder)
+	case r.URL.Path == "/v1/orders" && r.Method == http.MethodGet:
+		s.private(w, r, s.handleListOrders)
+	case r.URL.Path == "/v1/order" && r.Method == http.MethodGet:
+		s.private(w, r, s.handleGetOrder)
+	case r.URL.Path == "/v1/order" && r.Method == http.MethodDelete:
+		s.private(w, r, s.handleCancelOrder)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "unknown endpoint")
+	}
+}
+
+// 인증이 필요한 요청 처리
+func (s *Server) private(w http.ResponseWriter, r *http.Request, handler http.HandlerFunc) {
+	if err := s.authenticate(r); err != nil {
+		name, message := "jwt_verification", err.Error()
+		if authErr, ok := err.(*authError); ok {
+			name, message = authErr.name, authErr.message
+		}
+		writeError(w, http.StatusUnauthorized, name, message)
+		return
+	}
+	handler(w, r)
+}
+
+// 요청과 일치하는 주입 장애 꺼내기
+func (s *Server) takeFailure(r *http.Request) *Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, failure := range s.failures {
+		if failure.Method != "" && failure.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, failure.Path) {
+			continue
+		}
+		failure.Count--
+		if failure.Count <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		copied := *failure
+		return &copied
+	}
+	return nil
+}
+
+func (s *Server) balance(currency string) *balance {
+	b, ok := s.balances[currency]
+	if !ok {
+		b = &balance{}
+		s.balances[currency] = b
+	}
+	return b
+}
+
+func (s *Server) pricePath(market string) *pricePath {
+	path, ok := s.prices[market]
+	if !ok {
+		path = &pricePath{}
+		s.prices[market] = path
+	}
+	return path
+}
+
+func (s *Server) currentPrice(market string) float64 {
+	path, ok := s.prices[market]
+	if !ok {
+		return 0
+	}
+	if len(path.History) > 0 {
+		return path.History[len(path.History)-1]
+	}
+	if len(path.Prices) > 0 {
+		return path.Prices[0]
+	}
+	return 0
+}
+
+func (s *Server) advance(market string) float64 {
+	path, ok := s.prices[market]
+	if !ok {
+		return 0
+	}
+	if len(path.Prices) > 0 {
+		path.History = append(path.History, path.Prices[0])
+		path.Prices = path.Prices[1:]
+		if len(path.History) > 1000 {
+			path.History = path.History[len(path.History)-1000:]
+		}
+	}
+	price := s.currentPrice(market)
+	s.matchOrders(market, price)
+	return price
+}
+
+// 업비트 형식의 오류 응답
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{
+			"name":    name,
+			"message": message,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}